@@ -4,15 +4,17 @@ import (
 	"GoVersi/internal/config"
 	"GoVersi/internal/handlers"
 	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/media"
 	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
 	"GoVersi/internal/repository"
 	"GoVersi/internal/routes"
+	"GoVersi/internal/search"
 	services "GoVersi/internal/service"
 	"GoVersi/internal/service/email"
-	"encoding/json"
+	"GoVersi/internal/storage"
 
 	/* "encoding/json" */
-	"os"
 
 	"log"
 
@@ -22,84 +24,160 @@ import (
 )
 
 func main() {
-	rabbitMQ, err := queue.NewRabbitMQ()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer rabbitMQ.Close()
-
-	// Start processing RabbitMQ messages in a separate goroutine
-	go processRabbitMQMessages(rabbitMQ)
-
 	// Load environment variables
 	loadEnv()
 
+	rabbitMQ := newQueue(config.LoadQueueConfig())
+	defer rabbitMQ.Close()
+
 	// Database connection
 	db := connectDatabase()
 
+	storageConfig := config.LoadStorageConfig()
+	rawStore, mediaHandler := newBlobStore(storageConfig)
+	mediaObjectRepository := repository.NewMediaObjectRepository(db)
+	blobStore := storage.NewTrackedStore(rawStore, mediaObjectRepository)
+	models.SetMediaURLSigner(storage.URLSigner(blobStore, storageConfig.URLTTL))
+
+	mediaService := services.NewMediaService(mediaObjectRepository, blobStore, storageConfig.GCGrace)
+	mediaService.StartCronJob()
+	defer mediaService.StopCronJob()
+
+	searchConfig := config.LoadSearchConfig()
+	searchIndex := newSearchIndex(db, searchConfig)
+
+	// side effects are written to the outbox and relayed to RabbitMQ once committed
+	outboxRepository := repository.NewOutboxRepository(db)
+	outboxRelay := services.NewOutboxRelay(outboxRepository, rabbitMQ, config.LoadOutboxConfig())
+	outboxRelay.Start()
+	defer outboxRelay.Stop()
+
+	imageQueue := media.NewImageQueue(outboxRepository)
+	imageConfig := config.LoadImageConfig()
+	imageService := services.NewImageService(blobStore, repository.NewImageRepository(db), media.NewProcessor(imageConfig.JPEGQuality), mediaObjectRepository)
+	go processImageJobs(rabbitMQ, imageService, imageConfig)
+
 	// Initialize repositories and services
-	queueService := email.NewEmailQueueService(rabbitMQ)
-	mailService := email.NewEmailService(queueService)
+	authConfig := config.LoadAuthConfig()
+
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	tokenService := services.NewTokenService(refreshTokenRepository, authConfig)
+	tokenService.StartCronJob()
 
 	userRepository := repository.NewUserRepository(db)
-	userService := services.NewUserService(userRepository, mailService)
+	emailConfig := config.LoadEmailConfig()
+	mails, err := email.NewTemplates(emailConfig.PublicBaseURL, emailConfig.DefaultLocale)
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+
+	accountConfig := config.LoadAccountConfig()
+	emailVerifier := services.NewEmailVerifier(mails, accountConfig)
+
+	authorizer := services.NewAuthorizer(userRepository)
+	userService := services.NewUserService(userRepository, repository.NewTxManager(db), tokenService, authorizer, searchIndex, imageQueue, mediaObjectRepository, mails, emailVerifier)
 
 	postRepository := repository.NewPostRepository(db)
-	tokenBlacklistService := services.NewTokenBlacklistService(db)
+	tokenBlacklistService := services.NewTokenBlacklistService(db, authConfig.BlacklistCacheSize, authConfig.BlacklistNegativeTTL)
+	tokenBlacklistService.StartCronJob()
+	defer tokenBlacklistService.StopCronJob()
 	friendshipRepository := repository.NewFriendshipRepository(db)
 	commentRepository := repository.NewCommentRepository(db)
-	likeRepository := repository.NewLikeRepository(db)
+	reactionRepository := repository.NewReactionRepository(db)
+
+	notificationRepository := repository.NewNotificationRepository(db)
+	conversationRepository := repository.NewConversationRepository(db)
+	topicRepository := repository.NewTopicRepository(db)
+
+	realtimeConfig := config.LoadRealtimeConfig()
+	hub := realtime.NewMemoryHub(realtimeConfig.HistorySize, realtimeConfig.IdleTTL)
+	hub.StartCronJob()
+	defer hub.StopCronJob()
+
+	notificationService := services.NewNotificationService(notificationRepository, userRepository, hub)
+	if emailConfig.Digest {
+		digestService := services.NewDigestService(notificationRepository, userRepository, email.NewEmailQueueService(outboxRepository), mails)
+		digestService.StartCronJob()
+		defer digestService.StopCronJob()
+	}
 
-	postService := services.NewPostService(postRepository)
-	friendshipService := services.NewFriendshipService(friendshipRepository)
-	commentService := services.NewCommentService(commentRepository)
-	likeService := services.NewLikeService(likeRepository)
+	postService := services.NewPostService(postRepository, friendshipRepository, topicRepository, authorizer, hub, searchIndex, imageQueue, mediaObjectRepository, config.LoadPostConfig())
+	friendshipService := services.NewFriendshipService(friendshipRepository, notificationService, hub)
+	commentService := services.NewCommentService(commentRepository, postRepository, authorizer, notificationService, searchIndex, imageQueue, mediaObjectRepository, config.LoadCommentConfig())
+	reactionService := services.NewReactionService(reactionRepository, postRepository, commentRepository, notificationService)
+	feedService := services.NewFeedService(postRepository, friendshipRepository, userRepository, reactionRepository, commentRepository)
+	messageService := services.NewMessageService(conversationRepository, friendshipRepository, userRepository, hub, mediaObjectRepository)
+	topicService := services.NewTopicService(topicRepository, authorizer, config.LoadTopicConfig())
+	searchService := services.NewSearchService(searchIndex, postRepository, commentRepository, userRepository)
+	uploadService, err := services.NewUploadService(repository.NewUploadSessionRepository(db), postService, blobStore, config.LoadResumableUploadConfig())
+	if err != nil {
+		log.Fatalf("Failed to prepare resumable uploads: %v", err)
+	}
+	uploadService.StartCronJob()
+	defer uploadService.StopCronJob()
+
+	accountService := services.NewAccountService(userRepository, repository.NewPasswordResetRepository(db), repository.NewEmailVerificationRepository(db), emailVerifier, repository.NewTxManager(db), mails, accountConfig)
+	if err := accountService.BootstrapAdmins(); err != nil {
+		log.Fatalf("Failed to promote the accounts of ADMIN_EMAILS: %v", err)
+	}
+	accountService.StartCronJob()
+	defer accountService.StopCronJob()
+
+	// emails and image jobs that keep failing end up in dead-letter queues archived for admins
+	deadLetterService := services.NewDeadLetterService(repository.NewDeadLetterRepository(db), repository.NewTxManager(db), authorizer)
+	go processRabbitMQMessages(rabbitMQ, newMailer(config.LoadMailConfig()), config.LoadEmailWorkerConfig())
+	go archiveDeadLetters(rabbitMQ, email.QueueName, deadLetterService)
+	go archiveDeadLetters(rabbitMQ, media.ImageProcessingQueue, deadLetterService)
 
 	// Configure the handlers with the services
 	handlers.SetUserService(userService)
 	handlers.SetTokenBlacklistService(tokenBlacklistService)
+	handlers.SetBlobStore(blobStore)
+	handlers.SetUploadLimits(uploadLimits(config.LoadUploadConfig()))
 
 	postHandler := handlers.NewPostHandler(postService)
 	friendshipHandler := handlers.NewFriendshipHandler(friendshipService)
 	commentHandler := handlers.NewCommentHandler(commentService)
-	likeHandler := handlers.NewLikeHandler(likeService)
+	reactionHandler := handlers.NewReactionHandler(reactionService)
+	feedHandler := handlers.NewFeedHandler(feedService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	streamHandler := handlers.NewStreamHandler(hub, realtime.NewTicketStore(realtimeConfig.TicketTTL), tokenBlacklistService, realtimeConfig.HeartbeatInterval)
+	messageHandler := handlers.NewMessageHandler(messageService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	topicHandler := handlers.NewTopicHandler(topicService, feedService)
+	uploadHandler := handlers.NewUploadHandler(uploadService)
+	deadLetterHandler := handlers.NewDeadLetterHandler(deadLetterService)
+	accountHandler := handlers.NewAccountHandler(accountService)
 
 	// Initialize the router
-	r := routes.SetupRouter(postHandler, friendshipHandler, commentHandler, likeHandler)
-
-	r.Static("/uploads", "./uploads")
-
-	// Create the uploads directory if it doesn't exist
-	os.MkdirAll("uploads/imageProfile", 0755)
-	os.MkdirAll("uploads/images", 0755)
-	os.MkdirAll("uploads/videos", 0755)
+	r := routes.SetupRouter(tokenBlacklistService, postHandler, friendshipHandler, commentHandler, reactionHandler, feedHandler, notificationHandler, streamHandler, messageHandler, searchHandler, topicHandler, mediaHandler, uploadHandler, deadLetterHandler, accountHandler)
 
 	// Start the server
 	startServer(r)
 }
 
-// Function to process RabbitMQ messages
-func processRabbitMQMessages(rabbitMQ *queue.RabbitMQ) {
-	msgs, err := rabbitMQ.Consume("email_queue")
-	if err != nil {
+// processRabbitMQMessages delivers queued emails; failed deliveries are retried with
+// backoff and dead-lettered once retries run out or the mail server rejects them for good
+func processRabbitMQMessages(rabbitMQ queue.RabbitMQClient, mailer email.Mailer, cfg config.EmailWorkerConfig) {
+	policy := queue.RetryPolicy{MaxRetries: cfg.MaxRetries, BaseDelay: cfg.RetryBaseDelay}
+	if err := email.RunWorker(rabbitMQ, mailer, policy, cfg.Prefetch); err != nil {
 		log.Printf("Error consuming RabbitMQ messages: %v", err)
-		return
 	}
+}
 
-	emailService := email.NewEmailService(email.NewEmailQueueService(rabbitMQ))
-
-	for d := range msgs {
-		var msg email.EmailMessage
-		if err := json.Unmarshal(d.Body, &msg); err != nil {
-			log.Printf("Error parsing message: %v", err)
-			continue
-		}
+// archiveDeadLetters moves the dead letters of queueName to the database for inspection and replay
+func archiveDeadLetters(rabbitMQ queue.RabbitMQClient, queueName string, deadLetterService *services.DeadLetterService) {
+	if err := rabbitMQ.WorkDeadLetters(queueName, deadLetterService.Archive); err != nil {
+		log.Printf("Error consuming dead letters of %s: %v", queueName, err)
+	}
+}
 
-		if err := emailService.SendEmail(msg.To, msg.Subject, msg.Body); err != nil {
-			log.Printf("Error sending email: %v", err)
-		} else {
-			log.Printf("Email sent to %s successfully", msg.To)
-		}
+// processImageJobs runs the image pipeline for every uploaded image; failed jobs are
+// retried with backoff and dead-lettered once retries run out or the file is not an image
+func processImageJobs(rabbitMQ queue.RabbitMQClient, imageService *services.ImageService, cfg config.ImageConfig) {
+	policy := queue.RetryPolicy{MaxRetries: cfg.MaxRetries, BaseDelay: cfg.RetryBaseDelay}
+	if err := services.RunImageWorker(rabbitMQ, imageService, policy, cfg.Prefetch); err != nil {
+		log.Printf("Error consuming image jobs: %v", err)
 	}
 }
 
@@ -127,6 +205,11 @@ func connectDatabase() *gorm.DB {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	err = db.AutoMigrate(&models.RefreshToken{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
 	err = db.AutoMigrate(&models.Post{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
@@ -142,7 +225,109 @@ func connectDatabase() *gorm.DB {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.Like{})
+	err = db.AutoMigrate(&models.Reaction{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.MigrateLegacyLikes(db)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Notification{}, &models.NotificationActor{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Conversation{}, &models.ConversationMember{}, &models.Message{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Topic{}, &models.TopicFollow{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.MigrateLegacyTopics(db)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.SeedTopics(db, config.LoadTopicConfig().Defaults)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.UniquePostMediaPositions(db)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.PostMedia{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.MigrateLegacyPostMedia(db)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.MediaObject{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.RepairRenditionKeys(db)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.OutboxEvent{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.UploadSession{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.DeadLetter{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// one-time links must not sit in the outbox or the dead-letter archive
+	err = repository.ScrubSettledPayloads(db, email.QueueName, email.RedactedToken)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = repository.RedactArchivedLinks(db, email.QueueName, email.RedactedToken)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.PasswordResetToken{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.EmailVerificationToken{})
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// pending confirmation links sent before tokens expired stay valid for one more TTL
+	err = repository.MigrateLegacyEmailConfirmTokens(db, config.LoadAccountConfig().EmailVerificationTTL)
+	if err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.AuditEvent{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -150,6 +335,123 @@ func connectDatabase() *gorm.DB {
 	return db
 }
 
+// newQueue connects to RabbitMQ, or keeps messages in process with QUEUE_BACKEND=memory
+func newQueue(cfg config.QueueConfig) queue.RabbitMQClient {
+	switch cfg.Backend {
+	case config.QueueBackendMemory:
+		log.Println("Using the in-memory queue; queued messages are lost on restart")
+		return queue.NewMemoryQueue()
+	case config.QueueBackendRabbitMQ:
+		rabbitMQ, err := queue.NewRabbitMQ(cfg.RabbitMQURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return rabbitMQ
+	default:
+		log.Fatalf("Unknown QUEUE_BACKEND %q", cfg.Backend)
+		return nil
+	}
+}
+
+// newMailer picks how emails leave the worker; file, console and memory are for development
+func newMailer(cfg config.MailConfig) email.Mailer {
+	switch cfg.Transport {
+	case config.MailTransportSMTP:
+		mailer, err := email.NewSMTPMailer(email.SMTPConfig{
+			Host:         cfg.SMTPHost,
+			Port:         cfg.SMTPPort,
+			From:         cfg.From,
+			Username:     cfg.SMTPUsername,
+			Password:     cfg.SMTPPassword,
+			Security:     cfg.SMTPSecurity,
+			Auth:         cfg.SMTPAuth,
+			CAFile:       cfg.SMTPCAFile,
+			MaxIdleConns: cfg.SMTPMaxIdleConns,
+			IdleTimeout:  cfg.SMTPIdleTimeout,
+			Timeout:      cfg.SMTPTimeout,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure SMTP: %v", err)
+		}
+		return mailer
+	case config.MailTransportFile:
+		mailer, err := email.NewFileMailer(cfg.FileDir, cfg.From)
+		if err != nil {
+			log.Fatalf("Failed to prepare %s for emails: %v", cfg.FileDir, err)
+		}
+		return mailer
+	case config.MailTransportConsole:
+		return email.NewConsoleMailer()
+	case config.MailTransportMemory:
+		return email.NewMemoryMailer()
+	default:
+		log.Fatalf("Unknown MAIL_TRANSPORT %q", cfg.Transport)
+		return nil
+	}
+}
+
+// newBlobStore picks the upload storage; only the local store needs the media route
+func newBlobStore(cfg config.StorageConfig) (storage.BlobStore, *handlers.MediaHandler) {
+	switch cfg.Backend {
+	case config.StorageBackendLocal:
+		if cfg.SigningKey == "" {
+			log.Fatal("STORAGE_SIGNING_KEY must be set to sign media URLs")
+		}
+		if cfg.SigningKey == config.LoadAuthConfig().SecretKey {
+			log.Fatal("STORAGE_SIGNING_KEY must not reuse JWT_SECRET_KEY")
+		}
+		store := storage.NewLocalStore(cfg.LocalDir, "/media", []byte(cfg.SigningKey))
+		return store, handlers.NewMediaHandler(store)
+	case config.StorageBackendS3:
+		store, err := storage.NewS3Store(storage.S3Config{
+			Endpoint:       cfg.S3Endpoint,
+			PublicEndpoint: cfg.S3PublicEndpoint,
+			Region:         cfg.S3Region,
+			Bucket:         cfg.S3Bucket,
+			AccessKey:      cfg.S3AccessKey,
+			SecretKey:      cfg.S3SecretKey,
+			PathStyle:      cfg.S3PathStyle,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure storage: %v", err)
+		}
+		return store, nil
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND %q", cfg.Backend)
+		return nil, nil
+	}
+}
+
+func uploadLimits(cfg config.UploadConfig) media.Limits {
+	return media.Limits{
+		MaxImageBytes:  int64(cfg.MaxImageMB) * 1024 * 1024,
+		MaxVideoBytes:  int64(cfg.MaxVideoMB) * 1024 * 1024,
+		MaxImageWidth:  cfg.MaxImageWidth,
+		MaxImageHeight: cfg.MaxImageHeight,
+		MaxImagePixels: cfg.MaxImagePixels,
+	}
+}
+
+// newSearchIndex picks the search backend; the in-memory index is rebuilt from the database on every run
+func newSearchIndex(db *gorm.DB, cfg config.SearchConfig) search.SearchIndex {
+	switch cfg.Backend {
+	case config.SearchBackendMemory:
+		index := search.NewMemoryIndex()
+		if err := index.Backfill(db); err != nil {
+			log.Fatalf("Failed to build the search index: %v", err)
+		}
+		return index
+	case config.SearchBackendPostgres:
+		if err := search.MigratePostgres(db, cfg.Language); err != nil {
+			log.Fatalf("Failed to migrate search columns: %v", err)
+		}
+		return search.NewPostgresIndex(db, cfg.Language)
+	default:
+		log.Fatalf("Unknown SEARCH_BACKEND %q", cfg.Backend)
+		return nil
+	}
+}
+
 func startServer(r *gin.Engine) {
 	log.Println("Starting server on port :8080")
 	if err := r.Run(":8080"); err != nil {
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// AccountConfig drives account recovery, email verification and credential changes
+type AccountConfig struct {
+	PasswordMinLength     int
+	PasswordResetTTL      time.Duration // how long a reset link stays valid
+	PasswordResetInterval time.Duration // minimum delay between two reset emails to the same user
+
+	EmailVerificationTTL            time.Duration // how long a confirmation link stays valid
+	EmailVerificationResendInterval time.Duration // minimum delay between two confirmation emails to the same user
+	EmailVerificationDailyLimit     int           // confirmation emails per user in 24 hours
+	RequireVerifiedEmailToLogin     bool
+
+	AdminEmails []string // accounts promoted to admin once their address is verified
+}
+
+func LoadAccountConfig() AccountConfig {
+	return AccountConfig{
+		PasswordMinLength:     getInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordResetTTL:      getDuration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordResetInterval: getDuration("PASSWORD_RESET_INTERVAL", time.Minute),
+
+		EmailVerificationTTL:            getDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		EmailVerificationResendInterval: getDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+		EmailVerificationDailyLimit:     getInt("EMAIL_VERIFICATION_DAILY_LIMIT", 5),
+		RequireVerifiedEmailToLogin:     getBool("REQUIRE_VERIFIED_EMAIL_TO_LOGIN", false),
+
+		AdminEmails: emailList(os.Getenv("ADMIN_EMAILS")),
+	}
+}
+
+// emailList splits a comma-separated list of addresses, lowercased
+func emailList(value string) []string {
+	var emails []string
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.ToLower(strings.TrimSpace(address)); address != "" {
+			emails = append(emails, address)
+		}
+	}
+	return emails
+}
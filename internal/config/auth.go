@@ -0,0 +1,76 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// AuthConfig holds the token settings used by the authentication flow
+type AuthConfig struct {
+	SecretKey       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// in-process cache in front of the token blacklist table
+	BlacklistCacheSize   int
+	BlacklistNegativeTTL time.Duration
+}
+
+// LoadAuthConfig reads the token settings from the environment
+func LoadAuthConfig() AuthConfig {
+	return AuthConfig{
+		SecretKey:       os.Getenv("JWT_SECRET_KEY"),
+		AccessTokenTTL:  getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		BlacklistCacheSize:   getInt("TOKEN_BLACKLIST_CACHE_SIZE", 10000),
+		BlacklistNegativeTTL: getDuration("TOKEN_BLACKLIST_NEGATIVE_TTL", 30*time.Second),
+	}
+}
+
+// getDuration parses a duration env var such as "15m", falling back to def
+func getDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid value for %s (%q), using default %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// getInt parses a positive integer env var, falling back to def
+func getInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid value for %s (%q), using default %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// getBool reads a boolean env var such as "true" or "0", falling back to def
+func getBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s (%q), using default %t", key, value, def)
+		return def
+	}
+	return b
+}
@@ -0,0 +1,18 @@
+package config
+
+// CommentConfig bounds how much of a comment thread is returned at once
+type CommentConfig struct {
+	MaxTreeDepth      int // deepest level returned by the tree endpoint, top-level comments being level 1
+	RepliesPerComment int // replies preloaded under each comment before clients have to page
+
+	RequireVerifiedEmail bool // only authors with a verified email may comment
+}
+
+func LoadCommentConfig() CommentConfig {
+	return CommentConfig{
+		MaxTreeDepth:      getInt("COMMENT_TREE_MAX_DEPTH", 3),
+		RepliesPerComment: getInt("COMMENT_REPLIES_PER_COMMENT", 3),
+
+		RequireVerifiedEmail: getBool("REQUIRE_VERIFIED_EMAIL_TO_POST", false),
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// EmailWorkerConfig drives the consumer delivering queued emails
+type EmailWorkerConfig struct {
+	Prefetch       int           // messages in flight, and emails sent concurrently
+	MaxRetries     int           // retries before a message is dead-lettered
+	RetryBaseDelay time.Duration // wait before the first retry, doubled for each following one
+}
+
+func LoadEmailWorkerConfig() EmailWorkerConfig {
+	return EmailWorkerConfig{
+		Prefetch:       getInt("EMAIL_WORKER_PREFETCH", 4),
+		MaxRetries:     getInt("EMAIL_MAX_RETRIES", 5),
+		RetryBaseDelay: getDuration("EMAIL_RETRY_BASE_DELAY", 10*time.Second),
+	}
+}
+
+// EmailConfig drives the content of the transactional emails
+type EmailConfig struct {
+	PublicBaseURL string // prefix of the links in emails
+	DefaultLocale string // for users whose locale has no templates
+	Digest        bool   // email a daily digest of unread notifications
+}
+
+func LoadEmailConfig() EmailConfig {
+	cfg := EmailConfig{
+		PublicBaseURL: os.Getenv("PUBLIC_BASE_URL"),
+		DefaultLocale: os.Getenv("EMAIL_DEFAULT_LOCALE"),
+		Digest:        os.Getenv("EMAIL_DIGEST_ENABLED") == "true",
+	}
+	if cfg.PublicBaseURL == "" {
+		cfg.PublicBaseURL = "http://localhost:8080"
+	}
+	if cfg.DefaultLocale == "" {
+		cfg.DefaultLocale = "pt-BR"
+	}
+	return cfg
+}
+
+const (
+	MailTransportSMTP    = "smtp"
+	MailTransportFile    = "file"
+	MailTransportConsole = "console"
+	MailTransportMemory  = "memory"
+)
+
+// MailConfig selects how the email worker delivers emails
+type MailConfig struct {
+	Transport string // smtp (default), file, console or memory
+	From      string
+	FileDir   string // where the file transport drops .eml files
+
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPSecurity     string // none, starttls or tls
+	SMTPAuth         string // plain or login
+	SMTPCAFile       string
+	SMTPMaxIdleConns int
+	SMTPIdleTimeout  time.Duration
+	SMTPTimeout      time.Duration
+}
+
+func LoadMailConfig() MailConfig {
+	cfg := MailConfig{
+		Transport:        os.Getenv("MAIL_TRANSPORT"),
+		From:             os.Getenv("MAIL_FROM"),
+		FileDir:          os.Getenv("MAIL_FILE_DIR"),
+		SMTPHost:         os.Getenv("SMTP_HOST"),
+		SMTPPort:         getInt("SMTP_PORT", 1025),
+		SMTPUsername:     os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:     os.Getenv("SMTP_PASSWORD"),
+		SMTPSecurity:     os.Getenv("SMTP_SECURITY"),
+		SMTPAuth:         os.Getenv("SMTP_AUTH"),
+		SMTPCAFile:       os.Getenv("SMTP_CA_FILE"),
+		SMTPMaxIdleConns: getInt("SMTP_MAX_IDLE_CONNS", 2),
+		SMTPIdleTimeout:  getDuration("SMTP_IDLE_TIMEOUT", 30*time.Second),
+		SMTPTimeout:      getDuration("SMTP_TIMEOUT", 10*time.Second),
+	}
+	if cfg.Transport == "" {
+		cfg.Transport = MailTransportSMTP
+	}
+	if cfg.From == "" {
+		cfg.From = "GoVerse <no-reply@goverse.local>"
+	}
+	if cfg.FileDir == "" {
+		cfg.FileDir = "mail"
+	}
+	if cfg.SMTPHost == "" {
+		cfg.SMTPHost = "mailhog"
+	}
+	if cfg.SMTPSecurity == "" {
+		cfg.SMTPSecurity = "none"
+	}
+	if cfg.SMTPAuth == "" {
+		cfg.SMTPAuth = "plain"
+	}
+	return cfg
+}
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// ImageConfig tunes the image processing pipeline
+type ImageConfig struct {
+	JPEGQuality    int           // 1-100, used for renditions and re-encoded originals
+	Prefetch       int           // jobs in flight, and images processed concurrently
+	MaxRetries     int           // retries before a job is dead-lettered
+	RetryBaseDelay time.Duration // wait before the first retry, doubled for each following one
+}
+
+func LoadImageConfig() ImageConfig {
+	quality := getInt("IMAGE_JPEG_QUALITY", 85)
+	if quality > 100 {
+		quality = 100
+	}
+	return ImageConfig{
+		JPEGQuality:    quality,
+		Prefetch:       getInt("IMAGE_WORKER_PREFETCH", 2),
+		MaxRetries:     getInt("IMAGE_MAX_RETRIES", 3),
+		RetryBaseDelay: getDuration("IMAGE_RETRY_BASE_DELAY", 30*time.Second),
+	}
+}
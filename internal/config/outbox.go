@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// OutboxConfig drives the relay publishing outbox events to the queue
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int           // attempts before an event is marked failed
+	RetryBase    time.Duration // delay after the first failure, doubled after each one
+	RetryMax     time.Duration
+	Retention    time.Duration // how long sent events are kept
+}
+
+func LoadOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		PollInterval: getDuration("OUTBOX_POLL_INTERVAL", 2*time.Second),
+		BatchSize:    getInt("OUTBOX_BATCH_SIZE", 100),
+		MaxAttempts:  getInt("OUTBOX_MAX_ATTEMPTS", 10),
+		RetryBase:    getDuration("OUTBOX_RETRY_BASE", 5*time.Second),
+		RetryMax:     getDuration("OUTBOX_RETRY_MAX", 10*time.Minute),
+		Retention:    getDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+	}
+}
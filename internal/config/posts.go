@@ -0,0 +1,14 @@
+package config
+
+// PostConfig bounds what a single post may carry and who may post
+type PostConfig struct {
+	MaxMedia             int  // attachments per post, images and videos together
+	RequireVerifiedEmail bool // only authors with a verified email may post
+}
+
+func LoadPostConfig() PostConfig {
+	return PostConfig{
+		MaxMedia:             getInt("POST_MAX_MEDIA", 10),
+		RequireVerifiedEmail: getBool("REQUIRE_VERIFIED_EMAIL_TO_POST", false),
+	}
+}
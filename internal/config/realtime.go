@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+// RealtimeConfig controls the streaming endpoint
+type RealtimeConfig struct {
+	HeartbeatInterval time.Duration
+	HistorySize       int           // events kept per user so reconnecting clients can resume
+	IdleTTL           time.Duration // history of users without connections is dropped after this long
+	TicketTTL         time.Duration // lifetime of the tickets EventSource clients open the stream with
+}
+
+func LoadRealtimeConfig() RealtimeConfig {
+	return RealtimeConfig{
+		HeartbeatInterval: getDuration("STREAM_HEARTBEAT_INTERVAL", 25*time.Second),
+		HistorySize:       getInt("STREAM_HISTORY_SIZE", 100),
+		IdleTTL:           getDuration("STREAM_IDLE_TTL", 10*time.Minute),
+		TicketTTL:         getDuration("STREAM_TICKET_TTL", 30*time.Second),
+	}
+}
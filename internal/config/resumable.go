@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResumableUploadConfig controls chunked upload sessions
+type ResumableUploadConfig struct {
+	Dir        string        // where partial uploads are kept until finalized
+	MaxSizeMB  int           // largest file a session may announce
+	SessionTTL time.Duration // idle sessions older than this are garbage-collected
+}
+
+func LoadResumableUploadConfig() ResumableUploadConfig {
+	dir := os.Getenv("RESUMABLE_UPLOAD_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "goverse-uploads")
+	}
+	return ResumableUploadConfig{
+		Dir:        dir,
+		MaxSizeMB:  getInt("RESUMABLE_UPLOAD_MAX_MB", 2048),
+		SessionTTL: getDuration("RESUMABLE_UPLOAD_TTL", 24*time.Hour),
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import "os"
+
+const (
+	SearchBackendPostgres = "postgres"
+	SearchBackendMemory   = "memory"
+)
+
+// SearchConfig selects the search index implementation
+type SearchConfig struct {
+	Backend  string // postgres (default) or memory
+	Language string // Postgres text search configuration, e.g. simple, english, portuguese
+}
+
+func LoadSearchConfig() SearchConfig {
+	cfg := SearchConfig{
+		Backend:  os.Getenv("SEARCH_BACKEND"),
+		Language: os.Getenv("SEARCH_LANGUAGE"),
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = SearchBackendPostgres
+	}
+	if cfg.Language == "" {
+		cfg.Language = "simple"
+	}
+	return cfg
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	StorageBackendLocal = "local"
+	StorageBackendS3    = "s3"
+)
+
+// StorageConfig selects where uploads are stored and how long their URLs stay valid
+type StorageConfig struct {
+	Backend    string // local (default) or s3
+	LocalDir   string
+	SigningKey string // signs local media URLs; must differ from JWT_SECRET_KEY
+	URLTTL     time.Duration
+	GCGrace    time.Duration // how long unreferenced files are kept before being swept
+
+	S3Endpoint       string
+	S3PublicEndpoint string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3PathStyle      bool
+}
+
+func LoadStorageConfig() StorageConfig {
+	cfg := StorageConfig{
+		Backend:          os.Getenv("STORAGE_BACKEND"),
+		LocalDir:         os.Getenv("STORAGE_LOCAL_DIR"),
+		SigningKey:       os.Getenv("STORAGE_SIGNING_KEY"),
+		URLTTL:           getDuration("MEDIA_URL_TTL", 15*time.Minute),
+		GCGrace:          getDuration("MEDIA_GC_GRACE", 24*time.Hour),
+		S3Endpoint:       os.Getenv("S3_ENDPOINT"),
+		S3PublicEndpoint: os.Getenv("S3_PUBLIC_ENDPOINT"),
+		S3Region:         os.Getenv("S3_REGION"),
+		S3Bucket:         os.Getenv("S3_BUCKET"),
+		S3AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:      os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:      os.Getenv("S3_PATH_STYLE") != "false",
+	}
+	if cfg.Backend == "" {
+		cfg.Backend = StorageBackendLocal
+	}
+	if cfg.LocalDir == "" {
+		cfg.LocalDir = "uploads"
+	}
+	return cfg
+}
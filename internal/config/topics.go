@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// TopicConfig controls how trending topics are computed and which topics always exist
+type TopicConfig struct {
+	TrendingWindow    time.Duration // default window when the client does not send one
+	MaxTrendingWindow time.Duration
+	Defaults          []string // names of the topics created at startup when missing
+}
+
+func LoadTopicConfig() TopicConfig {
+	defaults := os.Getenv("TOPIC_DEFAULTS")
+	if defaults == "" {
+		defaults = "General"
+	}
+
+	cfg := TopicConfig{
+		TrendingWindow:    getDuration("TOPIC_TRENDING_WINDOW", 24*time.Hour),
+		MaxTrendingWindow: getDuration("TOPIC_TRENDING_MAX_WINDOW", 30*24*time.Hour),
+	}
+	for _, name := range strings.Split(defaults, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Defaults = append(cfg.Defaults, name)
+		}
+	}
+	return cfg
+}
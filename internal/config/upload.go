@@ -0,0 +1,20 @@
+package config
+
+// UploadConfig limits the files accepted by the upload endpoints
+type UploadConfig struct {
+	MaxImageMB     int
+	MaxVideoMB     int
+	MaxImageWidth  int
+	MaxImageHeight int
+	MaxImagePixels int
+}
+
+func LoadUploadConfig() UploadConfig {
+	return UploadConfig{
+		MaxImageMB:     getInt("UPLOAD_MAX_IMAGE_MB", 10),
+		MaxVideoMB:     getInt("UPLOAD_MAX_VIDEO_MB", 100),
+		MaxImageWidth:  getInt("UPLOAD_MAX_IMAGE_WIDTH", 8000),
+		MaxImageHeight: getInt("UPLOAD_MAX_IMAGE_HEIGHT", 8000),
+		MaxImagePixels: getInt("UPLOAD_MAX_IMAGE_PIXELS", 40000000),
+	}
+}
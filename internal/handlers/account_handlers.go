@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	services "GoVersi/internal/service"
+	"GoVersi/internal/service/email"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(service *services.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: service}
+}
+
+// ForgotPassword answers the same way whether or not the address has an account; the
+// email is prepared in the background so the response time does not tell either
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	go func(address string) {
+		if err := h.accountService.RequestPasswordReset(address); err != nil {
+			log.Printf("password reset request error: %v", err)
+		}
+	}(request.Email)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var request struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(request.Token, request.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) || errors.Is(err, services.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("password reset error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated; sign in again on every device"})
+}
+
+func (h *AccountHandler) ConfirmEmail(c *gin.Context) {
+	if err := h.accountService.ConfirmEmail(c.Query("token"), requestMeta(c)); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("email confirmation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "verify email error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email confirmed successfully"})
+}
+
+// ResendVerification, like ForgotPassword, gives the same answer for every address
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	go func(address string) {
+		if err := h.accountService.ResendVerification(address); err != nil {
+			log.Printf("email verification resend error: %v", err)
+		}
+	}(request.Email)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If an unverified account exists for this email, a new confirmation link has been sent"})
+}
+
+// ChangePassword keeps the session of the request and signs out every other one
+func (h *AccountHandler) ChangePassword(c *gin.Context) {
+	var request struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	err := h.accountService.ChangePassword(userID, c.GetString("session_id"), request.CurrentPassword, request.NewPassword, requestMeta(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrWrongPassword):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Printf("password change error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated; other devices have been signed out"})
+}
+
+// ChangeEmail only stages the new address: it replaces the current one once confirmed
+func (h *AccountHandler) ChangeEmail(c *gin.Context) {
+	var request struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		Email           string `json:"email" binding:"required"`
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.accountService.RequestEmailChange(userID, request.CurrentPassword, request.Email, requestMeta(c)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrWrongPassword):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, email.ErrInvalidAddress), errors.Is(err, services.ErrEmailUnchanged):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTooManyEmailLinks):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			log.Printf("email change error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change email"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Follow the link sent to the new address to complete the change"})
+}
+
+func requestMeta(c *gin.Context) services.RequestMeta {
+	return services.RequestMeta{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	services "GoVersi/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user set by AuthMiddleware,
+// writing the error response itself when it is missing or malformed
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// respondForbidden writes a 403 when err is an authorization failure
+func respondForbidden(c *gin.Context, err error) bool {
+	if errors.Is(err, services.ErrForbidden) || errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"GoVersi/internal/models"
@@ -38,8 +39,8 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 
-	// Get post_id from the URL
-	postID, err := uuid.Parse(c.Param("post_id"))
+	// Get the post id from the URL
+	postID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
@@ -52,15 +53,30 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 	}
 
 	// Call the image upload function
-	imageURL, err := utils.HandleImageUpload(c, "uploads/images/posts/comments")
-	if err != nil {
+	if err := h.commentService.CanComment(authorID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	image, err := utils.HandleImageUpload(c, blobStore, "images/posts/comments", uploadLimits)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
 	// Call the service to create the comment, now including PostID
-	comment, err := h.commentService.CreateComment(request.Content, imageURL, postID, authorID)
+	comment, err := h.commentService.CreateComment(request.Content, image, postID, authorID)
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if err.Error() == "post not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -68,21 +84,107 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 	c.JSON(http.StatusCreated, comment)
 }
 
-// New function to list all comments of a post
+// list the comment tree of a post; top-level comments are paginated with cursor/limit
 func (h *CommentHandler) GetCommentsByPostID(c *gin.Context) {
-	postID, err := uuid.Parse(c.Param("post_id"))
+	postID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
 
-	comments, err := h.commentService.GetCommentsByPostID(postID)
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+	depth := utils.ParseLimit(c.Query("depth"), 2, 10)
+
+	page, err := h.commentService.GetCommentTree(postID, c.Query("cursor"), limit, depth)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load comments"})
 		return
 	}
 
-	c.JSON(http.StatusOK, comments)
+	c.JSON(http.StatusOK, page)
+}
+
+// list the replies of a comment, paginated independently from the top-level comments
+func (h *CommentHandler) GetReplies(c *gin.Context) {
+	commentID, err := uuid.Parse(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+	depth := utils.ParseLimit(c.Query("depth"), 1, 10)
+
+	page, err := h.commentService.GetReplies(commentID, c.Query("cursor"), limit, depth)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "comment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load replies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *CommentHandler) ReplyToComment(c *gin.Context) {
+	var request struct {
+		Content string `form:"content" json:"content" binding:"required"`
+	}
+
+	authorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parentID, err := uuid.Parse(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if err := h.commentService.CanComment(authorID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	image, err := utils.HandleImageUpload(c, blobStore, "images/posts/comments", uploadLimits)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	reply, err := h.commentService.CreateReply(request.Content, image, parentID, authorID)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if err.Error() == "comment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
 }
 
 func (h *CommentHandler) GetCommentById(c *gin.Context) {
@@ -102,6 +204,11 @@ func (h *CommentHandler) GetCommentById(c *gin.Context) {
 }
 
 func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	commentID, err := uuid.Parse(c.Param("comment_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
@@ -114,8 +221,11 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
-	updatedComment, err := h.commentService.UpdateComment(commentID, &updatedCommentData)
+	updatedComment, err := h.commentService.UpdateComment(actorID, commentID, &updatedCommentData)
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -124,13 +234,25 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 }
 
 func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	commentID, err := uuid.Parse(c.Param("comment_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
 		return
 	}
 
-	if err := h.commentService.DeleteComment(commentID); err != nil {
+	if err := h.commentService.DeleteComment(actorID, commentID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if err.Error() == "comment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
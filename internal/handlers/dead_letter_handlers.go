@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DeadLetterHandler struct {
+	deadLetterService *services.DeadLetterService
+}
+
+func NewDeadLetterHandler(service *services.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{deadLetterService: service}
+}
+
+// list archived dead letters, optionally filtered by ?queue=
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+
+	page, err := h.deadLetterService.List(actorID, c.Query("queue"), c.Query("cursor"), limit)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load dead letters"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *DeadLetterHandler) GetDeadLetter(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dead letter ID"})
+		return
+	}
+
+	letter, err := h.deadLetterService.Get(actorID, id)
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, letter)
+}
+
+// send the message back to its original queue
+func (h *DeadLetterHandler) ReplayDeadLetter(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dead letter ID"})
+		return
+	}
+
+	letter, err := h.deadLetterService.Replay(actorID, id)
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, letter)
+}
+
+// put the outbox events that failed to publish back in line, optionally only those of ?queue=
+func (h *DeadLetterHandler) ReplayOutbox(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	replayed, err := h.deadLetterService.ReplayOutbox(actorID, c.Query("queue"))
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+func respondDeadLetterError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyReplayed), errors.Is(err, services.ErrRedactedLetter):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
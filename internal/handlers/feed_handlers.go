@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FeedHandler struct {
+	feedService *services.FeedService
+}
+
+func NewFeedHandler(service *services.FeedService) *FeedHandler {
+	return &FeedHandler{feedService: service}
+}
+
+func (h *FeedHandler) GetFeed(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+
+	page, err := h.feedService.GetHomeFeed(userUUID, c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load feed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
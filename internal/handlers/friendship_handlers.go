@@ -44,7 +44,7 @@ func (h *FriendshipHandler) SendFriendRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.friendshipService.SendFriendRequest(addresseeUUID, requesterUUID); err != nil {
+	if err := h.friendshipService.SendFriendRequest(requesterUUID, addresseeUUID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send friend request"})
 		return
 	}
@@ -53,13 +53,21 @@ func (h *FriendshipHandler) SendFriendRequest(c *gin.Context) {
 }
 
 func (h *FriendshipHandler) AcceptFriendRequest(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	friendshipID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid friendship ID"})
 		return
 	}
 
-	if err := h.friendshipService.AcceptFriendRequest(friendshipID); err != nil {
+	if err := h.friendshipService.AcceptFriendRequest(actorID, friendshipID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -68,13 +76,21 @@ func (h *FriendshipHandler) AcceptFriendRequest(c *gin.Context) {
 }
 
 func (h *FriendshipHandler) DeclineFriendRequest(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	friendshipID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid friendship ID"})
 		return
 	}
 
-	if err := h.friendshipService.DeclineFriendRequest(friendshipID); err != nil {
+	if err := h.friendshipService.DeclineFriendRequest(actorID, friendshipID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"GoVersi/internal/media"
+	"GoVersi/internal/storage"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blob store and limits used by every upload endpoint
+var blobStore storage.BlobStore
+var uploadLimits media.Limits
+
+func SetBlobStore(store storage.BlobStore) {
+	blobStore = store
+}
+
+func SetUploadLimits(limits media.Limits) {
+	uploadLimits = limits
+}
+
+// respondUploadError tells the client why an upload was refused
+func respondUploadError(c *gin.Context, err error) {
+	var unsupported *media.UnsupportedMediaError
+	var tooLarge *media.TooLargeError
+	switch {
+	case errors.As(err, &unsupported):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// MediaHandler serves files of the local blob store behind signed URLs
+type MediaHandler struct {
+	store *storage.LocalStore
+}
+
+func NewMediaHandler(store *storage.LocalStore) *MediaHandler {
+	return &MediaHandler{store: store}
+}
+
+// mediaMaxAge caps, in seconds, how long clients cache a served file
+const mediaMaxAge = 300
+
+func (h *MediaHandler) ServeMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	if err := h.store.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, err := h.store.Open(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open media"})
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+		return
+	}
+
+	// the URL is only valid until it expires, so clients must not cache past that
+	expires, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+	maxAge := min(expires-time.Now().Unix(), mediaMaxAge)
+	c.Header("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10))
+	http.ServeContent(c.Writer, c.Request, path.Base(key), info.ModTime(), file)
+}
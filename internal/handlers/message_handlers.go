@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MessageHandler struct {
+	messageService *services.MessageService
+}
+
+func NewMessageHandler(service *services.MessageService) *MessageHandler {
+	return &MessageHandler{messageService: service}
+}
+
+func (h *MessageHandler) ListConversations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+
+	page, err := h.messageService.ListConversations(userID, c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// open (or reuse) the conversation with a friend
+func (h *MessageHandler) StartConversation(c *gin.Context) {
+	var request struct {
+		FriendID uuid.UUID `json:"friend_id" binding:"required"`
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	conversation, err := h.messageService.StartConversation(userID, request.FriendID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFriends) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 50, 100)
+
+	page, err := h.messageService.GetMessages(userID, conversationID, c.Query("cursor"), limit)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "conversation not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// send a message with text and/or an image
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	var request struct {
+		Content string `form:"content" json:"content"`
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	// refuse before storing an image that could never be sent
+	if err := h.messageService.CanSend(userID, conversationID); err != nil {
+		respondSendError(c, err)
+		return
+	}
+
+	image, err := utils.HandleImageUpload(c, blobStore, "images/messages", uploadLimits)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	message, err := h.messageService.SendMessage(userID, conversationID, request.Content, image)
+	if err != nil {
+		respondSendError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, message)
+}
+
+func respondSendError(c *gin.Context, err error) {
+	if respondForbidden(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrNotFriends):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "conversation not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "message must have content or an image":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	conversationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return
+	}
+
+	if err := h.messageService.MarkRead(userID, conversationID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if err.Error() == "conversation not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Conversation marked as read"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"GoVersi/internal/models"
@@ -54,20 +55,51 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	imageURL, err := utils.HandleImageUpload(c, "uploads/images")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error uploading image: " + err.Error()})
+	// refuse before anything gets uploaded
+	if err := h.postService.CanPost(authorID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating post"})
 		return
 	}
 
-	videoURL, err := utils.HandleVideoUpload(c, "uploads/videos")
+	// "media" files, images and videos in any mix; "alt_text" values pair with them by position
+	uploads, err := utils.HandleMediaUploads(c, blobStore, "media", h.postService.MaxMedia(), uploadLimits)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error uploading video: " + err.Error()})
+		if errors.Is(err, utils.ErrTooManyFiles) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondUploadError(c, err)
 		return
 	}
 
-	post, err := h.postService.CreatePost(request.Title, request.Content, request.Topic, imageURL, videoURL, authorID)
+	altTexts := c.PostFormArray("alt_text")
+	attachments := make([]models.PostMedia, len(uploads))
+	for i, u := range uploads {
+		attachments[i] = models.PostMedia{
+			Type:       models.MediaType(u.Info.Kind),
+			URL:        u.Key,
+			MimeType:   u.Info.MIME,
+			Width:      u.Info.Width,
+			Height:     u.Info.Height,
+			DurationMS: u.Info.Duration.Milliseconds(),
+		}
+		if i < len(altTexts) {
+			attachments[i].AltText = altTexts[i]
+		}
+	}
+
+	post, err := h.postService.CreatePost(request.Title, request.Content, request.Topic, attachments, authorID)
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrUnknownTopic) || errors.Is(err, services.ErrTooManyMedia) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating post: " + err.Error()})
 		return
 	}
@@ -92,20 +124,43 @@ func (h *PostHandler) GetPostById(c *gin.Context) {
 }
 
 func (h *PostHandler) UpdatePost(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	postID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
 
-	var updatedPostData models.Post
-	if err := c.ShouldBindJSON(&updatedPostData); err != nil {
+	// "media", when present, lists the attachments to keep in their new order
+	var request struct {
+		Title   string                     `json:"title"`
+		Content string                     `json:"content"`
+		Topic   string                     `json:"topic"`
+		Media   []services.PostMediaUpdate `json:"media"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	updatedPost, err := h.postService.UpdatePost(postID, &updatedPostData)
+	updatedPostData := models.Post{Title: request.Title, Content: request.Content, Topic: request.Topic}
+	updatedPost, err := h.postService.UpdatePost(actorID, postID, &updatedPostData, request.Media)
 	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrUnknownTopic) || errors.Is(err, services.ErrUnknownMedia) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrMediaChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -114,13 +169,25 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 }
 
 func (h *PostHandler) DeletePost(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	postID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
 
-	if err := h.postService.DeletePost(postID); err != nil {
+	if err := h.postService.DeletePost(actorID, postID); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		if err.Error() == "post not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 		return
 	}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"GoVersi/internal/models"
+	services "GoVersi/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReactionHandler struct {
+	reactionService *services.ReactionService
+}
+
+func NewReactionHandler(service *services.ReactionService) *ReactionHandler {
+	return &ReactionHandler{reactionService: service}
+}
+
+func (h *ReactionHandler) ReactToPost(c *gin.Context) {
+	h.react(c, models.ReactionTargetPost, "id")
+}
+
+func (h *ReactionHandler) RemovePostReaction(c *gin.Context) {
+	h.removeReaction(c, models.ReactionTargetPost, "id")
+}
+
+func (h *ReactionHandler) GetPostReactions(c *gin.Context) {
+	h.getSummary(c, models.ReactionTargetPost, "id")
+}
+
+func (h *ReactionHandler) ReactToComment(c *gin.Context) {
+	h.react(c, models.ReactionTargetComment, "comment_id")
+}
+
+func (h *ReactionHandler) RemoveCommentReaction(c *gin.Context) {
+	h.removeReaction(c, models.ReactionTargetComment, "comment_id")
+}
+
+func (h *ReactionHandler) GetCommentReactions(c *gin.Context) {
+	h.getSummary(c, models.ReactionTargetComment, "comment_id")
+}
+
+func (h *ReactionHandler) react(c *gin.Context, targetType models.ReactionTarget, param string) {
+	var request struct {
+		Type models.ReactionType `json:"type" binding:"required"`
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + string(targetType) + " ID"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	reaction, err := h.reactionService.React(userID, targetType, targetID, request.Type)
+	if err != nil {
+		respondReactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reaction)
+}
+
+func (h *ReactionHandler) removeReaction(c *gin.Context, targetType models.ReactionTarget, param string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + string(targetType) + " ID"})
+		return
+	}
+
+	if err := h.reactionService.RemoveReaction(userID, targetType, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ReactionHandler) getSummary(c *gin.Context, targetType models.ReactionTarget, param string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + string(targetType) + " ID"})
+		return
+	}
+
+	summary, err := h.reactionService.GetSummary(userID, targetType, targetID)
+	if err != nil {
+		respondReactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func respondReactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidReactionType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "post not found" || err.Error() == "comment not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
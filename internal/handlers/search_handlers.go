@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"GoVersi/internal/search"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+func NewSearchHandler(service *services.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: service}
+}
+
+// search posts, users or comments; type defaults to posts
+func (h *SearchHandler) Search(c *gin.Context) {
+	docType := search.DocumentType(c.DefaultQuery("type", string(search.TypePosts)))
+	limit := utils.ParseLimit(c.Query("limit"), 20, 50)
+
+	page, err := h.searchService.Search(c.Query("q"), docType, c.Query("cursor"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmptySearchQuery),
+			errors.Is(err, services.ErrInvalidSearchType),
+			errors.Is(err, utils.ErrInvalidCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
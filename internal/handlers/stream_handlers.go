@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"GoVersi/internal/middleware"
+	"GoVersi/internal/realtime"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+type StreamHandler struct {
+	hub               realtime.Hub
+	tickets           *realtime.TicketStore
+	blacklist         middleware.TokenBlacklistChecker
+	heartbeatInterval time.Duration
+}
+
+func NewStreamHandler(hub realtime.Hub, tickets *realtime.TicketStore, blacklist middleware.TokenBlacklistChecker, heartbeatInterval time.Duration) *StreamHandler {
+	return &StreamHandler{hub: hub, tickets: tickets, blacklist: blacklist, heartbeatInterval: heartbeatInterval}
+}
+
+// Tickets is the store IssueTicket hands tickets out of, for the stream middleware to redeem them
+func (h *StreamHandler) Tickets() *realtime.TicketStore {
+	return h.tickets
+}
+
+// IssueTicket exchanges the bearer token for a single-use ticket that opens the
+// stream as /stream?ticket=..., since EventSource cannot send Authorization
+func (h *StreamHandler) IssueTicket(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.tickets.Issue(realtime.StreamGrant{
+		UserID:    userID.String(),
+		SessionID: c.GetString("session_id"),
+		Token:     c.GetString("access_token"),
+		ExpiresAt: c.GetTime("token_expires_at"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue stream ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_in": int(h.tickets.TTL().Seconds())})
+}
+
+// Stream pushes notifications, friendship changes and friends' new posts as
+// server-sent events. Clients resume with the Last-Event-ID header (or the
+// last_event_id query parameter) after a reconnect. The stream ends with an
+// unauthorized event once the access token expires or is revoked.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	sub, backlog := h.hub.Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range backlog {
+		renderEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	token, expiresAt := c.GetString("access_token"), c.GetTime("token_expires_at")
+	expiry := time.NewTimer(time.Until(expiresAt))
+	defer expiry.Stop()
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, open := <-sub.Events:
+			if !open {
+				return false
+			}
+			renderEvent(c, event)
+			return true
+		case <-expiry.C:
+			c.Render(-1, sse.Event{Event: "unauthorized", Data: "token expired"})
+			return false
+		case now := <-heartbeat.C:
+			if h.revoked(token, expiresAt) {
+				c.Render(-1, sse.Event{Event: "unauthorized", Data: "token revoked"})
+				return false
+			}
+			c.Render(-1, sse.Event{Event: "heartbeat", Data: now.Unix()})
+			return true
+		}
+	})
+}
+
+// revoked re-checks the blacklist on each heartbeat so a logout also closes open
+// streams; lookup errors keep the stream, the token still expires on its own
+func (h *StreamHandler) revoked(token string, expiresAt time.Time) bool {
+	blacklisted, err := h.blacklist.IsTokenBlacklisted(token, expiresAt)
+	if err != nil {
+		log.Printf("Token blacklist lookup error: %v", err)
+		return false
+	}
+	return blacklisted
+}
+
+func renderEvent(c *gin.Context, event realtime.Event) {
+	c.Render(-1, sse.Event{Id: event.ID, Event: event.Type, Data: event.Data})
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TopicHandler struct {
+	topicService *services.TopicService
+	feedService  *services.FeedService
+}
+
+func NewTopicHandler(topicService *services.TopicService, feedService *services.FeedService) *TopicHandler {
+	return &TopicHandler{topicService: topicService, feedService: feedService}
+}
+
+func (h *TopicHandler) ListTopics(c *gin.Context) {
+	topics, err := h.topicService.ListTopics()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load topics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, topics)
+}
+
+// create a catalogue entry (moderators and admins)
+func (h *TopicHandler) CreateTopic(c *gin.Context) {
+	var request struct {
+		Name        string `json:"name" binding:"required"`
+		Slug        string `json:"slug"`
+		Description string `json:"description"`
+	}
+
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	topic, err := h.topicService.CreateTopic(actorID, request.Name, request.Slug, request.Description)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, repository.ErrTopicExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err.Error() == "topic name is required":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, topic)
+}
+
+func (h *TopicHandler) ListFollowedTopics(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	topics, err := h.topicService.ListFollowedTopics(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load topics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, topics)
+}
+
+// rank topics by posts created in ?window= (a Go duration such as 6h or 168h)
+func (h *TopicHandler) GetTrendingTopics(c *gin.Context) {
+	limit := utils.ParseLimit(c.Query("limit"), 10, 50)
+
+	topics, err := h.topicService.Trending(c.Query("window"), limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTrendingWindow) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load trending topics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, topics)
+}
+
+func (h *TopicHandler) GetTopic(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	topic, err := h.topicService.GetTopic(userID, c.Param("slug"))
+	if err != nil {
+		respondTopicError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, topic)
+}
+
+func (h *TopicHandler) FollowTopic(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.topicService.Follow(userID, c.Param("slug")); err != nil {
+		respondTopicError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Topic followed"})
+}
+
+func (h *TopicHandler) UnfollowTopic(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.topicService.Unfollow(userID, c.Param("slug")); err != nil {
+		respondTopicError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// posts of a topic, newest first, paginated with cursor/limit
+func (h *TopicHandler) GetTopicPosts(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	topic, err := h.topicService.GetTopic(userID, c.Param("slug"))
+	if err != nil {
+		respondTopicError(c, err)
+		return
+	}
+
+	limit := utils.ParseLimit(c.Query("limit"), 20, 100)
+
+	page, err := h.feedService.GetTopicFeed(userID, topic.Slug, c.Query("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func respondTopicError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrTopicNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	services "GoVersi/internal/service"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// the subset of the tus 1.0.0 protocol we speak: creation, HEAD for the
+// offset, PATCH to append and DELETE (termination)
+const (
+	tusVersion      = "1.0.0"
+	tusChunkContent = "application/offset+octet-stream"
+)
+
+type UploadHandler struct {
+	uploadService *services.UploadService
+}
+
+func NewUploadHandler(service *services.UploadService) *UploadHandler {
+	return &UploadHandler{uploadService: service}
+}
+
+func setUploadHeaders(c *gin.Context, session *models.UploadSession) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Cache-Control", "no-store")
+	if session != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+		c.Header("Upload-Length", strconv.FormatInt(session.Size, 10))
+		c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// uploadFilename reads the filename from the tus Upload-Metadata header
+// ("key base64value,key base64value")
+func uploadFilename(metadata string) string {
+	for _, pair := range strings.Split(metadata, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 2 && parts[0] == "filename" {
+			if name, err := base64.StdEncoding.DecodeString(parts[1]); err == nil {
+				return string(name)
+			}
+		}
+	}
+	return ""
+}
+
+func (h *UploadHandler) uploadID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUploadNotFound.Error()})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondResumableError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidUpload):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOffsetMismatch),
+		errors.Is(err, services.ErrUploadIncomplete),
+		errors.Is(err, services.ErrUploadFinalized):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateUpload opens a session; the size comes from Upload-Length
+func (h *UploadHandler) CreateUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	setUploadHeaders(c, nil)
+
+	size, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		respondResumableError(c, services.ErrInvalidUpload)
+		return
+	}
+
+	session, err := h.uploadService.CreateSession(userID, size, uploadFilename(c.GetHeader("Upload-Metadata")))
+	if err != nil {
+		respondResumableError(c, err)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Header("Location", "/uploads/"+session.ID.String())
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetUploadOffset tells a client where to resume
+func (h *UploadHandler) GetUploadOffset(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.uploadID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.uploadService.GetSession(userID, id)
+	if err != nil {
+		setUploadHeaders(c, nil)
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	setUploadHeaders(c, session)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload appends the request body at Upload-Offset
+func (h *UploadHandler) PatchUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.uploadID(c)
+	if !ok {
+		return
+	}
+	setUploadHeaders(c, nil)
+
+	if c.ContentType() != tusChunkContent {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + tusChunkContent})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset"})
+		return
+	}
+
+	session, err := h.uploadService.WriteChunk(userID, id, offset, c.Request.Body)
+	if session != nil {
+		setUploadHeaders(c, session)
+	}
+	if err != nil {
+		respondResumableError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// FinalizeUpload validates a complete upload and adds it to the attachments of a post
+func (h *UploadHandler) FinalizeUpload(c *gin.Context) {
+	var request struct {
+		PostID  uuid.UUID `json:"post_id" binding:"required"`
+		AltText string    `json:"alt_text"`
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.uploadID(c)
+	if !ok {
+		return
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	post, err := h.uploadService.Finalize(userID, id, request.PostID, request.AltText)
+	if err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		var unsupported *media.UnsupportedMediaError
+		switch {
+		case errors.As(err, &unsupported):
+			respondUploadError(c, err)
+		case err.Error() == "post not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTooManyMedia):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			respondResumableError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, post)
+}
+
+// DeleteUpload abandons an upload
+func (h *UploadHandler) DeleteUpload(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := h.uploadID(c)
+	if !ok {
+		return
+	}
+	setUploadHeaders(c, nil)
+
+	if err := h.uploadService.Cancel(userID, id); err != nil {
+		respondResumableError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,6 +4,7 @@ import (
 	"GoVersi/internal/models"
 	services "GoVersi/internal/service"
 	"GoVersi/internal/utils"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -30,6 +31,7 @@ func RegisterUser(c *gin.Context) {
 		Username string `json:"username" form:"username" binding:"required"`
 		Email    string `json:"email" form:"email" binding:"required"`
 		Password string `json:"password" form:"password" binding:"required"`
+		Locale   string `json:"locale" form:"locale"`
 	}
 
 	if err := c.ShouldBind(&request); err != nil {
@@ -40,10 +42,10 @@ func RegisterUser(c *gin.Context) {
 		}
 	}
 
-	imageURL, err := utils.HandleImageUpload(c, "uploads/imageProfile")
+	image, err := utils.HandleImageUpload(c, blobStore, "imageProfile", uploadLimits)
 	if err != nil {
 		log.Printf("Upload image error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondUploadError(c, err)
 		return
 	}
 
@@ -51,8 +53,13 @@ func RegisterUser(c *gin.Context) {
 		Username:     request.Username,
 		Email:        request.Email,
 		Password:     request.Password,
-		ImageProfile: imageURL,
+		ImageProfile: image,
 		IsActive:     true,
+		Locale:       request.Locale,
+	}
+	// without an explicit locale, emails follow the language of the browser
+	if user.Locale == "" {
+		user.Locale = preferredLanguage(c.GetHeader("Accept-Language"))
 	}
 
 	if err := userService.RegisterUser(user); err != nil {
@@ -69,6 +76,13 @@ func RegisterUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, user)
 }
 
+// preferredLanguage returns the first tag of an Accept-Language header, e.g. "pt-BR" for "pt-BR,pt;q=0.9"
+func preferredLanguage(header string) string {
+	tag, _, _ := strings.Cut(header, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	return strings.TrimSpace(tag)
+}
+
 func Login(c *gin.Context) {
 	var credentials struct {
 		Email    string `json:"email"`
@@ -80,13 +94,41 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token, err := userService.LoginUser(credentials.Email, credentials.Password)
+	tokens, err := userService.LoginUser(credentials.Email, credentials.Password)
 	if err != nil {
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, tokens)
+}
+
+func RefreshToken(c *gin.Context) {
+	var request struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	tokens, err := userService.RefreshTokens(request.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("refresh token error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
 }
 
 func Logout(c *gin.Context) {
@@ -98,10 +140,7 @@ func Logout(c *gin.Context) {
 
 	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-	log.Printf("Received token: %s", tokenString)
-
 	secretKey := os.Getenv("JWT_SECRET_KEY")
-	log.Printf("Secret Key during logout: %s", secretKey)
 
 	claims, err := utils.ParseTokenClaims(tokenString, secretKey)
 	if err != nil {
@@ -109,7 +148,15 @@ func Logout(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Token valid for user ID: %s", claims.UserID)
+	// revoke the refresh token family so the session cannot be renewed
+	if claims.SessionID != "" {
+		if err := userService.Logout(claims.SessionID); err != nil {
+			log.Printf("revoke session error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+			return
+		}
+	}
+
 	expirationTime := claims.ExpiresAt.Time
 
 	err = tokenBlacklistService.AddToTokenBlacklist(tokenString, expirationTime)
@@ -161,9 +208,17 @@ func GetUserByEmail(c *gin.Context) {
 
 // Handler delete user
 func DeleteUser(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	id := c.Param("id")
 
-	if err := userService.DeleteUser(id); err != nil {
+	if err := userService.DeleteUser(actorID, id); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		} else {
@@ -177,10 +232,25 @@ func DeleteUser(c *gin.Context) {
 
 // Handler suspend user account
 func SuspendUser(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	id := c.Param("id")
 
-	if err := userService.SuspendUser(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+	if err := userService.SuspendUser(actorID, id); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "invalid user ID":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		}
 		return
 	}
 
@@ -189,9 +259,17 @@ func SuspendUser(c *gin.Context) {
 
 // Handler delete account user solicitation
 func RequestAccountDeletion(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	id := c.Param("id")
 
-	if err := userService.RequestAccountDeletion(id); err != nil {
+	if err := userService.RequestAccountDeletion(actorID, id); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		} else {
@@ -205,9 +283,17 @@ func RequestAccountDeletion(c *gin.Context) {
 
 // Handler delete user account permanently
 func PermanentlyDeleteUser(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	id := c.Param("id")
 
-	if err := userService.PermanentlyDeleteUser(id); err != nil {
+	if err := userService.PermanentlyDeleteUser(actorID, id); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
 		if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		} else {
@@ -219,23 +305,36 @@ func PermanentlyDeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User will be deleted in 30 days"})
 }
 
-func ConfirmEmail(c *gin.Context) {
-	token := c.Query("token")
+// Handler change user role (admins only)
+func SetUserRole(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
 
-	// get user by token
-	user, err := userService.FindByEmailConfirmToken(token)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found or invalid token"})
+	var request struct {
+		Role models.Role `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	//
-	user.IsEmailVerified = true
-	user.EmailConfirmToken = ""
-	if err := userService.UpdateUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "verify email error"})
+	if err := userService.SetUserRole(actorID, c.Param("id"), request.Role); err != nil {
+		if respondForbidden(c, err) {
+			return
+		}
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "invalid role", "invalid user ID":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "email confirmed successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully"})
 }
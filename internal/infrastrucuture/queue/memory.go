@@ -0,0 +1,211 @@
+package queue
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrQueueClosed is returned when publishing to a closed MemoryQueue
+var ErrQueueClosed = errors.New("queue closed")
+
+// MemoryQueue is an in-process RabbitMQClient for local and test runs. It keeps
+// RabbitMQ's delivery semantics: unacknowledged messages count against the prefetch,
+// nacked messages are requeued at the head with Redelivered set, and retries wait in
+// timers instead of delay queues. Messages do not survive a restart.
+type MemoryQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queues  map[string][]memoryMessage
+	nextTag uint64
+	closed  bool
+	done    chan struct{}
+}
+
+type memoryMessage struct {
+	body        []byte
+	headers     amqp.Table
+	redelivered bool
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	q := &MemoryQueue{
+		queues: make(map[string][]memoryMessage),
+		done:   make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *MemoryQueue) Publish(queueName string, body []byte) error {
+	return q.publish(queueName, body, nil)
+}
+
+func (q *MemoryQueue) publish(queueName string, body []byte, headers amqp.Table) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	// copy the body like the broker would; callers may reuse their buffer
+	q.queues[queueName] = append(q.queues[queueName], memoryMessage{body: append([]byte(nil), body...), headers: headers})
+	q.cond.Broadcast()
+	return nil
+}
+
+func (q *MemoryQueue) Consume(queueName string) (<-chan amqp.Delivery, error) {
+	return q.consume(queueName, true, 0), nil
+}
+
+// Work has the semantics of RabbitMQ.Work; retries are held in timers for their delay
+func (q *MemoryQueue) Work(queueName string, policy RetryPolicy, prefetch int, handle func(Message) error) error {
+	w := worker{
+		queue:   queueName,
+		policy:  policy,
+		handle:  handle,
+		publish: q.publish,
+		retry: func(delay time.Duration, body []byte, headers amqp.Table) error {
+			time.AfterFunc(delay, func() {
+				q.publish(queueName, body, headers)
+			})
+			return nil
+		},
+	}
+	w.run(q.consume(queueName, false, prefetch), prefetch)
+	return nil
+}
+
+func (q *MemoryQueue) WorkDeadLetters(queueName string, handle func(DeadLetter) error) error {
+	drainDeadLetters(queueName, q.consume(DeadLetterQueue(queueName), false, 1), handle)
+	return nil
+}
+
+// Close stops every consumer; messages still queued or unacknowledged are dropped
+func (q *MemoryQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+	q.cond.Broadcast()
+}
+
+// consume feeds a channel from queueName until the queue is closed; with autoAck
+// off, at most prefetch deliveries (0 means unlimited) wait for an acknowledgement
+func (q *MemoryQueue) consume(queueName string, autoAck bool, prefetch int) <-chan amqp.Delivery {
+	c := &memoryConsumer{queue: q, name: queueName, prefetch: prefetch, unacked: make(map[uint64]memoryMessage)}
+	if autoAck {
+		c.prefetch = 0
+	}
+
+	deliveries := make(chan amqp.Delivery)
+	go func() {
+		defer close(deliveries)
+		for {
+			msg, tag, ok := c.next(autoAck)
+			if !ok {
+				return
+			}
+
+			d := amqp.Delivery{
+				Acknowledger: c,
+				Headers:      msg.headers,
+				DeliveryTag:  tag,
+				Redelivered:  msg.redelivered,
+				RoutingKey:   queueName,
+				Body:         msg.body,
+			}
+			select {
+			case deliveries <- d:
+			case <-q.done:
+				return
+			}
+		}
+	}()
+	return deliveries
+}
+
+// memoryConsumer tracks the deliveries of one consumer awaiting acknowledgement
+type memoryConsumer struct {
+	queue    *MemoryQueue
+	name     string
+	prefetch int
+	unacked  map[uint64]memoryMessage
+}
+
+// next waits for a message the consumer may take; false once the queue is closed
+func (c *memoryConsumer) next(autoAck bool) (memoryMessage, uint64, bool) {
+	q := c.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && (len(q.queues[c.name]) == 0 || (c.prefetch > 0 && len(c.unacked) >= c.prefetch)) {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return memoryMessage{}, 0, false
+	}
+
+	msg := q.queues[c.name][0]
+	q.queues[c.name] = q.queues[c.name][1:]
+	q.nextTag++
+	if !autoAck {
+		c.unacked[q.nextTag] = msg
+	}
+	return msg, q.nextTag, true
+}
+
+func (c *memoryConsumer) Ack(tag uint64, multiple bool) error {
+	return c.settle(tag, multiple, false)
+}
+
+func (c *memoryConsumer) Nack(tag uint64, multiple bool, requeue bool) error {
+	return c.settle(tag, multiple, requeue)
+}
+
+func (c *memoryConsumer) Reject(tag uint64, requeue bool) error {
+	return c.settle(tag, false, requeue)
+}
+
+// settle releases the given deliveries, putting them back at the head of the queue when requeue is set
+func (c *memoryConsumer) settle(tag uint64, multiple bool, requeue bool) error {
+	q := c.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var tags []uint64
+	if multiple {
+		for t := range c.unacked {
+			if t <= tag {
+				tags = append(tags, t)
+			}
+		}
+	} else if _, ok := c.unacked[tag]; ok {
+		tags = append(tags, tag)
+	} else {
+		return errors.New("unknown delivery tag")
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	var requeued []memoryMessage
+	for _, t := range tags {
+		if requeue {
+			msg := c.unacked[t]
+			msg.redelivered = true
+			requeued = append(requeued, msg)
+		}
+		delete(c.unacked, t)
+	}
+	if len(requeued) > 0 && !q.closed {
+		q.queues[c.name] = append(requeued, q.queues[c.name]...)
+	}
+	q.cond.Broadcast()
+	return nil
+}
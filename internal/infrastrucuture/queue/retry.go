@@ -0,0 +1,257 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// headers carried by retried and dead-lettered messages
+const (
+	headerRetries = "x-retries"
+	headerError   = "x-error"
+)
+
+// how long a dead letter that could not be archived waits before being requeued
+const deadLetterRetryDelay = 5 * time.Second
+
+// RetryPolicy bounds how often a failing message is delivered again
+type RetryPolicy struct {
+	MaxRetries int           // retries before the message is dead-lettered
+	BaseDelay  time.Duration // wait before the first retry, doubled for each following one
+}
+
+func (p RetryPolicy) delay(retry int) time.Duration {
+	return p.BaseDelay << (retry - 1)
+}
+
+// Message is a delivery handed to a worker
+type Message struct {
+	Body    []byte
+	Retries int // 0 on the first delivery
+}
+
+// DeadLetter is a message that exhausted its retries or failed permanently
+type DeadLetter struct {
+	Queue   string
+	Body    []byte
+	Error   string
+	Retries int
+}
+
+// DeadLetterQueue names the queue collecting the dead letters of queueName
+func DeadLetterQueue(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// retryQueue names the delay queue for one retry; the delay is part of the name
+// because RabbitMQ refuses to redeclare a queue with a different TTL
+func retryQueue(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, delay)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error that retrying cannot fix; the message is dead-lettered at once
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// DeclareRetryQueues declares the delay queues and the dead-letter queue of queueName.
+// A delay queue keeps messages for its TTL, then dead-letters them back to queueName.
+func (r *RabbitMQ) DeclareRetryQueues(queueName string, policy RetryPolicy) error {
+	for retry := 1; retry <= policy.MaxRetries; retry++ {
+		delay := policy.delay(retry)
+		_, err := r.Channel.QueueDeclare(retryQueue(queueName, delay), true, false, false, false, amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := r.Channel.QueueDeclare(DeadLetterQueue(queueName), true, false, false, false, nil)
+	return err
+}
+
+// Work consumes queueName with manual acknowledgements. prefetch bounds both the
+// messages in flight and the handlers running concurrently. A failed message is
+// acknowledged once it has been republished to the delay queue of its next retry,
+// or to the dead-letter queue when its retries are exhausted or the error is Permanent.
+// Work blocks until the connection is closed.
+func (r *RabbitMQ) Work(queueName string, policy RetryPolicy, prefetch int, handle func(Message) error) error {
+	if err := r.DeclareRetryQueues(queueName, policy); err != nil {
+		return err
+	}
+
+	deliveries, err := r.consumeManual(queueName, prefetch)
+	if err != nil {
+		return err
+	}
+
+	w := worker{
+		queue:   queueName,
+		policy:  policy,
+		handle:  handle,
+		publish: r.publish,
+		retry: func(delay time.Duration, body []byte, headers amqp.Table) error {
+			return r.publish(retryQueue(queueName, delay), body, headers)
+		},
+	}
+	w.run(deliveries, prefetch)
+	return nil
+}
+
+// WorkDeadLetters hands every message of the dead-letter queue of queueName to handle.
+// Messages are acknowledged once handled and requeued after a pause otherwise.
+func (r *RabbitMQ) WorkDeadLetters(queueName string, handle func(DeadLetter) error) error {
+	deliveries, err := r.consumeManual(DeadLetterQueue(queueName), 1)
+	if err != nil {
+		return err
+	}
+
+	drainDeadLetters(queueName, deliveries, handle)
+	return nil
+}
+
+// worker settles the deliveries of a queue; publish sends a message to a queue right
+// away and retry sends it back to the worked queue after delay. Both backends share it.
+type worker struct {
+	queue   string
+	policy  RetryPolicy
+	handle  func(Message) error
+	publish func(queueName string, body []byte, headers amqp.Table) error
+	retry   func(delay time.Duration, body []byte, headers amqp.Table) error
+}
+
+// run handles deliveries with concurrency goroutines until the channel is closed
+func (w worker) run(deliveries <-chan amqp.Delivery, concurrency int) {
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				w.settle(d)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (w worker) settle(d amqp.Delivery) {
+	retries := headerInt(d.Headers, headerRetries)
+	err := w.handle(Message{Body: d.Body, Retries: retries})
+	if err == nil {
+		d.Ack(false)
+		return
+	}
+
+	headers := amqp.Table{headerRetries: int32(retries + 1), headerError: err.Error()}
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) || retries >= w.policy.MaxRetries {
+		log.Printf("Dead-lettering message of %s after %d retries: %v", w.queue, retries, err)
+		err = w.publish(DeadLetterQueue(w.queue), d.Body, headers)
+	} else {
+		err = w.retry(w.policy.delay(retries+1), d.Body, headers)
+	}
+	if err != nil {
+		log.Printf("Failed to reroute message of %s, requeueing it: %v", w.queue, err)
+		d.Nack(false, true)
+		return
+	}
+	d.Ack(false)
+}
+
+func drainDeadLetters(queueName string, deliveries <-chan amqp.Delivery, handle func(DeadLetter) error) {
+	for d := range deliveries {
+		letter := DeadLetter{
+			Queue:   queueName,
+			Body:    d.Body,
+			Error:   headerString(d.Headers, headerError),
+			Retries: headerInt(d.Headers, headerRetries),
+		}
+		if err := handle(letter); err != nil {
+			log.Printf("Failed to handle dead letter of %s: %v", queueName, err)
+			time.Sleep(deadLetterRetryDelay)
+			d.Nack(false, true)
+			continue
+		}
+		d.Ack(false)
+	}
+}
+
+// consumeManual opens a dedicated channel so that its prefetch does not limit other consumers
+func (r *RabbitMQ) consumeManual(queueName string, prefetch int) (<-chan amqp.Delivery, error) {
+	ch, err := r.Conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return ch.Consume(queueName, "", false, false, false, false, nil)
+}
+
+func headerInt(headers amqp.Table, key string) int {
+	switch v := headers[key].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func headerString(headers amqp.Table, key string) string {
+	s, _ := headers[key].(string)
+	return s
+}
+
+// publish sends a persistent message and waits for the broker to confirm it
+func (r *RabbitMQ) publish(queueName string, body []byte, headers amqp.Table) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	confirmation, err := r.Channel.PublishWithDeferredConfirmWithContext(
+		ctx,
+		"",
+		queueName,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Headers:      headers,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("message rejected by the broker")
+	}
+	return nil
+}
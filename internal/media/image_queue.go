@@ -0,0 +1,40 @@
+package media
+
+import (
+	"GoVersi/internal/infrastrucuture/queue"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+const ImageProcessingQueue = "image_processing"
+
+// image owners whose records get the renditions
+const (
+	TargetPostMedia = "post_media"
+	TargetComment   = "comment"
+	TargetUser      = "user"
+)
+
+// ImageJob asks the pipeline to process the image stored at Key and owned by a post attachment, comment or user
+type ImageJob struct {
+	Target string    `json:"target"`
+	ID     uuid.UUID `json:"id"`
+	Key    string    `json:"key"`
+}
+
+type ImageQueue struct {
+	publisher queue.Publisher
+}
+
+func NewImageQueue(publisher queue.Publisher) *ImageQueue {
+	return &ImageQueue{publisher: publisher}
+}
+
+func (q *ImageQueue) Enqueue(job ImageJob) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.publisher.Publish(ImageProcessingQueue, body)
+}
@@ -0,0 +1,155 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var errNoMovieHeader = errors.New("no movie header found")
+
+// VideoInfo is what ProbeMP4 reads from the container headers
+type VideoInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+}
+
+// ProbeMP4 reads the duration and the video dimensions of an MP4 or QuickTime file
+// from its moov box (mvhd and the tkhd of the first visual track) without decoding it
+func ProbeMP4(r io.ReadSeeker, size int64) (*VideoInfo, error) {
+	info := &VideoInfo{}
+	found := false
+
+	err := walkBoxes(r, 0, size, func(kind string, start, end int64) error {
+		if kind != "moov" {
+			return nil
+		}
+		return walkBoxes(r, start, end, func(kind string, start, end int64) error {
+			switch kind {
+			case "mvhd":
+				duration, err := readMovieDuration(r, start, end)
+				if err != nil {
+					return err
+				}
+				info.Duration, found = duration, true
+			case "trak":
+				if info.Width != 0 {
+					return nil
+				}
+				return walkBoxes(r, start, end, func(kind string, start, end int64) error {
+					if kind != "tkhd" {
+						return nil
+					}
+					width, height, err := readTrackSize(r, start, end)
+					if err == nil {
+						info.Width, info.Height = width, height
+					}
+					return err
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errNoMovieHeader
+	}
+	return info, nil
+}
+
+// walkBoxes calls fn with the type and body bounds of every box between start and end
+func walkBoxes(r io.ReadSeeker, start, end int64, fn func(kind string, start, end int64) error) error {
+	var header [16]byte
+	for offset := start; offset+8 <= end; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		bodyStart := offset + 8
+		switch boxSize {
+		case 0: // box extends to the end of its parent
+			boxSize = end - offset
+		case 1: // 64-bit size follows the type
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			bodyStart += 8
+		}
+		if boxSize < bodyStart-offset || offset+boxSize > end {
+			return errors.New("malformed box " + kind)
+		}
+
+		if err := fn(kind, bodyStart, offset+boxSize); err != nil {
+			return err
+		}
+		offset += boxSize
+	}
+	return nil
+}
+
+func readBox(r io.ReadSeeker, start, end int64, limit int) ([]byte, error) {
+	length := end - start
+	if length > int64(limit) {
+		length = int64(limit)
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	_, err := io.ReadFull(r, data)
+	return data, err
+}
+
+func readMovieDuration(r io.ReadSeeker, start, end int64) (time.Duration, error) {
+	data, err := readBox(r, start, end, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	var timescale, duration uint64
+	switch {
+	case len(data) >= 20 && data[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	case len(data) >= 32 && data[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+		duration = binary.BigEndian.Uint64(data[24:32])
+	default:
+		return 0, errors.New("unsupported mvhd box")
+	}
+	if timescale == 0 {
+		return 0, errors.New("mvhd box has no timescale")
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// readTrackSize returns the presentation size of a track; audio tracks report 0x0
+func readTrackSize(r io.ReadSeeker, start, end int64) (int, int, error) {
+	data, err := readBox(r, start, end, 96)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// the size is the last field, after the version-dependent times and the matrix
+	offset := 76
+	if len(data) > 0 && data[0] == 1 {
+		offset = 88
+	}
+	if len(data) < offset+8 {
+		return 0, 0, errors.New("short tkhd box")
+	}
+	// 16.16 fixed point
+	width := int(binary.BigEndian.Uint32(data[offset:offset+4]) >> 16)
+	height := int(binary.BigEndian.Uint32(data[offset+4:offset+8]) >> 16)
+	return width, height, nil
+}
@@ -0,0 +1,132 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// Rendition is a fixed-size variant produced for every processed image
+type Rendition struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool // fill the box and crop instead of fitting inside it
+}
+
+var Renditions = []Rendition{
+	{Name: "thumb", Width: 150, Height: 150, Crop: true},
+	{Name: "medium", Width: 600, Height: 600},
+	{Name: "large", Width: 1200, Height: 1200},
+}
+
+// ProcessedImage holds the re-encoded original, free of metadata, and its renditions.
+// Original is nil when the source is kept as is (GIFs, to preserve animation).
+type ProcessedImage struct {
+	Original          []byte
+	OriginalExtension string
+	Renditions        map[string][]byte // JPEG encoded
+	Width             int               // after applying the orientation
+	Height            int
+}
+
+type Processor struct {
+	jpegQuality int
+}
+
+// ErrUndecodableImage means the upload is not an image the pipeline can read; retrying won't help
+var ErrUndecodableImage = errors.New("undecodable image")
+
+func NewProcessor(jpegQuality int) *Processor {
+	return &Processor{jpegQuality: jpegQuality}
+}
+
+// Process decodes an uploaded image, applies its EXIF orientation and re-encodes it.
+// Re-encoding drops EXIF, XMP and every other metadata block, GPS position included.
+func (p *Processor) Process(data []byte) (*ProcessedImage, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUndecodableImage, err)
+	}
+
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	result := &ProcessedImage{
+		Renditions: make(map[string][]byte, len(Renditions)),
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+	}
+
+	switch format {
+	case "gif":
+		// no EXIF in GIFs; re-encoding would only lose the animation
+	case "png":
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		result.Original, result.OriginalExtension = buf.Bytes(), ".png"
+	default:
+		encoded, err := p.encodeJPEG(img)
+		if err != nil {
+			return nil, err
+		}
+		result.Original, result.OriginalExtension = encoded, ".jpg"
+	}
+
+	for _, r := range Renditions {
+		encoded, err := p.encodeJPEG(resize(img, r))
+		if err != nil {
+			return nil, err
+		}
+		result.Renditions[r.Name] = encoded
+	}
+	return result, nil
+}
+
+// encodeJPEG flattens transparency onto white, since JPEG has no alpha channel
+func (p *Processor) encodeJPEG(img image.Image) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: p.jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resize scales img to the rendition box; images are never upscaled
+func resize(img image.Image, r Rendition) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	src := bounds
+	if r.Crop {
+		// centered crop to the aspect ratio of the box
+		if w*r.Height > h*r.Width {
+			cw := h * r.Width / r.Height
+			src = image.Rect(bounds.Min.X+(w-cw)/2, bounds.Min.Y, bounds.Min.X+(w-cw)/2+cw, bounds.Max.Y)
+		} else {
+			ch := w * r.Height / r.Width
+			src = image.Rect(bounds.Min.X, bounds.Min.Y+(h-ch)/2, bounds.Max.X, bounds.Min.Y+(h-ch)/2+ch)
+		}
+		w, h = src.Dx(), src.Dy()
+	}
+
+	scale := min(float64(r.Width)/float64(w), float64(r.Height)/float64(h), 1)
+	dw, dh := max(int(float64(w)*scale), 1), max(int(float64(h)*scale), 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,176 @@
+// Package media inspects uploaded files by their content rather than their names.
+package media
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	_ "golang.org/x/image/webp"
+)
+
+type Kind string
+
+const (
+	KindImage Kind = "image"
+	KindVideo Kind = "video"
+)
+
+// accepted content types and the extension files are stored with
+var allowedTypes = map[Kind]map[string]string{
+	KindImage: {
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	},
+	KindVideo: {
+		"video/mp4":        ".mp4",
+		"video/quicktime":  ".mov",
+		"video/x-msvideo":  ".avi",
+		"video/x-ms-asf":   ".wmv",
+		"video/x-matroska": ".mkv",
+		"video/webm":       ".webm",
+	},
+}
+
+// Limits bounds what an upload may contain
+type Limits struct {
+	MaxImageBytes  int64
+	MaxVideoBytes  int64
+	MaxImageWidth  int
+	MaxImageHeight int
+	MaxImagePixels int
+}
+
+func (l Limits) maxBytes(kind Kind) int64 {
+	if kind == KindVideo {
+		return l.MaxVideoBytes
+	}
+	return l.MaxImageBytes
+}
+
+// UnsupportedMediaError is returned when the content does not match an accepted type
+type UnsupportedMediaError struct {
+	Reason string
+}
+
+func (e *UnsupportedMediaError) Error() string {
+	return e.Reason
+}
+
+// TooLargeError is returned when a file exceeds the size limit of its kind
+type TooLargeError struct {
+	Kind  Kind
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s file too large (max %dMB)", e.Kind, e.Limit/(1024*1024))
+}
+
+// Info describes a validated file
+type Info struct {
+	Kind      Kind
+	MIME      string
+	Extension string
+	Width     int // images, and MP4/QuickTime videos
+	Height    int
+	Duration  time.Duration // MP4/QuickTime videos
+}
+
+// Inspect detects the type of r from its magic bytes and checks it against the
+// limits; images are fully decoded. r is rewound before returning.
+func Inspect(r io.ReadSeeker, size int64, kind Kind, limits Limits) (*Info, error) {
+	if max := limits.maxBytes(kind); max > 0 && size > max {
+		return nil, &TooLargeError{Kind: kind, Limit: max}
+	}
+	return inspect(r, size, []Kind{kind}, limits)
+}
+
+// InspectAny works like Inspect for a file that may be either an image or a video
+func InspectAny(r io.ReadSeeker, size int64, limits Limits) (*Info, error) {
+	return inspect(r, size, []Kind{KindImage, KindVideo}, limits)
+}
+
+func inspect(r io.ReadSeeker, size int64, kinds []Kind, limits Limits) (*Info, error) {
+	detected, err := mimetype.DetectReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	for _, kind := range kinds {
+		for mime, ext := range allowedTypes[kind] {
+			if detected.Is(mime) {
+				info.Kind, info.MIME, info.Extension = kind, mime, ext
+				break
+			}
+		}
+	}
+	if info.MIME == "" {
+		name := "media"
+		if len(kinds) == 1 {
+			name = string(kinds[0])
+		}
+		return nil, &UnsupportedMediaError{Reason: fmt.Sprintf("unsupported %s type %s", name, detected.String())}
+	}
+	if max := limits.maxBytes(info.Kind); max > 0 && size > max {
+		return nil, &TooLargeError{Kind: info.Kind, Limit: max}
+	}
+
+	switch {
+	case info.Kind == KindImage:
+		if err := checkImage(r, info, limits); err != nil {
+			return nil, err
+		}
+	case info.MIME == "video/mp4" || info.MIME == "video/quicktime":
+		// the headers are informative only; players cope with files we cannot parse
+		if video, err := ProbeMP4(r, size); err == nil {
+			info.Width, info.Height, info.Duration = video.Width, video.Height, video.Duration
+		}
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// checkImage reads the dimensions first so oversized images are refused before being decoded
+func checkImage(r io.ReadSeeker, info *Info, limits Limits) error {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return &UnsupportedMediaError{Reason: "image could not be decoded: " + err.Error()}
+	}
+
+	if (limits.MaxImageWidth > 0 && cfg.Width > limits.MaxImageWidth) ||
+		(limits.MaxImageHeight > 0 && cfg.Height > limits.MaxImageHeight) {
+		return &UnsupportedMediaError{Reason: fmt.Sprintf("image is %dx%d, larger than the allowed %dx%d",
+			cfg.Width, cfg.Height, limits.MaxImageWidth, limits.MaxImageHeight)}
+	}
+	if limits.MaxImagePixels > 0 && cfg.Width*cfg.Height > limits.MaxImagePixels {
+		return &UnsupportedMediaError{Reason: fmt.Sprintf("image has %d pixels, more than the allowed %d",
+			cfg.Width*cfg.Height, limits.MaxImagePixels)}
+	}
+	if cfg.Width == 0 || cfg.Height == 0 {
+		return &UnsupportedMediaError{Reason: "image has no pixels"}
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, _, err := image.Decode(r); err != nil {
+		return &UnsupportedMediaError{Reason: "image could not be decoded: " + err.Error()}
+	}
+
+	info.Width, info.Height = cfg.Width, cfg.Height
+	return nil
+}
@@ -1,15 +1,23 @@
 package middleware
 
 import (
+	"GoVersi/internal/realtime"
 	"GoVersi/internal/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// TokenBlacklistChecker tells whether an access token was revoked (e.g. on logout)
+type TokenBlacklistChecker interface {
+	IsTokenBlacklisted(token string, expiresAt time.Time) (bool, error)
+}
+
+func AuthMiddleware(secretKey string, blacklist TokenBlacklistChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
@@ -23,6 +31,9 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 		}
 
 		claims, err := utils.ParseTokenClaims(tokenString, secretKey)
+		if err == nil && claims.ExpiresAt == nil {
+			err = errors.New("token has no expiration")
+		}
 		if err != nil {
 			log.Printf("Token parsing error: %v", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -30,8 +41,71 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
-		log.Printf("Token valid for user ID: %s", claims.UserID)
-		c.Set("user_id", claims.UserID)
+		blacklisted, err := blacklist.IsTokenBlacklisted(tokenString, claims.ExpiresAt.Time)
+		if err != nil {
+			log.Printf("Token blacklist lookup error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+			c.Abort()
+			return
+		}
+		if blacklisted {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		setIdentity(c, claims.UserID, claims.SessionID, tokenString, claims.ExpiresAt.Time)
 		c.Next()
 	}
 }
+
+// StreamTicketRedeemer exchanges a single-use stream ticket for the grant it was issued for
+type StreamTicketRedeemer interface {
+	Redeem(ticket string) (realtime.StreamGrant, bool)
+}
+
+// StreamAuthMiddleware authenticates the event stream with the ticket query
+// parameter, falling back to the Authorization header like AuthMiddleware
+func StreamAuthMiddleware(secretKey string, blacklist TokenBlacklistChecker, tickets StreamTicketRedeemer) gin.HandlerFunc {
+	authenticate := AuthMiddleware(secretKey, blacklist)
+	return func(c *gin.Context) {
+		value := c.Query("ticket")
+		if value == "" {
+			authenticate(c)
+			return
+		}
+
+		grant, ok := tickets.Redeem(value)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream ticket"})
+			c.Abort()
+			return
+		}
+
+		// the token may have been revoked since the ticket was issued
+		blacklisted, err := blacklist.IsTokenBlacklisted(grant.Token, grant.ExpiresAt)
+		if err != nil {
+			log.Printf("Token blacklist lookup error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+			c.Abort()
+			return
+		}
+		if blacklisted {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		setIdentity(c, grant.UserID, grant.SessionID, grant.Token, grant.ExpiresAt)
+		c.Next()
+	}
+}
+
+// setIdentity stores the authenticated user; the token and its expiry let long
+// lived requests such as the stream notice when it is revoked or expires
+func setIdentity(c *gin.Context, userID, sessionID, token string, expiresAt time.Time) {
+	c.Set("user_id", userID)
+	c.Set("session_id", sessionID)
+	c.Set("access_token", token)
+	c.Set("token_expires_at", expiresAt)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction names a security-relevant change to an account
+type AuditAction string
+
+const (
+	AuditPasswordChanged      AuditAction = "password_changed"
+	AuditEmailChangeRequested AuditAction = "email_change_requested"
+	AuditEmailChanged         AuditAction = "email_changed"
+)
+
+// AuditEvent records who changed what on an account, and from where
+type AuditEvent struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID   `json:"user_id" gorm:"type:uuid;index;not null"`
+	Action    AuditAction `json:"action" gorm:"index;not null"`
+	Detail    string      `json:"detail,omitempty"`
+	IP        string      `json:"ip,omitempty"`
+	UserAgent string      `json:"user_agent,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
@@ -7,11 +7,13 @@ import (
 )
 
 type Comment struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	Content   string    `json:"content"`
-	PostID    string    `json:"post_id"`
-	AuthorID  uuid.UUID `json:"author_id"`
-	ImageURL  string    `json:"image_url"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Content         string     `json:"content"`
+	PostID          string     `json:"post_id" gorm:"index"`
+	ParentID        *uuid.UUID `json:"parent_id,omitempty" gorm:"type:uuid;index"` // nil for top-level comments
+	AuthorID        uuid.UUID  `json:"author_id"`
+	ImageURL        MediaKey   `json:"image_url"`
+	ImageRenditions Renditions `json:"image_renditions,omitempty" gorm:"type:jsonb"` // filled by the image pipeline
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
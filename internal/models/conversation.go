@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation between two friends. The pair is stored ordered (UserAID < UserBID)
+// so there is at most one conversation per pair of users.
+type Conversation struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserAID        uuid.UUID `json:"user_a_id" gorm:"type:uuid;not null;uniqueIndex:idx_conversations_pair,priority:1"`
+	UserBID        uuid.UUID `json:"user_b_id" gorm:"type:uuid;not null;uniqueIndex:idx_conversations_pair,priority:2"`
+	LastActivityAt time.Time `json:"last_activity_at" gorm:"not null;index"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ConversationMember keeps the read state of one participant
+type ConversationMember struct {
+	ConversationID uuid.UUID  `json:"conversation_id" gorm:"type:uuid;primaryKey"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	UnreadCount    int        `json:"unread_count" gorm:"not null;default:0"`
+	LastReadAt     *time.Time `json:"last_read_at,omitempty"`
+}
+
+type Message struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ConversationID uuid.UUID  `json:"conversation_id" gorm:"type:uuid;not null;index:idx_messages_conversation_created,priority:1"`
+	SenderID       uuid.UUID  `json:"sender_id" gorm:"type:uuid;not null"`
+	Content        string     `json:"content"`
+	ImageURL       MediaKey   `json:"image_url"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index:idx_messages_conversation_created,priority:2"`
+}
+
+// OrderedPair returns the two user ids in the order used by Conversation
+func OrderedPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if a.String() < b.String() {
+		return a, b
+	}
+	return b, a
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetter is a queued message the workers gave up on, archived for inspection and replay.
+// Redacted letters had secrets blanked out of their payload and cannot be replayed.
+type DeadLetter struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Queue      string     `json:"queue" gorm:"not null;index"`
+	Payload    []byte     `json:"-" gorm:"type:bytea;not null"`
+	Error      string     `json:"error"`
+	Retries    int        `json:"retries" gorm:"not null;default:0"`
+	Redacted   bool       `json:"redacted" gorm:"not null;default:false"`
+	ReplayedAt *time.Time `json:"replayed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
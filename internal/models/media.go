@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MediaKey is the storage key of an uploaded file. The key is what gets stored;
+// API responses render it as a signed, time-limited URL.
+type MediaKey string
+
+var mediaURLSigner func(key string) string
+
+// SetMediaURLSigner installs the function turning keys into URLs in API responses
+func SetMediaURLSigner(signer func(key string) string) {
+	mediaURLSigner = signer
+}
+
+func (k MediaKey) URL() string {
+	if k == "" || mediaURLSigner == nil {
+		return string(k)
+	}
+	return mediaURLSigner(string(k))
+}
+
+func (k MediaKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.URL())
+}
+
+// Renditions maps a rendition name (thumb, medium, large) to its key. It is stored
+// as jsonb and rendered, like MediaKey, as signed URLs.
+type Renditions map[string]MediaKey
+
+// Value stores the plain keys; marshalling MediaKey itself would store signed URLs
+func (r Renditions) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	keys := make(map[string]string, len(r))
+	for name, key := range r {
+		keys[name] = string(key)
+	}
+	return json.Marshal(keys)
+}
+
+func (r *Renditions) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Renditions", value)
+	}
+
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	if keys == nil {
+		*r = nil
+		return nil
+	}
+	*r = make(Renditions, len(keys))
+	for name, key := range keys {
+		(*r)[name] = MediaKey(key)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// MediaObject is a file of the blob store and the number of records using it.
+// Files left without references are deleted by the media sweeper after a grace period.
+type MediaObject struct {
+	Key            string     `json:"key" gorm:"primaryKey"`
+	Size           int64      `json:"size"`
+	ContentType    string     `json:"content_type"`
+	RefCount       int        `json:"ref_count" gorm:"not null;default:0"`
+	UnreferencedAt *time.Time `json:"unreferenced_at,omitempty" gorm:"index"` // set while RefCount is 0
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationComment        NotificationType = "comment"         // someone commented on your post
+	NotificationReply          NotificationType = "reply"           // someone replied to your comment
+	NotificationReaction       NotificationType = "reaction"        // someone reacted to your post or comment
+	NotificationFriendRequest  NotificationType = "friend_request"  // someone wants to be your friend
+	NotificationFriendAccepted NotificationType = "friend_accepted" // someone accepted your friend request
+)
+
+// Notification groups every unread event of the same type on the same target,
+// e.g. "5 people liked your post" is a single row with ActorCount = 5
+type Notification struct {
+	ID          uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	RecipientID uuid.UUID        `json:"recipient_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_notifications_unread_group,priority:1,where:read_at IS NULL"`
+	Type        NotificationType `json:"type" gorm:"type:varchar(32);not null;uniqueIndex:idx_notifications_unread_group,priority:2,where:read_at IS NULL"`
+	TargetType  string           `json:"target_type" gorm:"type:varchar(32);not null;uniqueIndex:idx_notifications_unread_group,priority:3,where:read_at IS NULL"`
+	TargetID    uuid.UUID        `json:"target_id" gorm:"type:uuid;not null;uniqueIndex:idx_notifications_unread_group,priority:4,where:read_at IS NULL"`
+	LastActorID uuid.UUID        `json:"last_actor_id" gorm:"type:uuid;not null"`
+	ActorCount  int              `json:"actor_count" gorm:"not null;default:1"`
+	ReadAt      *time.Time       `json:"read_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at" gorm:"index"`
+}
+
+// NotificationActor records who contributed to a grouped notification, so the
+// same user reacting twice is only counted once
+type NotificationActor struct {
+	NotificationID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ActorID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt      time.Time
+}
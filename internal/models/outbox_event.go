@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxFailed  OutboxStatus = "failed" // gave up after the maximum number of attempts
+)
+
+// OutboxEvent is a message waiting to be published to a queue. It is written in the
+// same transaction as the change it announces and relayed once that change is committed.
+// Sent events keep no payload; failed ones keep it for a replay, with the one-time
+// links of emails redacted.
+type OutboxEvent struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Queue         string       `json:"queue" gorm:"not null"`
+	Payload       []byte       `json:"payload" gorm:"type:bytea;not null"`
+	Status        OutboxStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending';index:idx_outbox_status_next,priority:1"`
+	Attempts      int          `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"not null;index:idx_outbox_status_next,priority:2"`
+	LastError     string       `json:"last_error,omitempty"`
+	Redacted      bool         `json:"redacted" gorm:"not null;default:false"` // one-time links were blanked, the event cannot be replayed
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
@@ -7,13 +7,12 @@ import (
 )
 
 type Post struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Topic     string    `json:"topic"`
-	AuthorID  uuid.UUID `json:"author_id"`
-	ImageURL  string    `json:"image_url"`
-	VideoURL  string    `json:"video_url"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Title     string      `json:"title"`
+	Content   string      `json:"content"`
+	Topic     string      `json:"topic" gorm:"index:idx_posts_topic_created,priority:1"` // slug of a models.Topic
+	AuthorID  uuid.UUID   `json:"author_id" gorm:"index:idx_posts_author_created,priority:1"`
+	Media     []PostMedia `json:"media" gorm:"foreignKey:PostID"` // ordered by position
+	CreatedAt time.Time   `json:"created_at" gorm:"index:idx_posts_author_created,priority:2;index:idx_posts_topic_created,priority:2"`
+	UpdatedAt time.Time   `json:"updated_at"`
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MediaType string
+
+const (
+	MediaTypeImage MediaType = "image"
+	MediaTypeVideo MediaType = "video"
+)
+
+type MediaStatus string
+
+const (
+	MediaProcessing MediaStatus = "processing" // waiting for the image pipeline
+	MediaReady      MediaStatus = "ready"
+	MediaFailed     MediaStatus = "failed"
+)
+
+// PostMedia is a file attached to a post; a post returns its attachments ordered by Position
+type PostMedia struct {
+	ID         uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	PostID     uuid.UUID   `json:"post_id" gorm:"type:uuid;not null;uniqueIndex:idx_post_media_post_position,priority:1"`
+	Position   int         `json:"position" gorm:"not null;uniqueIndex:idx_post_media_post_position,priority:2"`
+	Type       MediaType   `json:"type" gorm:"type:varchar(16);not null"`
+	URL        MediaKey    `json:"url" gorm:"not null"`
+	MimeType   string      `json:"mime_type"`
+	Width      int         `json:"width,omitempty"`
+	Height     int         `json:"height,omitempty"`
+	DurationMS int64       `json:"duration_ms,omitempty"` // videos only
+	AltText    string      `json:"alt_text"`
+	Status     MediaStatus `json:"status" gorm:"type:varchar(16);not null;default:'ready'"`
+	Renditions Renditions  `json:"renditions,omitempty" gorm:"type:jsonb"` // filled by the image pipeline
+	CreatedAt  time.Time   `json:"created_at"`
+}
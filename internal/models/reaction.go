@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReactionType string
+
+const (
+	ReactionLike  ReactionType = "like"
+	ReactionLove  ReactionType = "love"
+	ReactionHaha  ReactionType = "haha"
+	ReactionWow   ReactionType = "wow"
+	ReactionSad   ReactionType = "sad"
+	ReactionAngry ReactionType = "angry"
+)
+
+// ReactionTypes lists every supported reaction, in display order
+var ReactionTypes = []ReactionType{ReactionLike, ReactionLove, ReactionHaha, ReactionWow, ReactionSad, ReactionAngry}
+
+func (t ReactionType) IsValid() bool {
+	for _, valid := range ReactionTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+type ReactionTarget string
+
+const (
+	ReactionTargetPost    ReactionTarget = "post"
+	ReactionTargetComment ReactionTarget = "comment"
+)
+
+// Reaction of a user to a post or a comment; a user holds at most one reaction per target
+type Reaction struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_reactions_user_target,priority:1"`
+	TargetType ReactionTarget `json:"target_type" gorm:"type:varchar(16);not null;uniqueIndex:idx_reactions_user_target,priority:2;index:idx_reactions_target,priority:1"`
+	TargetID   uuid.UUID      `json:"target_id" gorm:"type:uuid;not null;uniqueIndex:idx_reactions_user_target,priority:3;index:idx_reactions_target,priority:2"`
+	Type       ReactionType   `json:"type" gorm:"type:varchar(16);not null"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
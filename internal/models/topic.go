@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Topic is an entry of the topic catalogue; posts reference it by slug
+type Topic struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Slug          string    `json:"slug" gorm:"uniqueIndex;not null"`
+	Name          string    `json:"name" gorm:"not null"`
+	Description   string    `json:"description"`
+	FollowerCount int64     `json:"follower_count" gorm:"not null;default:0"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type TopicFollow struct {
+	TopicID   uuid.UUID `json:"topic_id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
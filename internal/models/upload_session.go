@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UploadStatus string
+
+const (
+	UploadPending   UploadStatus = "pending"
+	UploadCompleted UploadStatus = "completed"
+)
+
+// UploadSession tracks a resumable upload; bytes are appended to a temporary
+// file until Offset reaches Size and the upload is finalized
+type UploadSession struct {
+	ID        uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	UserID    uuid.UUID    `json:"user_id" gorm:"type:uuid;not null;index"`
+	Filename  string       `json:"filename"`
+	Size      int64        `json:"size" gorm:"not null"`
+	Offset    int64        `json:"offset" gorm:"not null;default:0"`
+	Status    UploadStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending'"`
+	ExpiresAt time.Time    `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
@@ -12,16 +12,20 @@ type User struct {
 	Username            string     `json:"username" gorm:"unique;not null"`
 	Email               string     `json:"email" gorm:"unique;not null"`
 	Password            string     `json:"password" gorm:"not null"`
-	ImageProfile        string     `json:"image_url"`
+	ImageProfile        MediaKey   `json:"image_url"`
+	ImageRenditions     Renditions `json:"image_renditions,omitempty" gorm:"type:jsonb"` // filled by the image pipeline
+	Role                Role       `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
 	IsActive            bool       `json:"is_active"`
 	IsPendingDeletion   bool       `json:"is_pending_deletion"`
 	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
 	IsEmailVerified     bool       `json:"is_email_verified" gorm:"default:false"`
-	EmailConfirmToken   string     `json:"email_confirm_token" gorm:"unique;not null"` // Novo campo para o token de confirmação
+	Locale              string     `json:"locale" gorm:"type:varchar(16)"` // language of the emails sent to the user
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	u.ID = uuid.New()
-	u.EmailConfirmToken = uuid.New().String() // Gera um token único
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 	return
 }
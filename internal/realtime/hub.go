@@ -0,0 +1,40 @@
+package realtime
+
+import (
+	"github.com/google/uuid"
+)
+
+// Event is a message pushed to every open connection of a user
+type Event struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// EventReset tells a resuming client that the events it missed are no longer
+// known, e.g. after a restart, so it has to reload its state
+const EventReset = "reset"
+
+// Hub fans events out to the connections of each user. The in-process
+// MemoryHub only reaches clients connected to the same instance; a broker
+// backed implementation can be plugged in behind this interface.
+type Hub interface {
+	// Publish delivers an event to every connection of the user
+	Publish(userID uuid.UUID, eventType string, data interface{})
+
+	// Subscribe opens a connection for the user. Events published after
+	// lastEventID that are still retained are returned as backlog; when some
+	// of them are not, the backlog is a single EventReset instead.
+	Subscribe(userID uuid.UUID, lastEventID string) (*Subscription, []Event)
+}
+
+// Subscription is a single connection of a user
+type Subscription struct {
+	Events <-chan Event
+	close  func()
+}
+
+// Close unregisters the connection; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.close()
+}
@@ -0,0 +1,172 @@
+package realtime
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+)
+
+const subscriberBuffer = 64
+
+type subscriber struct {
+	events chan Event
+	once   sync.Once
+}
+
+type userStream struct {
+	subscribers map[*subscriber]struct{}
+	history     []Event // most recent events, oldest first
+	// floor is the id of the newest event of the user that is no longer retained,
+	// or the sequence when the stream was (re)created; clients behind it missed events
+	floor      uint64
+	lastActive time.Time
+}
+
+// MemoryHub is an in-process Hub that keeps the last events of each user for resumption
+type MemoryHub struct {
+	mu          sync.Mutex
+	seq         uint64
+	historySize int
+	idleTTL     time.Duration // streams without connections are dropped after this long
+	streams     map[uuid.UUID]*userStream
+	cron        *cron.Cron
+}
+
+func NewMemoryHub(historySize int, idleTTL time.Duration) *MemoryHub {
+	return &MemoryHub{
+		historySize: historySize,
+		idleTTL:     idleTTL,
+		streams:     make(map[uuid.UUID]*userStream),
+	}
+}
+
+func (h *MemoryHub) Publish(userID uuid.UUID, eventType string, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stream := h.stream(userID)
+	h.seq++
+	event := Event{ID: strconv.FormatUint(h.seq, 10), Type: eventType, Data: data}
+
+	stream.lastActive = time.Now()
+	stream.history = append(stream.history, event)
+	if len(stream.history) > h.historySize {
+		trimmed := stream.history[len(stream.history)-h.historySize-1]
+		stream.floor, _ = strconv.ParseUint(trimmed.ID, 10, 64)
+		stream.history = stream.history[len(stream.history)-h.historySize:]
+	}
+
+	for sub := range stream.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			// the client is not keeping up; drop the connection so it reconnects
+			// and resumes from its last event id instead of silently missing events
+			log.Printf("Realtime subscriber of user %s is too slow, disconnecting", userID)
+			h.remove(userID, sub)
+		}
+	}
+}
+
+func (h *MemoryHub) Subscribe(userID uuid.UUID, lastEventID string) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stream := h.stream(userID)
+	stream.lastActive = time.Now()
+	sub := &subscriber{events: make(chan Event, subscriberBuffer)}
+	stream.subscribers[sub] = struct{}{}
+
+	var backlog []Event
+	if lastEventID != "" {
+		last, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil || last > h.seq || last < stream.floor {
+			// the id comes from before a restart, or the events after it were
+			// dropped; the client has to reload instead of trusting a partial backlog
+			return h.subscription(userID, sub), []Event{{ID: strconv.FormatUint(h.seq, 10), Type: EventReset}}
+		}
+		for _, event := range stream.history {
+			if id, _ := strconv.ParseUint(event.ID, 10, 64); id > last {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	return h.subscription(userID, sub), backlog
+}
+
+// EvictIdle drops the streams that had no connection nor event for idleTTL and
+// returns how many were dropped; clients resuming from them get a reset event
+func (h *MemoryHub) EvictIdle() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	evicted := 0
+	cutoff := time.Now().Add(-h.idleTTL)
+	for userID, stream := range h.streams {
+		if len(stream.subscribers) == 0 && stream.lastActive.Before(cutoff) {
+			delete(h.streams, userID)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// StartCronJob schedules the eviction of idle streams
+func (h *MemoryHub) StartCronJob() {
+	h.cron = cron.New()
+	h.cron.AddFunc("@every 1m", func() {
+		if evicted := h.EvictIdle(); evicted > 0 {
+			log.Printf("Evicted %d idle realtime streams", evicted)
+		}
+	})
+	h.cron.Start()
+}
+
+func (h *MemoryHub) StopCronJob() {
+	if h.cron != nil {
+		h.cron.Stop()
+	}
+}
+
+// subscription wraps a registered subscriber; callers hold h.mu
+func (h *MemoryHub) subscription(userID uuid.UUID, sub *subscriber) *Subscription {
+
+	return &Subscription{
+		Events: sub.events,
+		close: func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.remove(userID, sub)
+		},
+	}
+}
+
+// stream returns the state of a user, creating it when needed; callers hold h.mu
+func (h *MemoryHub) stream(userID uuid.UUID) *userStream {
+	stream, ok := h.streams[userID]
+	if !ok {
+		// events published for the user before now are unknown to a new stream
+		stream = &userStream{subscribers: make(map[*subscriber]struct{}), floor: h.seq}
+		h.streams[userID] = stream
+	}
+	return stream
+}
+
+// remove unregisters a subscriber and closes its channel; callers hold h.mu
+func (h *MemoryHub) remove(userID uuid.UUID, sub *subscriber) {
+	stream, ok := h.streams[userID]
+	if !ok {
+		return
+	}
+
+	if _, ok := stream.subscribers[sub]; ok {
+		delete(stream.subscribers, sub)
+		stream.lastActive = time.Now()
+		sub.once.Do(func() { close(sub.events) })
+	}
+}
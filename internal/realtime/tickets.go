@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"GoVersi/internal/utils"
+	"sync"
+	"time"
+)
+
+// StreamGrant is the access token a stream ticket was issued against
+type StreamGrant struct {
+	UserID    string
+	SessionID string
+	Token     string
+	ExpiresAt time.Time
+}
+
+type ticket struct {
+	grant     StreamGrant
+	expiresAt time.Time
+}
+
+// TicketStore hands out single-use, short-lived tickets so that EventSource
+// clients, which cannot send an Authorization header, can open the stream
+type TicketStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]ticket
+}
+
+func NewTicketStore(ttl time.Duration) *TicketStore {
+	return &TicketStore{ttl: ttl, tickets: make(map[string]ticket)}
+}
+
+// TTL is how long an unused ticket stays valid
+func (s *TicketStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue returns a ticket for the grant; it never outlives the access token
+func (s *TicketStore) Issue(grant StreamGrant) (string, error) {
+	value, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	if grant.ExpiresAt.Before(expiresAt) {
+		expiresAt = grant.ExpiresAt
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, t := range s.tickets {
+		if !now.Before(t.expiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[utils.HashToken(value)] = ticket{grant: grant, expiresAt: expiresAt}
+	return value, nil
+}
+
+// Redeem consumes a ticket, returning its grant while it is still valid
+func (s *TicketStore) Redeem(value string) (StreamGrant, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := utils.HashToken(value)
+	t, ok := s.tickets[key]
+	if !ok {
+		return StreamGrant{}, false
+	}
+	delete(s.tickets, key)
+	if !time.Now().Before(t.expiresAt) {
+		return StreamGrant{}, false
+	}
+	return t.grant, true
+}
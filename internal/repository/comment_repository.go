@@ -2,6 +2,7 @@ package repository
 
 import (
 	"GoVersi/internal/models"
+	"GoVersi/internal/utils"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -27,6 +28,13 @@ func (r *CommentRepository) FindByID(id uuid.UUID) (*models.Comment, error) {
 	return &comment, nil
 }
 
+// get the comments with the given ids, in no particular order
+func (r *CommentRepository) FindByIDs(ids []uuid.UUID) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.Where("id IN ?", ids).Find(&comments).Error
+	return comments, err
+}
+
 func (r *CommentRepository) FindByPostID(postID uuid.UUID) ([]*models.Comment, error) {
 	var comments []*models.Comment
 	if err := r.db.Where("post_id = ?", postID.String()).Find(&comments).Error; err != nil {
@@ -39,6 +47,97 @@ func (r *CommentRepository) Update(comment *models.Comment) error {
 	return r.db.Save(comment).Error
 }
 
-func (r *CommentRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Post{}, "id = ?", id).Error
+// delete a comment together with all of its replies; returns the deleted rows
+func (r *CommentRepository) Delete(id uuid.UUID) ([]models.Comment, error) {
+	var deleted []models.Comment
+	err := r.db.Raw(`
+		WITH RECURSIVE thread AS (
+			SELECT id FROM comments WHERE id = ?
+			UNION ALL
+			SELECT c.id FROM comments c JOIN thread t ON c.parent_id = t.id
+		)
+		DELETE FROM comments WHERE id IN (SELECT id FROM thread)
+		RETURNING *`, id).Scan(&deleted).Error
+	return deleted, err
+}
+
+// get top-level comments of a post, oldest first, starting after the cursor
+func (r *CommentRepository) FindTopLevelByPostID(postID uuid.UUID, cursor *utils.Cursor, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	query := r.db.Where("post_id = ? AND parent_id IS NULL", postID.String())
+	if cursor != nil {
+		query = query.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at ASC, id ASC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
+
+// get direct replies of a comment, oldest first, starting after the cursor
+func (r *CommentRepository) FindReplies(parentID uuid.UUID, cursor *utils.Cursor, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	query := r.db.Where("parent_id = ?", parentID)
+	if cursor != nil {
+		query = query.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at ASC, id ASC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
+
+// get the first replies of each of the given comments in a single query
+func (r *CommentRepository) FindFirstRepliesForParents(parentIDs []uuid.UUID, perParent int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.Raw(`
+		SELECT * FROM (
+			SELECT comments.*, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at ASC, id ASC) AS position
+			FROM comments
+			WHERE parent_id IN ?
+		) ranked
+		WHERE position <= ?
+		ORDER BY created_at ASC, id ASC`, parentIDs, perParent).
+		Scan(&comments).Error
+	return comments, err
+}
+
+// count direct replies for each of the given comments in a single query
+func (r *CommentRepository) CountReplies(parentIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		ParentID uuid.UUID
+		Count    int64
+	}
+	err := r.db.Model(&models.Comment{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", parentIDs).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ParentID] = row.Count
+	}
+	return counts, nil
+}
+
+// count comments for each of the given posts in a single query
+func (r *CommentRepository) CountByPostIDs(postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		PostID uuid.UUID
+		Count  int64
+	}
+	err := r.db.Model(&models.Comment{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	return counts, nil
 }
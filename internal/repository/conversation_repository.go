@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/utils"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// get the conversation between two users, creating it (with both members) when missing
+func (r *ConversationRepository) FindOrCreate(userID, otherID uuid.UUID) (*models.Conversation, error) {
+	a, b := models.OrderedPair(userID, otherID)
+
+	conversation, err := r.findByPair(a, b)
+	if err == nil {
+		return conversation, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	conversation = &models.Conversation{ID: uuid.New(), UserAID: a, UserBID: b, LastActivityAt: now}
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(conversation).Error; err != nil {
+			return err
+		}
+		members := []models.ConversationMember{
+			{ConversationID: conversation.ID, UserID: a},
+			{ConversationID: conversation.ID, UserID: b},
+		}
+		return tx.Create(&members).Error
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			// created concurrently by the other participant
+			return r.findByPair(a, b)
+		}
+		return nil, err
+	}
+	return conversation, nil
+}
+
+func (r *ConversationRepository) findByPair(a, b uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	if err := r.db.Where("user_a_id = ? AND user_b_id = ?", a, b).First(&conversation).Error; err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (r *ConversationRepository) FindByID(id uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	if err := r.db.First(&conversation, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// get conversations of a user, most recently active first, starting after the cursor
+func (r *ConversationRepository) FindByUser(userID uuid.UUID, cursor *utils.Cursor, limit int) ([]models.Conversation, error) {
+	var conversations []models.Conversation
+	query := r.db.Where("user_a_id = ? OR user_b_id = ?", userID, userID)
+	if cursor != nil {
+		query = query.Where("(last_activity_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("last_activity_at DESC, id DESC").Limit(limit).Find(&conversations).Error
+	return conversations, err
+}
+
+// get the read state of a user for each of the given conversations
+func (r *ConversationRepository) FindMemberships(userID uuid.UUID, conversationIDs []uuid.UUID) (map[uuid.UUID]models.ConversationMember, error) {
+	var members []models.ConversationMember
+	err := r.db.Where("user_id = ? AND conversation_id IN ?", userID, conversationIDs).Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byConversation := make(map[uuid.UUID]models.ConversationMember, len(members))
+	for _, m := range members {
+		byConversation[m.ConversationID] = m
+	}
+	return byConversation, nil
+}
+
+// get the latest message of each of the given conversations in a single query
+func (r *ConversationRepository) FindLastMessages(conversationIDs []uuid.UUID) (map[uuid.UUID]models.Message, error) {
+	var messages []models.Message
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (conversation_id) *
+		FROM messages
+		WHERE conversation_id IN ?
+		ORDER BY conversation_id, created_at DESC, id DESC`, conversationIDs).
+		Scan(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byConversation := make(map[uuid.UUID]models.Message, len(messages))
+	for _, m := range messages {
+		byConversation[m.ConversationID] = m
+	}
+	return byConversation, nil
+}
+
+// store a message, bump the conversation and the unread counter of the recipient
+func (r *ConversationRepository) CreateMessage(message *models.Message, recipientID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Conversation{}).
+			Where("id = ?", message.ConversationID).
+			Update("last_activity_at", message.CreatedAt).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", message.ConversationID, recipientID).
+			Update("unread_count", gorm.Expr("unread_count + 1")).Error
+	})
+}
+
+// get messages of a conversation, newest first, starting after the cursor
+func (r *ConversationRepository) FindMessages(conversationID uuid.UUID, cursor *utils.Cursor, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	query := r.db.Where("conversation_id = ?", conversationID)
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// mark every message received by the user in the conversation as read
+func (r *ConversationRepository) MarkRead(conversationID, userID uuid.UUID) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Message{}).
+			Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversationID, userID).
+			Update("read_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ConversationMember{}).
+			Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+			Updates(map[string]interface{}{"unread_count": 0, "last_read_at": now}).Error
+	})
+}
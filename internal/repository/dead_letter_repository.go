@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/utils"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DeadLetterRepository struct {
+	db *gorm.DB
+}
+
+func NewDeadLetterRepository(db *gorm.DB) *DeadLetterRepository {
+	return &DeadLetterRepository{db: db}
+}
+
+func (r *DeadLetterRepository) Create(letter *models.DeadLetter) error {
+	return r.db.Create(letter).Error
+}
+
+func (r *DeadLetterRepository) FindByID(id uuid.UUID) (*models.DeadLetter, error) {
+	var letter models.DeadLetter
+	if err := r.db.First(&letter, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+// newest first; an empty queue name lists every queue
+func (r *DeadLetterRepository) FindPage(queueName string, cursor *utils.Cursor, limit int) ([]models.DeadLetter, error) {
+	var letters []models.DeadLetter
+	query := r.db.Model(&models.DeadLetter{})
+	if queueName != "" {
+		query = query.Where("queue = ?", queueName)
+	}
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&letters).Error
+	return letters, err
+}
+
+// mark the letter replayed unless it already was; reports whether it was claimed
+func (r *DeadLetterRepository) MarkReplayed(id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.Model(&models.DeadLetter{}).
+		Where("id = ? AND replayed_at IS NULL", id).
+		Update("replayed_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+// RedactArchivedLinks blanks the tokens of one-time links out of the letters of queueName
+// archived before the archive redacted them, and flags those letters redacted
+func RedactArchivedLinks(db *gorm.DB, queueName, placeholder string) error {
+	result := db.Exec(`
+		UPDATE dead_letters SET
+			payload = convert_to(regexp_replace(convert_from(payload, 'UTF8'), '(token=)[A-Za-z0-9_.~%-]+', '\1' || ?, 'g'), 'UTF8'),
+			redacted = true
+		WHERE queue = ? AND NOT redacted AND position('token=' in convert_from(payload, 'UTF8')) > 0`, placeholder, queueName)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Redacted the one-time links of %d archived dead letters", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db: db}
+}
+
+func (r *EmailVerificationRepository) Create(token *models.EmailVerificationToken) error {
+	return r.db.Create(token).Error
+}
+
+// get verification token by the hash of its value
+func (r *EmailVerificationRepository) FindByHash(hash string) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// number of tokens issued to the user since the given time
+func (r *EmailVerificationRepository) CountIssuedSince(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// mark the token used if it still is valid; reports whether it was claimed
+func (r *EmailVerificationRepository) Consume(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// invalidate every unused token of the user
+func (r *EmailVerificationRepository) InvalidateForUser(userID uuid.UUID, now time.Time) error {
+	return r.db.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", now).Error
+}
+
+func (r *EmailVerificationRepository) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.EmailVerificationToken{}).Error
+}
+
+// MigrateLegacyEmailConfirmTokens moves the pending confirmation tokens kept on users
+// into email_verification_tokens, hashed and expiring after ttl, then drops the old column
+func MigrateLegacyEmailConfirmTokens(db *gorm.DB, ttl time.Duration) error {
+	if !db.Migrator().HasColumn(&models.User{}, "email_confirm_token") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at, created_at)
+			SELECT id, email, encode(sha256(convert_to(email_confirm_token, 'UTF8')), 'hex'), ?, now()
+			FROM users
+			WHERE NOT is_email_verified AND email_confirm_token <> ''
+			ON CONFLICT (token_hash) DO NOTHING`, time.Now().Add(ttl)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&models.User{}, "email_confirm_token")
+	})
+}
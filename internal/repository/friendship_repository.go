@@ -27,7 +27,7 @@ func NewFriendshipRepository(db *gorm.DB) *FriendshipRepository {
 	return &FriendshipRepository{db: db}
 }
 
-func (r *FriendshipRepository) SendFriendRequest(requesterID, addresseeID uuid.UUID) error {
+func (r *FriendshipRepository) SendFriendRequest(requesterID, addresseeID uuid.UUID) (*models.Friendship, error) {
 	friendship := &models.Friendship{
 		ID:          uuid.New(),
 		RequesterID: requesterID,
@@ -39,10 +39,13 @@ func (r *FriendshipRepository) SendFriendRequest(requesterID, addresseeID uuid.U
 
 	// Validação antes de criar
 	if err := friendship.Validate(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return r.db.Create(friendship).Error
+	if err := r.db.Create(friendship).Error; err != nil {
+		return nil, err
+	}
+	return friendship, nil
 }
 
 // get friendship between users
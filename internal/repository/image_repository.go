@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// records that own an image, the column holding its key and the one holding its renditions;
+// post attachments also track their processing status and dimensions
+var imageOwners = map[string]struct {
+	model      interface{}
+	column     string
+	renditions string
+	attachment bool
+}{
+	"post_media": {model: &models.PostMedia{}, column: "url", renditions: "renditions", attachment: true},
+	"comment":    {model: &models.Comment{}, column: "image_url", renditions: "image_renditions"},
+	"user":       {model: &models.User{}, column: "image_profile", renditions: "image_renditions"},
+}
+
+// ImageRepository stores the output of the image pipeline on the owning record
+type ImageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) *ImageRepository {
+	return &ImageRepository{db: db}
+}
+
+// replace the image key of a record and set its renditions, only if the record still
+// points at oldKey; reports whether a row was updated
+func (r *ImageRepository) SetProcessedImage(target string, id uuid.UUID, oldKey, newKey models.MediaKey, renditions models.Renditions, width, height int) (bool, error) {
+	owner, ok := imageOwners[target]
+	if !ok {
+		return false, fmt.Errorf("unknown image target %q", target)
+	}
+
+	columns := map[string]interface{}{
+		owner.column:     newKey,
+		owner.renditions: renditions,
+	}
+	if owner.attachment {
+		columns["status"] = models.MediaReady
+		columns["width"] = width
+		columns["height"] = height
+	}
+
+	result := r.db.Model(owner.model).
+		Where("id = ? AND "+owner.column+" = ?", id, oldKey).
+		UpdateColumns(columns)
+	return result.RowsAffected > 0, result.Error
+}
+
+// record that the image of a record could not be processed; only attachments keep a status
+func (r *ImageRepository) SetImageFailed(target string, id uuid.UUID, key models.MediaKey) error {
+	owner, ok := imageOwners[target]
+	if !ok || !owner.attachment {
+		return nil
+	}
+	return r.db.Model(owner.model).
+		Where("id = ? AND "+owner.column+" = ?", id, key).
+		UpdateColumn("status", models.MediaFailed).Error
+}
+
+// RepairRenditionKeys fixes renditions stored as signed URLs instead of keys; keys never
+// carry a query string. Rendition
+// keys derive from the image key (images/x_clean.jpg -> images/x_thumb.jpg), so they are
+// rebuilt from it. The references released under those URLs are moved to the real files.
+func RepairRenditionKeys(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var repaired int64
+		for _, owner := range imageOwners {
+			base := fmt.Sprintf(`regexp_replace(regexp_replace(regexp_replace(%s, '^/?(uploads/)?', ''), '\.[^./]*$', ''), '_clean$', '')`, owner.column)
+			result := tx.Model(owner.model).
+				Where("strpos("+owner.renditions+"::text, ?) > 0", "?").
+				UpdateColumn(owner.renditions, gorm.Expr(fmt.Sprintf(
+					`(SELECT jsonb_object_agg(name, %s || '_' || name || '.jpg') FROM jsonb_object_keys(%s) AS name)`,
+					base, owner.renditions)))
+			if result.Error != nil {
+				return result.Error
+			}
+			repaired += result.RowsAffected
+		}
+
+		// a released URL stands for the file whose key ends its path
+		err := tx.Exec(`
+			UPDATE media_objects AS file SET
+				ref_count = GREATEST(file.ref_count - 1, 0),
+				unreferenced_at = CASE WHEN file.ref_count <= 1
+					THEN COALESCE(file.unreferenced_at, NOW()) END,
+				updated_at = NOW()
+			FROM media_objects AS released
+			WHERE strpos(released.key, ?) > 0
+				AND strpos(file.key, ?) = 0
+				AND split_part(released.key, ?, 1) LIKE '%/' || file.key`, "?", "?", "?").Error
+		if err != nil {
+			return err
+		}
+		junk := tx.Where("strpos(key, ?) > 0", "?").Delete(&models.MediaObject{})
+		if junk.Error != nil {
+			return junk.Error
+		}
+
+		if repaired > 0 || junk.RowsAffected > 0 {
+			log.Printf("Repaired the renditions of %d images and %d released rendition references", repaired, junk.RowsAffected)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MediaObjectRepository counts the references to stored files
+type MediaObjectRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaObjectRepository(db *gorm.DB) *MediaObjectRepository {
+	return &MediaObjectRepository{db: db}
+}
+
+// record a newly stored file; it starts unreferenced until a record retains it
+func (r *MediaObjectRepository) Register(key string, size int64, contentType string) error {
+	now := time.Now()
+	object := models.MediaObject{Key: key, Size: size, ContentType: contentType, UnreferencedAt: &now}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "content_type", "updated_at"}),
+	}).Create(&object).Error
+}
+
+// drop the record of a file deleted from the store
+func (r *MediaObjectRepository) Forget(key string) error {
+	return r.db.Delete(&models.MediaObject{}, "key = ?", key).Error
+}
+
+// add a reference to each file; files stored before tracking existed get a record
+func (r *MediaObjectRepository) Retain(keys []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			err := tx.Exec(`
+				INSERT INTO media_objects (key, ref_count, created_at, updated_at)
+				VALUES (?, 1, NOW(), NOW())
+				ON CONFLICT (key) DO UPDATE SET
+					ref_count = media_objects.ref_count + 1,
+					unreferenced_at = NULL,
+					updated_at = NOW()`, key).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// remove a reference from each file; a file left without references starts its grace period
+func (r *MediaObjectRepository) Release(keys []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			err := tx.Exec(`
+				INSERT INTO media_objects (key, ref_count, unreferenced_at, created_at, updated_at)
+				VALUES (?, 0, NOW(), NOW(), NOW())
+				ON CONFLICT (key) DO UPDATE SET
+					ref_count = GREATEST(media_objects.ref_count - 1, 0),
+					unreferenced_at = CASE WHEN media_objects.ref_count <= 1
+						THEN COALESCE(media_objects.unreferenced_at, NOW()) END,
+					updated_at = NOW()`, key).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// get files unreferenced since before the given time, oldest first
+func (r *MediaObjectRepository) FindUnreferenced(before time.Time, limit int) ([]models.MediaObject, error) {
+	var objects []models.MediaObject
+	err := r.db.Where("ref_count = 0 AND unreferenced_at < ?", before).
+		Order("unreferenced_at").
+		Limit(limit).
+		Find(&objects).Error
+	return objects, err
+}
+
+// delete the record of a file only if it is still unreferenced; reports whether
+// the caller now owns the deletion of the file
+func (r *MediaObjectRepository) ClaimUnreferenced(key string, before time.Time) (bool, error) {
+	result := r.db.Where("key = ? AND ref_count = 0 AND unreferenced_at < ?", key, before).
+		Delete(&models.MediaObject{})
+	return result.RowsAffected > 0, result.Error
+}
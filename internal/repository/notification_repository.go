@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/utils"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnreadActivity sums the actors of the unread notifications of one type for a recipient
+type UnreadActivity struct {
+	RecipientID uuid.UUID
+	Type        models.NotificationType
+	Count       int
+}
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// RecordGrouped adds the actor to the unread notification of the same group,
+// creating it when there is none. It returns the resulting notification.
+func (r *NotificationRepository) RecordGrouped(event *models.Notification) (*models.Notification, error) {
+	result, err := r.recordGrouped(event)
+	if err != nil && isUniqueViolation(err) {
+		// a concurrent event created the group first; join it instead
+		result, err = r.recordGrouped(event)
+	}
+	return result, err
+}
+
+func (r *NotificationRepository) recordGrouped(event *models.Notification) (*models.Notification, error) {
+	var result models.Notification
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("recipient_id = ? AND type = ? AND target_type = ? AND target_id = ? AND read_at IS NULL",
+				event.RecipientID, event.Type, event.TargetType, event.TargetID).
+			First(&result).Error
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result = *event
+			result.ID = uuid.New()
+			result.ActorCount = 1
+			if err := tx.Create(&result).Error; err != nil {
+				return err
+			}
+			return tx.Create(&models.NotificationActor{NotificationID: result.ID, ActorID: event.LastActorID}).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		added := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.NotificationActor{NotificationID: result.ID, ActorID: event.LastActorID})
+		if added.Error != nil {
+			return added.Error
+		}
+
+		updates := map[string]interface{}{"last_actor_id": event.LastActorID, "updated_at": time.Now()}
+		if added.RowsAffected > 0 {
+			updates["actor_count"] = gorm.Expr("actor_count + 1")
+		}
+		if err := tx.Model(&result).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(&result, "id = ?", result.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// get notifications of a user, most recently updated first, starting after the cursor
+func (r *NotificationRepository) FindByRecipient(recipientID uuid.UUID, cursor *utils.Cursor, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db.Where("recipient_id = ?", recipientID)
+	if cursor != nil {
+		query = query.Where("(updated_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("updated_at DESC, id DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *NotificationRepository) CountUnread(recipientID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("recipient_id = ? AND read_at IS NULL", recipientID).
+		Count(&count).Error
+	return count, err
+}
+
+// mark one notification as read, reporting whether it belongs to the recipient
+func (r *NotificationRepository) MarkRead(recipientID, id uuid.UUID) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Notification{}).
+		Where("id = ? AND recipient_id = ?", id, recipientID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, nil
+	}
+
+	err := r.db.Model(&models.Notification{}).
+		Where("id = ? AND recipient_id = ? AND read_at IS NULL", id, recipientID).
+		Update("read_at", time.Now()).Error
+	return true, err
+}
+
+// mark the given notifications as read, or all of them when ids is empty
+func (r *NotificationRepository) MarkManyRead(recipientID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("recipient_id = ? AND read_at IS NULL", recipientID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// sum the unread activity updated since the given time, per recipient and type
+func (r *NotificationRepository) SumUnreadSince(since time.Time) ([]UnreadActivity, error) {
+	var activity []UnreadActivity
+	err := r.db.Model(&models.Notification{}).
+		Select("recipient_id, type, SUM(actor_count) AS count").
+		Where("read_at IS NULL AND updated_at >= ?", since).
+		Group("recipient_id, type").
+		Scan(&activity).Error
+	return activity, err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository stores messages until the relay publishes them. It implements
+// queue.Publisher, so anything that publishes can write to the outbox instead.
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// queue a message; within a transaction it is only relayed if the transaction commits
+func (r *OutboxRepository) Publish(queueName string, body []byte) error {
+	return r.db.Create(&models.OutboxEvent{
+		Queue:         queueName,
+		Payload:       body,
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// lock up to limit events due for publishing while fn runs, so concurrent relays skip them;
+// fn records the outcome through the repository it receives
+func (r *OutboxRepository) WithDue(now time.Time, limit int, fn func(events []models.OutboxEvent, tx *OutboxRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var events []models.OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+			Order("created_at").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		return fn(events, NewOutboxRepository(tx))
+	})
+}
+
+// the payload is cleared once the event is settled: emails carry one-time links that
+// must not outlive their delivery in the database
+func (r *OutboxRepository) MarkSent(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": models.OutboxSent, "sent_at": at, "last_error": "", "payload": []byte{}}).Error
+}
+
+// record a failed attempt and when to try again
+func (r *OutboxRepository) MarkRetry(id uuid.UUID, attempts int, next time.Time, lastError string) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": next, "last_error": lastError}).Error
+}
+
+// give up on an event; the payload is kept so that it can be replayed, the caller
+// passes it with the one-time links of emails redacted
+func (r *OutboxRepository) MarkFailed(id uuid.UUID, attempts int, lastError string, payload []byte, redacted bool) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": models.OutboxFailed, "attempts": attempts, "last_error": lastError, "payload": payload, "redacted": redacted}).Error
+}
+
+// ReplayFailed puts the failed events of a queue (all queues when empty) back in line
+// with a fresh attempt budget; redacted events are left out, their links are unusable
+func (r *OutboxRepository) ReplayFailed(queueName string, now time.Time) (int64, error) {
+	query := r.db.Model(&models.OutboxEvent{}).Where("status = ? AND NOT redacted", models.OutboxFailed)
+	if queueName != "" {
+		query = query.Where("queue = ?", queueName)
+	}
+	result := query.Updates(map[string]interface{}{"status": models.OutboxPending, "attempts": 0, "next_attempt_at": now, "last_error": ""})
+	return result.RowsAffected, result.Error
+}
+
+// ScrubSettledPayloads removes the one-time links that emails settled before MarkSent
+// and MarkFailed took care of them still hold: sent emails lose their payload, failed
+// ones have their links redacted. Events of other queues are left alone.
+func ScrubSettledPayloads(db *gorm.DB, queueName, placeholder string) error {
+	err := db.Model(&models.OutboxEvent{}).
+		Where("queue = ? AND status = ? AND length(payload) > 0", queueName, models.OutboxSent).
+		Update("payload", []byte{}).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec(`
+		UPDATE outbox_events SET
+			payload = convert_to(regexp_replace(convert_from(payload, 'UTF8'), '(token=)[A-Za-z0-9_.~%-]+', '\1' || ?, 'g'), 'UTF8'),
+			redacted = true
+		WHERE queue = ? AND status = ? AND NOT redacted AND position('token=' in convert_from(payload, 'UTF8')) > 0`,
+		placeholder, queueName, models.OutboxFailed).Error
+}
+
+// delete events published before the given time
+func (r *OutboxRepository) DeleteSentBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND sent_at < ?", models.OutboxSent, before).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
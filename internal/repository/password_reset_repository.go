@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r *PasswordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// get reset token by the hash of its value
+func (r *PasswordResetRepository) FindByHash(hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// whether a token was issued to the user since the given time
+func (r *PasswordResetRepository) IssuedSince(userID uuid.UUID, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// mark the token used if it still is valid; reports whether it was claimed
+func (r *PasswordResetRepository) Consume(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// invalidate every unused token of the user
+func (r *PasswordResetRepository) InvalidateForUser(userID uuid.UUID, now time.Time) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", now).Error
+}
+
+func (r *PasswordResetRepository) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.PasswordResetToken{}).Error
+}
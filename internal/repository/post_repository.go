@@ -2,9 +2,12 @@ package repository
 
 import (
 	"GoVersi/internal/models"
+	"GoVersi/internal/utils"
+	"log"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository struct {
@@ -15,22 +18,255 @@ func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{db: db}
 }
 
+// withMedia loads the attachments of the queried posts in their order
+func (r *PostRepository) withMedia() *gorm.DB {
+	return r.db.Preload("Media", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("position")
+	})
+}
+
+// create the post together with its attachments
 func (r *PostRepository) Create(post *models.Post) error {
 	return r.db.Create(post).Error
 }
 
 func (r *PostRepository) FindByID(id uuid.UUID) (*models.Post, error) {
 	var post models.Post
-	if err := r.db.First(&post, "id = ?", id).Error; err != nil {
+	if err := r.withMedia().First(&post, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
+// get posts of a topic, newest first, starting after the cursor
+func (r *PostRepository) FindByTopic(slug string, cursor *utils.Cursor, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	query := r.withMedia().Where("topic = ?", slug)
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// get the posts with the given ids, in no particular order
+func (r *PostRepository) FindByIDs(ids []uuid.UUID) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.withMedia().Where("id IN ?", ids).Find(&posts).Error
+	return posts, err
+}
+
+// save the post columns; attachments are changed through AddMedia and ReplaceMedia
 func (r *PostRepository) Update(post *models.Post) error {
-	return r.db.Save(post).Error
+	return r.db.Omit(clause.Associations).Save(post).Error
+}
+
+// append an attachment after the existing ones, unless the post already has limit of them;
+// the post row is locked so that concurrent uploads to a post are counted one at a time
+func (r *PostRepository) AddMedia(attachment *models.PostMedia, limit int) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&post, "id = ?", attachment.PostID).Error
+		if err != nil {
+			return err
+		}
+
+		var stats struct {
+			Count int
+			Next  int
+		}
+		err = tx.Model(&models.PostMedia{}).
+			Select("count(*) AS count, COALESCE(MAX(position) + 1, 0) AS next").
+			Where("post_id = ?", attachment.PostID).
+			Scan(&stats).Error
+		if err != nil || stats.Count >= limit {
+			return err
+		}
+
+		attachment.Position = stats.Next
+		if err := tx.Create(attachment).Error; err != nil {
+			return err
+		}
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+// save the post columns and keep only the given attachments, in the given order, with
+// their alt text. The post row is locked as in AddMedia; when its attachments are no
+// longer those the caller read, e.g. one was added meanwhile, nothing is changed and
+// false is returned. It returns the attachments it deleted.
+func (r *PostRepository) ReplaceMedia(post *models.Post, read []uuid.UUID, attachments []models.PostMedia) ([]models.PostMedia, bool, error) {
+	var removed []models.PostMedia
+	replaced := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var locked models.Post
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, "id = ?", post.ID).Error
+		if err != nil {
+			return err
+		}
+
+		var current []models.PostMedia
+		if err := tx.Where("post_id = ?", post.ID).Find(&current).Error; err != nil {
+			return err
+		}
+		if !sameMedia(current, read) {
+			return nil
+		}
+
+		keep := make(map[uuid.UUID]bool, len(attachments))
+		for _, m := range attachments {
+			keep[m.ID] = true
+		}
+		for _, m := range current {
+			if !keep[m.ID] {
+				removed = append(removed, m)
+			}
+		}
+
+		if err := tx.Omit(clause.Associations).Save(post).Error; err != nil {
+			return err
+		}
+		for _, m := range removed {
+			if err := tx.Delete(&models.PostMedia{}, "id = ?", m.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		// positions are unique per post: move the kept ones out of the way before renumbering
+		err = tx.Model(&models.PostMedia{}).Where("post_id = ?", post.ID).
+			UpdateColumn("position", gorm.Expr("-1 - position")).Error
+		if err != nil {
+			return err
+		}
+
+		for i, m := range attachments {
+			err := tx.Model(&models.PostMedia{}).
+				Where("id = ? AND post_id = ?", m.ID, post.ID).
+				UpdateColumns(map[string]interface{}{"position": i, "alt_text": m.AltText}).Error
+			if err != nil {
+				return err
+			}
+		}
+		replaced = true
+		return nil
+	})
+	if err != nil || !replaced {
+		return nil, false, err
+	}
+	return removed, true, nil
 }
 
+// sameMedia tells whether the attachments are exactly those with the given ids
+func sameMedia(attachments []models.PostMedia, ids []uuid.UUID) bool {
+	if len(attachments) != len(ids) {
+		return false
+	}
+	want := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	for _, m := range attachments {
+		if !want[m.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// delete the post and its attachments
 func (r *PostRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Post{}, "id = ?", id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.PostMedia{}, "post_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Post{}, "id = ?", id).Error
+	})
+}
+
+// get posts written by any of the given authors, newest first, starting after the cursor
+func (r *PostRepository) FindByAuthors(authorIDs []uuid.UUID, cursor *utils.Cursor, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	query := r.withMedia().Where("author_id IN ?", authorIDs)
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// UniquePostMediaPositions prepares the (post_id, position) index of post_media to become
+// unique: concurrent uploads could give two attachments of a post the same position, so
+// the attachments of every post are renumbered and the former plain index is dropped for
+// AutoMigrate to recreate
+func UniquePostMediaPositions(db *gorm.DB) error {
+	var plain int64
+	err := db.Raw(`
+		SELECT count(*) FROM pg_indexes
+		WHERE schemaname = current_schema() AND indexname = 'idx_post_media_post_position'
+			AND indexdef NOT LIKE 'CREATE UNIQUE%'`).Scan(&plain).Error
+	if err != nil || plain == 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		renumbered := tx.Exec(`
+			UPDATE post_media SET position = ranked.position
+			FROM (
+				SELECT id, row_number() OVER (PARTITION BY post_id ORDER BY position, created_at, id) - 1 AS position
+				FROM post_media
+			) AS ranked
+			WHERE post_media.id = ranked.id AND post_media.position <> ranked.position`)
+		if renumbered.Error != nil {
+			return renumbered.Error
+		}
+		if renumbered.RowsAffected > 0 {
+			log.Printf("Renumbered %d post attachments sharing a position", renumbered.RowsAffected)
+		}
+		return tx.Migrator().DropIndex(&models.PostMedia{}, "idx_post_media_post_position")
+	})
+}
+
+// MigrateLegacyPostMedia moves the single image and video of posts created before
+// attachments existed into post_media, then drops the old columns
+func MigrateLegacyPostMedia(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Post{}, "image_url") {
+		return nil
+	}
+
+	renditions := "NULL::jsonb"
+	if migrator.HasColumn(&models.Post{}, "image_renditions") {
+		renditions = "image_renditions"
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		images := tx.Exec(`
+			INSERT INTO post_media (post_id, position, type, url, status, renditions, created_at)
+			SELECT id, 0, 'image', image_url, 'ready', ` + renditions + `, created_at
+			FROM posts WHERE image_url <> ''`)
+		if images.Error != nil {
+			return images.Error
+		}
+
+		videos := tx.Exec(`
+			INSERT INTO post_media (post_id, position, type, url, status, created_at)
+			SELECT id, CASE WHEN image_url <> '' THEN 1 ELSE 0 END, 'video', video_url, 'ready', created_at
+			FROM posts WHERE video_url <> ''`)
+		if videos.Error != nil {
+			return videos.Error
+		}
+
+		log.Printf("Moved %d images and %d videos of legacy posts to attachments", images.RowsAffected, videos.RowsAffected)
+		for _, column := range []string{"image_url", "video_url", "image_renditions"} {
+			if tx.Migrator().HasColumn(&models.Post{}, column) {
+				if err := tx.Migrator().DropColumn(&models.Post{}, column); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// upsert stores the reaction, replacing the type when the user already reacted to the target
+func (r *ReactionRepository) Upsert(reaction *models.Reaction) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "target_type"}, {Name: "target_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"type": reaction.Type, "updated_at": time.Now()}),
+	}).Create(reaction).Error
+}
+
+func (r *ReactionRepository) Delete(userID uuid.UUID, targetType models.ReactionTarget, targetID uuid.UUID) error {
+	return r.db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Delete(&models.Reaction{}).Error
+}
+
+// get the reaction of a user to a target, nil when there is none
+func (r *ReactionRepository) FindUserReaction(userID uuid.UUID, targetType models.ReactionTarget, targetID uuid.UUID) (*models.Reaction, error) {
+	var reaction models.Reaction
+	err := r.db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		First(&reaction).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reaction, nil
+}
+
+// count reactions of a target grouped by reaction type
+func (r *ReactionRepository) CountByType(targetType models.ReactionTarget, targetID uuid.UUID) (map[models.ReactionType]int64, error) {
+	var rows []struct {
+		Type  models.ReactionType
+		Count int64
+	}
+	err := r.db.Model(&models.Reaction{}).
+		Select("type, COUNT(*) AS count").
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.ReactionType]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// count reactions for each of the given targets in a single query
+func (r *ReactionRepository) CountByTargets(targetType models.ReactionTarget, targetIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		TargetID uuid.UUID
+		Count    int64
+	}
+	err := r.db.Model(&models.Reaction{}).
+		Select("target_id, COUNT(*) AS count").
+		Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Group("target_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TargetID] = row.Count
+	}
+	return counts, nil
+}
+
+// get the reactions of a user to each of the given targets in a single query
+func (r *ReactionRepository) FindUserReactions(userID uuid.UUID, targetType models.ReactionTarget, targetIDs []uuid.UUID) (map[uuid.UUID]models.ReactionType, error) {
+	var reactions []models.Reaction
+	err := r.db.Where("user_id = ? AND target_type = ? AND target_id IN ?", userID, targetType, targetIDs).
+		Find(&reactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byTarget := make(map[uuid.UUID]models.ReactionType, len(reactions))
+	for _, reaction := range reactions {
+		byTarget[reaction.TargetID] = reaction.Type
+	}
+	return byTarget, nil
+}
+
+// MigrateLegacyLikes copies rows of the old likes table into reactions and drops it
+func MigrateLegacyLikes(db *gorm.DB) error {
+	if !db.Migrator().HasTable("likes") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO reactions (id, user_id, target_type, target_id, type, created_at, updated_at)
+			SELECT id::uuid, user_id::uuid,
+				CASE WHEN post_id::uuid <> '00000000-0000-0000-0000-000000000000' THEN 'post' ELSE 'comment' END,
+				CASE WHEN post_id::uuid <> '00000000-0000-0000-0000-000000000000' THEN post_id::uuid ELSE comment_id::uuid END,
+				'like', created_at, created_at
+			FROM likes
+			ON CONFLICT DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+
+		log.Println("Legacy likes migrated to reactions")
+		return tx.Migrator().DropTable("likes")
+	})
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/utils"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTopicExists is returned when a topic with the same slug is already in the catalogue
+var ErrTopicExists = errors.New("topic already exists")
+
+// TrendingTopic is a topic with the number of posts it received in the window
+type TrendingTopic struct {
+	models.Topic
+	PostCount int64 `json:"post_count"`
+}
+
+type TopicRepository struct {
+	db *gorm.DB
+}
+
+func NewTopicRepository(db *gorm.DB) *TopicRepository {
+	return &TopicRepository{db: db}
+}
+
+func (r *TopicRepository) Create(topic *models.Topic) error {
+	if err := r.db.Create(topic).Error; err != nil {
+		if isUniqueViolation(err) {
+			return ErrTopicExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *TopicRepository) FindBySlug(slug string) (*models.Topic, error) {
+	var topic models.Topic
+	if err := r.db.Where("slug = ?", slug).First(&topic).Error; err != nil {
+		return nil, err
+	}
+	return &topic, nil
+}
+
+// get the whole catalogue, most followed first
+func (r *TopicRepository) FindAll() ([]models.Topic, error) {
+	var topics []models.Topic
+	err := r.db.Order("follower_count DESC, slug").Find(&topics).Error
+	return topics, err
+}
+
+// get the topics a user follows
+func (r *TopicRepository) FindFollowedBy(userID uuid.UUID) ([]models.Topic, error) {
+	var topics []models.Topic
+	err := r.db.Joins("JOIN topic_follows ON topic_follows.topic_id = topics.id").
+		Where("topic_follows.user_id = ?", userID).
+		Order("topics.slug").
+		Find(&topics).Error
+	return topics, err
+}
+
+func (r *TopicRepository) IsFollowing(topicID, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.TopicFollow{}).
+		Where("topic_id = ? AND user_id = ?", topicID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// follow is idempotent; the follower counter only moves when a row is actually inserted
+func (r *TopicRepository) Follow(topicID, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.TopicFollow{TopicID: topicID, UserID: userID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Topic{}).Where("id = ?", topicID).
+			Update("follower_count", gorm.Expr("follower_count + 1")).Error
+	})
+}
+
+func (r *TopicRepository) Unfollow(topicID, userID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("topic_id = ? AND user_id = ?", topicID, userID).Delete(&models.TopicFollow{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Topic{}).Where("id = ?", topicID).
+			Update("follower_count", gorm.Expr("GREATEST(follower_count - 1, 0)")).Error
+	})
+}
+
+// get the topics with the most posts created since the given time
+func (r *TopicRepository) FindTrending(since time.Time, limit int) ([]TrendingTopic, error) {
+	var topics []TrendingTopic
+	err := r.db.Model(&models.Topic{}).
+		Select("topics.*, COUNT(posts.id) AS post_count").
+		Joins("JOIN posts ON posts.topic = topics.slug").
+		Where("posts.created_at >= ?", since).
+		Group("topics.id").
+		Order("post_count DESC, topics.follower_count DESC, topics.slug").
+		Limit(limit).
+		Scan(&topics).Error
+	return topics, err
+}
+
+// SeedTopics creates the named topics that are missing, so that a fresh install has
+// somewhere to post before an admin fills the catalogue
+func SeedTopics(db *gorm.DB, names []string) error {
+	created := int64(0)
+	for _, name := range names {
+		slug := utils.Slugify(name)
+		if slug == "" {
+			continue
+		}
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Topic{Slug: slug, Name: name})
+		if result.Error != nil {
+			return result.Error
+		}
+		created += result.RowsAffected
+	}
+
+	if created > 0 {
+		log.Printf("Created %d default topics", created)
+	}
+	return nil
+}
+
+// MigrateLegacyTopics turns the free-form topics of existing posts into catalogue entries
+// and rewrites the posts to reference them by slug. Running it again is a no-op.
+func MigrateLegacyTopics(db *gorm.DB) error {
+	var legacy []string
+	err := db.Model(&models.Post{}).
+		Where("topic <> ''").
+		Distinct().
+		Pluck("topic", &legacy).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		created := int64(0)
+		for _, name := range legacy {
+			slug := utils.Slugify(name)
+
+			if slug != "" {
+				result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Topic{Slug: slug, Name: name})
+				if result.Error != nil {
+					return result.Error
+				}
+				created += result.RowsAffected
+			}
+
+			if slug != name {
+				if err := tx.Model(&models.Post{}).Where("topic = ?", name).UpdateColumn("topic", slug).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if created > 0 {
+			log.Printf("Created %d topics from legacy post topics", created)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,52 @@
+package repository
+
+import "gorm.io/gorm"
+
+// TxManager runs work in a single database transaction
+type TxManager struct {
+	db *gorm.DB
+}
+
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// Do runs fn in a transaction that is committed when fn returns nil and rolled back otherwise
+func (m *TxManager) Do(fn func(tx *Tx) error) error {
+	return m.db.Transaction(func(db *gorm.DB) error {
+		return fn(&Tx{db: db})
+	})
+}
+
+// Tx hands out repositories that write through the same transaction
+type Tx struct {
+	db *gorm.DB
+}
+
+func (t *Tx) Users() UserRepository {
+	return NewUserRepository(t.db)
+}
+
+func (t *Tx) Outbox() *OutboxRepository {
+	return NewOutboxRepository(t.db)
+}
+
+func (t *Tx) DeadLetters() *DeadLetterRepository {
+	return NewDeadLetterRepository(t.db)
+}
+
+func (t *Tx) RefreshTokens() *RefreshTokenRepository {
+	return NewRefreshTokenRepository(t.db)
+}
+
+func (t *Tx) PasswordResets() *PasswordResetRepository {
+	return NewPasswordResetRepository(t.db)
+}
+
+func (t *Tx) EmailVerifications() *EmailVerificationRepository {
+	return NewEmailVerificationRepository(t.db)
+}
+
+func (t *Tx) Audit() *AuditRepository {
+	return NewAuditRepository(t.db)
+}
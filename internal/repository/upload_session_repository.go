@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"GoVersi/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UploadSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadSessionRepository(db *gorm.DB) *UploadSessionRepository {
+	return &UploadSessionRepository{db: db}
+}
+
+func (r *UploadSessionRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *UploadSessionRepository) FindByID(id uuid.UUID) (*models.UploadSession, error) {
+	var session models.UploadSession
+	if err := r.db.First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// move the offset forward and push back the expiry, only if nobody else moved it first
+func (r *UploadSessionRepository) AdvanceOffset(id uuid.UUID, from, to int64, expiresAt time.Time) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND \"offset\" = ? AND status = ?", id, from, models.UploadPending).
+		Updates(map[string]interface{}{"offset": to, "expires_at": expiresAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UploadSessionRepository) MarkCompleted(id uuid.UUID) error {
+	return r.db.Model(&models.UploadSession{}).Where("id = ?", id).
+		Update("status", models.UploadCompleted).Error
+}
+
+func (r *UploadSessionRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.UploadSession{}, "id = ?", id).Error
+}
+
+// get sessions that expired before the given time, finished or not
+func (r *UploadSessionRepository) FindExpired(before time.Time) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	err := r.db.Where("expires_at < ?", before).Find(&sessions).Error
+	return sessions, err
+}
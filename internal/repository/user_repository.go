@@ -26,19 +26,34 @@ func (r *UserRepositoryImpl) GetUserByID(userID uuid.UUID) (*models.User, error)
 	return &user, nil
 }
 
-// implementation of FindByEmailConfirmToken
-func (r *UserRepositoryImpl) FindByEmailConfirmToken(token string) (*models.User, error) {
-	var user models.User
-	if err := r.DB.Where("email_confirm_token = ?", token).First(&user).Error; err != nil {
-		return nil, err
-	}
-	return &user, nil
+// implementation of MarkEmailVerified
+func (r *UserRepositoryImpl) MarkEmailVerified(userID uuid.UUID) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("is_email_verified", true).Error
+}
+
+// implementation of UpdateEmail; the new address has been confirmed by its owner
+func (r *UserRepositoryImpl) UpdateEmail(userID uuid.UUID, address string) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]any{"email": address, "is_email_verified": true}).Error
+}
+
+// implementation of PromoteVerifiedToAdmin; emails are lowercase, unverified accounts are left alone
+func (r *UserRepositoryImpl) PromoteVerifiedToAdmin(emails []string) (int64, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("lower(email) IN ? AND is_email_verified AND role <> ?", emails, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	return result.RowsAffected, result.Error
 }
 
 func (r *UserRepositoryImpl) UpdateUser(user *models.User) error {
 	return r.DB.Save(user).Error
 }
 
+// implementation of UpdatePassword; hash is the bcrypt hash of the new password
+func (r *UserRepositoryImpl) UpdatePassword(userID uuid.UUID, hash string) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", userID).Update("password", hash).Error
+}
+
 func (r *UserRepositoryImpl) DeleteUser(userID uuid.UUID) error {
 	return r.DB.Delete(&models.User{}, userID).Error
 }
@@ -89,6 +104,15 @@ func (r *UserRepositoryImpl) FindByID(userID uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// implementation of FindByIDs
+func (r *UserRepositoryImpl) FindByIDs(userIDs []uuid.UUID) ([]models.User, error) {
+	var users []models.User
+	if err := r.DB.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // implementation of FindByUsername
 func (r *UserRepositoryImpl) FindByUsername(username string) (*models.User, error) {
 	var user models.User
@@ -9,6 +9,8 @@ import (
 type UserRepository interface {
 	GetUserByID(userID uuid.UUID) (*models.User, error)
 	UpdateUser(user *models.User) error
+	UpdatePassword(userID uuid.UUID, hash string) error
+	UpdateEmail(userID uuid.UUID, address string) error
 	DeleteUser(userID uuid.UUID) error
 	GetUsersWithPendingDeletion() ([]models.User, error)
 
@@ -19,7 +21,9 @@ type UserRepository interface {
 	FindByEmail(email string) (*models.User, error)
 
 	FindByID(userID uuid.UUID) (*models.User, error)
+	FindByIDs(userIDs []uuid.UUID) ([]models.User, error)
 	FindByUsername(username string) (*models.User, error)
 	RequestAccountDeletion(userID uuid.UUID) error
-	FindByEmailConfirmToken(token string) (*models.User, error)
+	MarkEmailVerified(userID uuid.UUID) error
+	PromoteVerifiedToAdmin(emails []string) (int64, error)
 }
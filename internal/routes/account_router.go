@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// credential changes of the signed-in user; both require the current password
+func SetupAccountRoutes(router *gin.RouterGroup, accountHandler *handlers.AccountHandler) {
+	account := router.Group("/account")
+	{
+		account.PUT("/password", accountHandler.ChangePassword)
+		account.PUT("/email", accountHandler.ChangeEmail)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// admin routes; the services check the role
+func SetupAdminRoutes(router *gin.RouterGroup, deadLetterHandler *handlers.DeadLetterHandler) {
+	admin := router.Group("/admin")
+	{
+		admin.GET("/dead-letters", deadLetterHandler.ListDeadLetters)
+		admin.GET("/dead-letters/:id", deadLetterHandler.GetDeadLetter)
+		admin.POST("/dead-letters/:id/replay", deadLetterHandler.ReplayDeadLetter)
+		admin.POST("/outbox/replay", deadLetterHandler.ReplayOutbox)
+	}
+}
@@ -9,9 +9,28 @@ import (
 func SetupCommentRoutes(router *gin.RouterGroup, commentHandler *handlers.CommentHandler) {
 	posts := router.Group("/posts/comments")
 	{
-		posts.POST("/:post_id/create", commentHandler.CreateComment)
 		posts.GET("/:comment_id", commentHandler.GetCommentById)
 		posts.PUT("/:comment_id", commentHandler.UpdateComment)
 		posts.DELETE("/:comment_id", commentHandler.DeleteComment)
+		posts.POST("/:comment_id/reply", commentHandler.ReplyToComment)
+		posts.GET("/:comment_id/replies", commentHandler.GetReplies)
+
+		// deprecated alias of POST /posts/:id/comments kept for existing clients; gin
+		// requires one wildcard name per segment, so the post id arrives as comment_id
+		posts.POST("/:comment_id/create", deprecatedCreateComment, commentHandler.CreateComment)
 	}
+
+	// comments of a post live under the post itself
+	router.POST("/posts/:id/comments", commentHandler.CreateComment)
+	router.GET("/posts/:id/comments", commentHandler.GetCommentsByPostID)
+}
+
+// deprecatedCreateComment exposes the post id where CreateComment reads it and
+// points clients at the route replacing the old one
+func deprecatedCreateComment(c *gin.Context) {
+	postID := c.Param("comment_id")
+	c.Params = append(c.Params, gin.Param{Key: "id", Value: postID})
+	c.Header("Deprecation", "true")
+	c.Header("Link", "</posts/"+postID+"/comments>; rel=\"successor-version\"")
+	c.Next()
 }
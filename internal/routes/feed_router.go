@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFeedRoutes(router *gin.RouterGroup, feedHandler *handlers.FeedHandler) {
+	router.GET("/feed", feedHandler.GetFeed) // home timeline
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupMessageRoutes(router *gin.RouterGroup, messageHandler *handlers.MessageHandler) {
+	conversations := router.Group("/conversations")
+	{
+		conversations.GET("", messageHandler.ListConversations)
+		conversations.POST("", messageHandler.StartConversation)
+		conversations.GET("/:id/messages", messageHandler.GetMessages)
+		conversations.POST("/:id/messages", messageHandler.SendMessage)
+		conversations.POST("/:id/read", messageHandler.MarkRead)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupNotificationRoutes(router *gin.RouterGroup, notificationHandler *handlers.NotificationHandler) {
+	notifications := router.Group("/notifications")
+	{
+		notifications.GET("", notificationHandler.ListNotifications)
+		notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+		notifications.POST("/read", notificationHandler.MarkManyAsRead) // bulk, all unread when no ids are given
+		notifications.POST("/:id/read", notificationHandler.MarkAsRead)
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupReactionRoutes(router *gin.RouterGroup, reactionHandler *handlers.ReactionHandler) {
+	posts := router.Group("/posts/:id/reactions")
+	{
+		posts.GET("", reactionHandler.GetPostReactions)      // counts per reaction type
+		posts.PUT("", reactionHandler.ReactToPost)           // set or change reaction
+		posts.DELETE("", reactionHandler.RemovePostReaction) // remove reaction
+	}
+
+	comments := router.Group("/posts/comments/:comment_id/reactions")
+	{
+		comments.GET("", reactionHandler.GetCommentReactions)      // counts per reaction type
+		comments.PUT("", reactionHandler.ReactToComment)           // set or change reaction
+		comments.DELETE("", reactionHandler.RemoveCommentReaction) // remove reaction
+	}
+}
@@ -3,40 +3,56 @@ package routes
 import (
 	"GoVersi/internal/handlers"
 	"GoVersi/internal/middleware"
-	"log"
 	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
 // setupRouter inicializa as rotas da aplicação
-func SetupRouter(postHandler *handlers.PostHandler, friendshipHandler *handlers.FriendshipHandler, commentHandler *handlers.CommentHandler, likeHandler *handlers.LikeHandler) *gin.Engine {
+func SetupRouter(tokenBlacklist middleware.TokenBlacklistChecker, postHandler *handlers.PostHandler, friendshipHandler *handlers.FriendshipHandler, commentHandler *handlers.CommentHandler, reactionHandler *handlers.ReactionHandler, feedHandler *handlers.FeedHandler, notificationHandler *handlers.NotificationHandler, streamHandler *handlers.StreamHandler, messageHandler *handlers.MessageHandler, searchHandler *handlers.SearchHandler, topicHandler *handlers.TopicHandler, mediaHandler *handlers.MediaHandler, uploadHandler *handlers.UploadHandler, deadLetterHandler *handlers.DeadLetterHandler, accountHandler *handlers.AccountHandler) *gin.Engine {
 	r := gin.Default()
 
-	SetupRoutes(r, postHandler, friendshipHandler, commentHandler, likeHandler)
+	SetupRoutes(r, tokenBlacklist, postHandler, friendshipHandler, commentHandler, reactionHandler, feedHandler, notificationHandler, streamHandler, messageHandler, searchHandler, topicHandler, mediaHandler, uploadHandler, deadLetterHandler, accountHandler)
 
 	return r
 }
 
 // SetupRoutes agora também recebe um FriendshipHandler
-func SetupRoutes(router *gin.Engine, postHandler *handlers.PostHandler, friendshipHandler *handlers.FriendshipHandler, commentHandler *handlers.CommentHandler, likeHandler *handlers.LikeHandler) {
+func SetupRoutes(router *gin.Engine, tokenBlacklist middleware.TokenBlacklistChecker, postHandler *handlers.PostHandler, friendshipHandler *handlers.FriendshipHandler, commentHandler *handlers.CommentHandler, reactionHandler *handlers.ReactionHandler, feedHandler *handlers.FeedHandler, notificationHandler *handlers.NotificationHandler, streamHandler *handlers.StreamHandler, messageHandler *handlers.MessageHandler, searchHandler *handlers.SearchHandler, topicHandler *handlers.TopicHandler, mediaHandler *handlers.MediaHandler, uploadHandler *handlers.UploadHandler, deadLetterHandler *handlers.DeadLetterHandler, accountHandler *handlers.AccountHandler) {
 	// secret key
 	secretKey := os.Getenv("JWT_SECRET_KEY")
-	log.Printf("SetupRoutes Secret Key: %s", secretKey)
 
 	// public routes (authentication not required)
 	router.POST("/login", handlers.Login)
 	router.POST("/register", handlers.RegisterUser)
-	router.GET("/confirm-email", handlers.ConfirmEmail)
+	router.POST("/token/refresh", handlers.RefreshToken)
+	router.GET("/confirm-email", accountHandler.ConfirmEmail)
+	router.POST("/confirm-email/resend", accountHandler.ResendVerification)
+	router.POST("/password/forgot", accountHandler.ForgotPassword)
+	router.POST("/password/reset", accountHandler.ResetPassword)
+
+	// media of the local blob store; access is granted by the URL signature
+	if mediaHandler != nil {
+		router.GET("/media/*key", mediaHandler.ServeMedia)
+	}
 
 	// protected routes (authentication required)
 	auth := router.Group("/")
-	auth.Use(middleware.AuthMiddleware(secretKey))
+	auth.Use(middleware.AuthMiddleware(secretKey, tokenBlacklist))
 
 	// user routes
 	SetupUserRoutes(auth)
 	SetupPostRoutes(auth, postHandler)
 	SetupFriendshipRoutes(auth, friendshipHandler)
 	SetupCommentRoutes(auth, commentHandler)
-	SetupLikeRoutes(auth, likeHandler)
+	SetupReactionRoutes(auth, reactionHandler)
+	SetupFeedRoutes(auth, feedHandler)
+	SetupNotificationRoutes(auth, notificationHandler)
+	SetupStreamRoutes(router, auth, secretKey, tokenBlacklist, streamHandler)
+	SetupMessageRoutes(auth, messageHandler)
+	SetupSearchRoutes(auth, searchHandler)
+	SetupTopicRoutes(auth, topicHandler)
+	SetupUploadRoutes(auth, uploadHandler)
+	SetupAdminRoutes(auth, deadLetterHandler)
+	SetupAccountRoutes(auth, accountHandler)
 }
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupSearchRoutes(router *gin.RouterGroup, searchHandler *handlers.SearchHandler) {
+	router.GET("/search", searchHandler.Search) // ?q=&type=posts|users|comments
+}
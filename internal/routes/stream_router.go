@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupStreamRoutes registers the stream outside the auth group: EventSource cannot
+// send Authorization, so browsers open it with a ticket obtained with their token
+func SetupStreamRoutes(router *gin.Engine, auth *gin.RouterGroup, secretKey string, tokenBlacklist middleware.TokenBlacklistChecker, streamHandler *handlers.StreamHandler) {
+	auth.POST("/stream/ticket", streamHandler.IssueTicket)
+	router.GET("/stream", middleware.StreamAuthMiddleware(secretKey, tokenBlacklist, streamHandler.Tickets()), streamHandler.Stream) // server-sent events
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTopicRoutes(router *gin.RouterGroup, topicHandler *handlers.TopicHandler) {
+	topics := router.Group("/topics")
+	{
+		topics.GET("", topicHandler.ListTopics)
+		topics.POST("", topicHandler.CreateTopic) // moderators and admins
+		topics.GET("/trending", topicHandler.GetTrendingTopics)
+		topics.GET("/following", topicHandler.ListFollowedTopics)
+		topics.GET("/:slug", topicHandler.GetTopic)
+		topics.POST("/:slug/follow", topicHandler.FollowTopic)
+		topics.DELETE("/:slug/follow", topicHandler.UnfollowTopic)
+		topics.GET("/:slug/posts", topicHandler.GetTopicPosts)
+	}
+}
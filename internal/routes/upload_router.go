@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"GoVersi/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupUploadRoutes(router *gin.RouterGroup, uploadHandler *handlers.UploadHandler) {
+	uploads := router.Group("/uploads")
+	{
+		uploads.POST("", uploadHandler.CreateUpload)
+		uploads.HEAD("/:id", uploadHandler.GetUploadOffset)
+		uploads.PATCH("/:id", uploadHandler.PatchUpload)
+		uploads.DELETE("/:id", uploadHandler.DeleteUpload)
+		uploads.POST("/:id/finalize", uploadHandler.FinalizeUpload)
+	}
+}
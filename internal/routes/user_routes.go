@@ -15,6 +15,7 @@ func SetupUserRoutes(router *gin.RouterGroup) {
 		users.DELETE("/:id", handlers.DeleteUser)
 
 		users.PATCH("/:id/suspend", handlers.SuspendUser)
+		users.PATCH("/:id/role", handlers.SetUserRole)
 		users.POST("/:id/request-deletion", handlers.RequestAccountDeletion)
 		users.DELETE("/:id/permanently-delete", handlers.PermanentlyDeleteUser)
 
@@ -0,0 +1,99 @@
+// Package search provides full-text search over posts, comments and users.
+//
+// The SearchIndex interface hides where the index lives: PostgresIndex relies on
+// generated tsvector columns maintained by the database itself, while MemoryIndex
+// keeps an inverted index in process and is meant for tests and local runs.
+package search
+
+import (
+	"GoVersi/internal/models"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+type DocumentType string
+
+const (
+	TypePosts    DocumentType = "posts"
+	TypeUsers    DocumentType = "users"
+	TypeComments DocumentType = "comments"
+)
+
+func (t DocumentType) IsValid() bool {
+	switch t {
+	case TypePosts, TypeUsers, TypeComments:
+		return true
+	}
+	return false
+}
+
+// Document is the searchable text of a post, comment or user
+type Document struct {
+	ID   uuid.UUID
+	Type DocumentType
+	Text string
+}
+
+type Query struct {
+	Text   string
+	Type   DocumentType
+	Offset int
+	Limit  int
+}
+
+// Hit is a matching document. Highlight is an HTML fragment: the text is escaped and
+// only the <b></b> around matched terms is markup.
+type Hit struct {
+	ID        uuid.UUID
+	Type      DocumentType
+	Score     float64
+	Highlight string
+}
+
+type SearchIndex interface {
+	// Index adds or replaces a document
+	Index(doc Document) error
+	Remove(docType DocumentType, id uuid.UUID) error
+	// Search returns hits ordered by relevance, best first
+	Search(query Query) ([]Hit, error)
+}
+
+func PostDocument(post *models.Post) Document {
+	return Document{ID: post.ID, Type: TypePosts, Text: post.Title + " " + post.Content + " " + post.Topic}
+}
+
+func CommentDocument(comment *models.Comment) Document {
+	return Document{ID: comment.ID, Type: TypeComments, Text: comment.Content}
+}
+
+func UserDocument(user *models.User) Document {
+	return Document{ID: user.ID, Type: TypeUsers, Text: user.Username}
+}
+
+// Tokenize lowercases the text and splits it on anything that is not a letter or digit
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// backends mark matches with control characters that are stripped from the indexed
+// text, so that escaping the fragment cannot touch the markers
+const (
+	matchStart = "\x01"
+	matchStop  = "\x02"
+)
+
+var (
+	markerStripper  = strings.NewReplacer(matchStart, "", matchStop, "")
+	markerHighlight = strings.NewReplacer(matchStart, "<b>", matchStop, "</b>")
+)
+
+// highlightHTML escapes a fragment whose matches sit between matchStart and matchStop
+// and turns the markers into <b></b>
+func highlightHTML(fragment string) string {
+	return markerHighlight.Replace(html.EscapeString(fragment))
+}
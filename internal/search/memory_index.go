@@ -0,0 +1,240 @@
+package search
+
+import (
+	"GoVersi/internal/models"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	highlightWords   = 30 // words kept around the first match
+	highlightLeading = 5  // words kept before the first match
+
+	backfillBatchSize = 500
+)
+
+type memoryDoc struct {
+	text   string
+	terms  map[string]int
+	length int
+}
+
+// MemoryIndex is an in-process inverted index ranked by tf-idf. Every query term must
+// match, like the Postgres implementation. Nothing is persisted.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[DocumentType]map[uuid.UUID]*memoryDoc
+	postings map[DocumentType]map[string]map[uuid.UUID]int
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[DocumentType]map[uuid.UUID]*memoryDoc),
+		postings: make(map[DocumentType]map[string]map[uuid.UUID]int),
+	}
+}
+
+// Backfill indexes the posts, comments and active users already stored, so that a
+// process starting with an empty index can search existing content
+func (m *MemoryIndex) Backfill(db *gorm.DB) error {
+	var posts []models.Post
+	err := db.FindInBatches(&posts, backfillBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range posts {
+			m.Index(PostDocument(&posts[i]))
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var comments []models.Comment
+	err = db.FindInBatches(&comments, backfillBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range comments {
+			m.Index(CommentDocument(&comments[i]))
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var users []models.User
+	return db.Where("is_active").FindInBatches(&users, backfillBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range users {
+			m.Index(UserDocument(&users[i]))
+		}
+		return nil
+	}).Error
+}
+
+func (m *MemoryIndex) Index(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(doc.Type, doc.ID)
+
+	tokens := Tokenize(doc.Text)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	terms := make(map[string]int)
+	for _, t := range tokens {
+		terms[t]++
+	}
+
+	if m.docs[doc.Type] == nil {
+		m.docs[doc.Type] = make(map[uuid.UUID]*memoryDoc)
+		m.postings[doc.Type] = make(map[string]map[uuid.UUID]int)
+	}
+	m.docs[doc.Type][doc.ID] = &memoryDoc{text: doc.Text, terms: terms, length: len(tokens)}
+
+	for term, freq := range terms {
+		if m.postings[doc.Type][term] == nil {
+			m.postings[doc.Type][term] = make(map[uuid.UUID]int)
+		}
+		m.postings[doc.Type][term][doc.ID] = freq
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Remove(docType DocumentType, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(docType, id)
+	return nil
+}
+
+func (m *MemoryIndex) remove(docType DocumentType, id uuid.UUID) {
+	doc, ok := m.docs[docType][id]
+	if !ok {
+		return
+	}
+
+	for term := range doc.terms {
+		delete(m.postings[docType][term], id)
+		if len(m.postings[docType][term]) == 0 {
+			delete(m.postings[docType], term)
+		}
+	}
+	delete(m.docs[docType], id)
+}
+
+func (m *MemoryIndex) Search(query Query) ([]Hit, error) {
+	terms := uniqueTerms(Tokenize(query.Text))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	postings := m.postings[query.Type]
+	total := float64(len(m.docs[query.Type]))
+
+	// start from the rarest term so the intersection stays small
+	sort.Slice(terms, func(i, j int) bool { return len(postings[terms[i]]) < len(postings[terms[j]]) })
+
+	scores := make(map[uuid.UUID]float64)
+	for id := range postings[terms[0]] {
+		scores[id] = 0
+	}
+
+	for _, term := range terms {
+		docs := postings[term]
+		idf := math.Log(1 + total/float64(len(docs)+1))
+		for id := range scores {
+			freq, ok := docs[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += float64(freq) / float64(m.docs[query.Type][id].length) * idf
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Type: query.Type, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID.String() < hits[j].ID.String()
+	})
+
+	if query.Offset >= len(hits) {
+		return []Hit{}, nil
+	}
+	hits = hits[query.Offset:]
+	if query.Limit > 0 && len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+
+	matched := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		matched[t] = true
+	}
+	for i := range hits {
+		hits[i].Highlight = highlight(m.docs[query.Type][hits[i].ID].text, matched)
+	}
+	return hits, nil
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	terms := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// highlight returns an HTML fragment around the first match with matched words wrapped in <b></b>
+func highlight(text string, matched map[string]bool) string {
+	words := strings.Fields(markerStripper.Replace(text))
+
+	first := -1
+	marked := make([]string, len(words))
+	for i, w := range words {
+		marked[i] = w
+		for _, t := range Tokenize(w) {
+			if matched[t] {
+				marked[i] = matchStart + w + matchStop
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+
+	start := 0
+	if first > highlightLeading {
+		start = first - highlightLeading
+	}
+	end := start + highlightWords
+	if end > len(marked) {
+		end = len(marked)
+	}
+
+	fragment := strings.Join(marked[start:end], " ")
+	if start > 0 {
+		fragment = "... " + fragment
+	}
+	if end < len(marked) {
+		fragment += " ..."
+	}
+	return highlightHTML(fragment)
+}
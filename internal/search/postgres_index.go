@@ -0,0 +1,99 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// matches are marked with matchStart/matchStop, turned into <b></b> once the text is escaped
+const headlineOptions = "StartSel=\"" + matchStart + "\", StopSel=\"" + matchStop + "\", MinWords=10, MaxWords=30, MaxFragments=2, FragmentDelimiter=\" ... \""
+
+// searchable text of each table; kept in sync by generated tsvector columns
+var searchSources = map[DocumentType]struct {
+	table  string
+	text   string
+	filter string
+}{
+	TypePosts:    {table: "posts", text: "coalesce(title, '') || ' ' || coalesce(content, '') || ' ' || coalesce(topic, '')"},
+	TypeComments: {table: "comments", text: "coalesce(content, '')"},
+	TypeUsers:    {table: "users", text: "coalesce(username, '')", filter: "AND is_active"},
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// PostgresIndex searches the search_vector columns added by MigratePostgres. The database
+// keeps them up to date, so Index and Remove have nothing to do.
+type PostgresIndex struct {
+	db       *gorm.DB
+	language string
+}
+
+func NewPostgresIndex(db *gorm.DB, language string) *PostgresIndex {
+	return &PostgresIndex{db: db, language: language}
+}
+
+// MigratePostgres adds a generated search_vector column with a GIN index to every searchable
+// table. The language is baked into the column, so changing it later requires dropping them.
+func MigratePostgres(db *gorm.DB, language string) error {
+	if !languagePattern.MatchString(language) {
+		return fmt.Errorf("invalid search language %q", language)
+	}
+
+	for _, source := range searchSources {
+		statements := []string{
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS search_vector tsvector
+				GENERATED ALWAYS AS (to_tsvector('%s'::regconfig, %s)) STORED`, source.table, language, source.text),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_search ON %s USING GIN (search_vector)`, source.table, source.table),
+		}
+		for _, stmt := range statements {
+			if err := db.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *PostgresIndex) Index(doc Document) error {
+	return nil
+}
+
+func (p *PostgresIndex) Remove(docType DocumentType, id uuid.UUID) error {
+	return nil
+}
+
+func (p *PostgresIndex) Search(query Query) ([]Hit, error) {
+	source, ok := searchSources[query.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown document type %q", query.Type)
+	}
+
+	var rows []struct {
+		ID        uuid.UUID
+		Score     float64
+		Highlight string
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT id, ts_rank(search_vector, q) AS score,
+			ts_headline(?::regconfig, translate(%s, chr(1) || chr(2), ''), q, ?) AS highlight
+		FROM %s, websearch_to_tsquery(?::regconfig, ?) AS q
+		WHERE search_vector @@ q %s
+		ORDER BY score DESC, id
+		OFFSET ? LIMIT ?`, source.text, source.table, source.filter)
+
+	err := p.db.Raw(sql, p.language, headlineOptions, p.language, query.Text, query.Offset, query.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, len(rows))
+	for i, row := range rows {
+		hits[i] = Hit{ID: row.ID, Type: query.Type, Score: row.Score, Highlight: highlightHTML(row.Highlight)}
+	}
+	return hits, nil
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/service/email"
+	"GoVersi/internal/utils"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrWeakPassword      = errors.New("password is too short")
+
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+	ErrWrongPassword     = errors.New("current password is incorrect")
+	ErrEmailTaken        = errors.New("email address is already in use")
+	ErrEmailUnchanged    = errors.New("this already is the email address of the account")
+	ErrTooManyEmailLinks = errors.New("too many confirmation emails today, try again later")
+)
+
+// RequestMeta tells where an account change came from, for the audit trail
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+}
+
+func audit(tx *repository.Tx, userID uuid.UUID, action models.AuditAction, detail string, meta RequestMeta) error {
+	return tx.Audit().Create(&models.AuditEvent{
+		UserID:    userID,
+		Action:    action,
+		Detail:    detail,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+	})
+}
+
+// AccountService handles account recovery and email verification
+type AccountService struct {
+	users         repository.UserRepository
+	resets        *repository.PasswordResetRepository
+	verifications *repository.EmailVerificationRepository
+	verifier      *EmailVerifier
+	tx            *repository.TxManager
+	mails         *email.Templates
+	cfg           config.AccountConfig
+	cron          *cron.Cron
+}
+
+func NewAccountService(users repository.UserRepository, resets *repository.PasswordResetRepository, verifications *repository.EmailVerificationRepository, verifier *EmailVerifier, tx *repository.TxManager, mails *email.Templates, cfg config.AccountConfig) *AccountService {
+	return &AccountService{users: users, resets: resets, verifications: verifications, verifier: verifier, tx: tx, mails: mails, cfg: cfg}
+}
+
+// ConfirmEmail verifies the address a token was issued for. When that address is not
+// the one of the account, the token ends a pending email change and the address
+// replaces the current one.
+func (s *AccountService) ConfirmEmail(token string, meta RequestMeta) error {
+	record, err := s.verifications.FindByHash(utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+
+	user, err := s.users.FindByID(record.UserID)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+	change := !strings.EqualFold(user.Email, record.Email)
+	if change {
+		if err := s.checkEmailAvailable(record.Email); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	return s.tx.Do(func(tx *repository.Tx) error {
+		claimed, err := tx.EmailVerifications().Consume(record.ID, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrInvalidVerificationToken
+		}
+		if err := tx.EmailVerifications().InvalidateForUser(record.UserID, now); err != nil {
+			return err
+		}
+		if !change {
+			if err := tx.Users().MarkEmailVerified(record.UserID); err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Users().UpdateEmail(record.UserID, record.Email); err != nil {
+				return err
+			}
+			if err := audit(tx, record.UserID, models.AuditEmailChanged, user.Email+" -> "+record.Email, meta); err != nil {
+				return err
+			}
+		}
+		return s.promoteAdmins(tx.Users(), record.Email)
+	})
+}
+
+// BootstrapAdmins promotes the verified accounts listed in ADMIN_EMAILS; the others
+// are promoted when they confirm their address. This is how the first admin is made.
+func (s *AccountService) BootstrapAdmins() error {
+	if len(s.cfg.AdminEmails) == 0 {
+		return nil
+	}
+	promoted, err := s.users.PromoteVerifiedToAdmin(s.cfg.AdminEmails)
+	if promoted > 0 {
+		log.Printf("Promoted %d accounts listed in ADMIN_EMAILS to admin", promoted)
+	}
+	return err
+}
+
+func (s *AccountService) promoteAdmins(users repository.UserRepository, address string) error {
+	for _, admin := range s.cfg.AdminEmails {
+		if strings.EqualFold(admin, address) {
+			_, err := users.PromoteVerifiedToAdmin([]string{admin})
+			return err
+		}
+	}
+	return nil
+}
+
+// ChangePassword replaces the password of a signed-in user who knows the current one.
+// Every other session of the user is revoked; sessionID is the one kept.
+func (s *AccountService) ChangePassword(userID uuid.UUID, sessionID, current, password string, meta RequestMeta) error {
+	if err := s.validatePassword(password); err != nil {
+		return err
+	}
+	if _, err := s.checkPassword(userID, current); err != nil {
+		return err
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.Do(func(tx *repository.Tx) error {
+		if err := tx.Users().UpdatePassword(userID, hash); err != nil {
+			return err
+		}
+		if err := tx.PasswordResets().InvalidateForUser(userID, time.Now()); err != nil {
+			return err
+		}
+		// an access token issued without a session keeps none
+		familyID, _ := uuid.Parse(sessionID)
+		if err := tx.RefreshTokens().RevokeAllForUserExcept(userID, familyID); err != nil {
+			return err
+		}
+		return audit(tx, userID, models.AuditPasswordChanged, "", meta)
+	})
+}
+
+// RequestEmailChange stages address as the new email of a signed-in user who knows
+// their password. Nothing changes until a link sent to address is followed; the
+// current address is told about the request. Earlier pending links stop working.
+func (s *AccountService) RequestEmailChange(userID uuid.UUID, current, address string, meta RequestMeta) error {
+	address = strings.TrimSpace(address)
+	if parsed, err := mail.ParseAddress(address); err != nil || parsed.Address != address {
+		return email.ErrInvalidAddress
+	}
+
+	user, err := s.checkPassword(userID, current)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, address) {
+		return ErrEmailUnchanged
+	}
+	if err := s.checkEmailAvailable(address); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today, err := s.verifications.CountIssuedSince(userID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if today >= int64(s.cfg.EmailVerificationDailyLimit) {
+		return ErrTooManyEmailLinks
+	}
+
+	notice, err := s.mails.EmailChangeNotice(user, address, now)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.Do(func(tx *repository.Tx) error {
+		if err := tx.EmailVerifications().InvalidateForUser(userID, now); err != nil {
+			return err
+		}
+		if err := s.verifier.Issue(tx, user, address); err != nil {
+			return err
+		}
+		if err := email.NewEmailQueueService(tx.Outbox()).PublishEmail(notice); err != nil {
+			return err
+		}
+		return audit(tx, userID, models.AuditEmailChangeRequested, address, meta)
+	})
+}
+
+func (s *AccountService) checkPassword(userID uuid.UUID, password string) (*models.User, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+func (s *AccountService) checkEmailAvailable(address string) error {
+	other, err := s.users.FindByEmail(address)
+	if err != nil {
+		return err
+	}
+	if other != nil {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// ResendVerification emails a new confirmation link to an unverified account.
+// Like RequestPasswordReset it never tells whether anything was sent: unknown or
+// already verified addresses and throttled requests succeed silently.
+func (s *AccountService) ResendVerification(address string) error {
+	user, err := s.users.FindByEmail(strings.TrimSpace(address))
+	if err != nil {
+		return err
+	}
+	if user == nil || !user.IsActive || user.IsEmailVerified {
+		return nil
+	}
+
+	now := time.Now()
+	recent, err := s.verifications.CountIssuedSince(user.ID, now.Add(-s.cfg.EmailVerificationResendInterval))
+	if err != nil || recent > 0 {
+		return err
+	}
+	today, err := s.verifications.CountIssuedSince(user.ID, now.Add(-24*time.Hour))
+	if err != nil || today >= int64(s.cfg.EmailVerificationDailyLimit) {
+		return err
+	}
+
+	return s.tx.Do(func(tx *repository.Tx) error {
+		return s.verifier.Issue(tx, user, user.Email)
+	})
+}
+
+// RequestPasswordReset emails a reset link to the account of the address, if there is
+// one. Callers must not tell the outcome apart, so that accounts cannot be enumerated:
+// unknown addresses and throttled requests succeed silently.
+func (s *AccountService) RequestPasswordReset(address string) error {
+	user, err := s.users.FindByEmail(strings.TrimSpace(address))
+	if err != nil {
+		return err
+	}
+	if user == nil || !user.IsActive {
+		return nil
+	}
+
+	now := time.Now()
+	recent, err := s.resets.IssuedSince(user.ID, now.Add(-s.cfg.PasswordResetInterval))
+	if err != nil || recent {
+		return err
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	msg, err := s.mails.PasswordReset(user, token, s.cfg.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.tx.Do(func(tx *repository.Tx) error {
+		err := tx.PasswordResets().Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(s.cfg.PasswordResetTTL),
+		})
+		if err != nil {
+			return err
+		}
+		return email.NewEmailQueueService(tx.Outbox()).PublishEmail(msg)
+	})
+}
+
+// ResetPassword sets a new password with a reset token. The token and every other
+// pending token of the user are spent, and all sessions of the user are revoked.
+func (s *AccountService) ResetPassword(token, password string) error {
+	if err := s.validatePassword(password); err != nil {
+		return err
+	}
+
+	record, err := s.resets.FindByHash(utils.HashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.tx.Do(func(tx *repository.Tx) error {
+		claimed, err := tx.PasswordResets().Consume(record.ID, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrInvalidResetToken
+		}
+		if err := tx.Users().UpdatePassword(record.UserID, hash); err != nil {
+			return err
+		}
+		if err := tx.PasswordResets().InvalidateForUser(record.UserID, now); err != nil {
+			return err
+		}
+		return tx.RefreshTokens().RevokeAllForUser(record.UserID)
+	})
+}
+
+func (s *AccountService) validatePassword(password string) error {
+	if len([]rune(password)) < s.cfg.PasswordMinLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// StartCronJob schedules the removal of expired reset and verification tokens
+func (s *AccountService) StartCronJob() {
+	s.cron = cron.New()
+	s.cron.AddFunc("@daily", func() {
+		now := time.Now()
+		if err := s.resets.DeleteExpired(now); err != nil {
+			log.Printf("Failed to remove expired password reset tokens: %v", err)
+		}
+		if err := s.verifications.DeleteExpired(now); err != nil {
+			log.Printf("Failed to remove expired email verification tokens: %v", err)
+		}
+	})
+	s.cron.Start()
+}
+
+func (s *AccountService) StopCronJob() {
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+}
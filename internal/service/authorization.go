@@ -0,0 +1,73 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrForbidden is returned when the caller is authenticated but not allowed to act
+	ErrForbidden = errors.New("you do not have permission to perform this action")
+	// ErrEmailNotVerified is returned when an action requires a confirmed email address
+	ErrEmailNotVerified = errors.New("confirm your email address first")
+)
+
+// Authorizer answers ownership and role questions for the services
+type Authorizer struct {
+	userRepo repository.UserRepository
+}
+
+func NewAuthorizer(userRepo repository.UserRepository) *Authorizer {
+	return &Authorizer{userRepo: userRepo}
+}
+
+func (a *Authorizer) RoleOf(userID uuid.UUID) (models.Role, error) {
+	user, err := a.userRepo.FindByID(userID)
+	if err != nil {
+		return "", ErrForbidden
+	}
+	if user.Role == "" {
+		return models.RoleUser, nil
+	}
+	return user.Role, nil
+}
+
+// RequireRole allows the actor only if they hold one of the given roles
+func (a *Authorizer) RequireRole(actorID uuid.UUID, roles ...models.Role) error {
+	role, err := a.RoleOf(actorID)
+	if err != nil {
+		return err
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// RequireOwnerOr allows the owner of a resource, or any actor holding one of the given roles
+func (a *Authorizer) RequireOwnerOr(actorID, ownerID uuid.UUID, roles ...models.Role) error {
+	if actorID == ownerID {
+		return nil
+	}
+	if len(roles) == 0 {
+		return ErrForbidden
+	}
+	return a.RequireRole(actorID, roles...)
+}
+
+// RequireVerifiedEmail allows the actor only once they confirmed their email address
+func (a *Authorizer) RequireVerifiedEmail(actorID uuid.UUID) error {
+	user, err := a.userRepo.FindByID(actorID)
+	if err != nil {
+		return ErrForbidden
+	}
+	if !user.IsEmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
@@ -1,43 +1,222 @@
 package services
 
 import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/media"
 	"GoVersi/internal/models"
 	"GoVersi/internal/repository"
+	"GoVersi/internal/search"
+	"GoVersi/internal/utils"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// CommentNode is a comment with the first page of its replies
+type CommentNode struct {
+	models.Comment
+	ReplyCount    int64          `json:"reply_count"`
+	Replies       []*CommentNode `json:"replies"`
+	RepliesCursor string         `json:"replies_cursor,omitempty"` // pass to the replies endpoint to load more
+}
+
+type CommentPage struct {
+	Items      []*CommentNode `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
 type CommentService struct {
-	repo *repository.CommentRepository
+	repo     *repository.CommentRepository
+	postRepo *repository.PostRepository
+	authz    *Authorizer
+	notifier NotificationPublisher
+	index    search.SearchIndex
+	images   ImageEnqueuer
+	refs     MediaRefs
+	cfg      config.CommentConfig
 }
 
-func NewCommentService(repo *repository.CommentRepository) *CommentService {
-	return &CommentService{repo: repo}
+func NewCommentService(repo *repository.CommentRepository, postRepo *repository.PostRepository, authz *Authorizer, notifier NotificationPublisher, index search.SearchIndex, images ImageEnqueuer, refs MediaRefs, cfg config.CommentConfig) *CommentService {
+	return &CommentService{repo: repo, postRepo: postRepo, authz: authz, notifier: notifier, index: index, images: images, refs: refs, cfg: cfg}
 }
 
-func (s *CommentService) CreateComment(content, imageURL string, postID, authorID uuid.UUID) (*models.Comment, error) {
+// CanComment checks that the author may comment, so that uploads can be refused early
+func (s *CommentService) CanComment(authorID uuid.UUID) error {
+	if !s.cfg.RequireVerifiedEmail {
+		return nil
+	}
+	return s.authz.RequireVerifiedEmail(authorID)
+}
+
+func (s *CommentService) CreateComment(content string, image models.MediaKey, postID, authorID uuid.UUID) (*models.Comment, error) {
+	if err := s.CanComment(authorID); err != nil {
+		return nil, err
+	}
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return nil, errors.New("post not found")
+	}
+
 	comment := &models.Comment{
 		Content:  content,
-		ImageURL: imageURL,
+		ImageURL: image,
 		PostID:   postID.String(), // Associando o comentário ao PostID
 		AuthorID: authorID,
 	}
 
-	if err := s.repo.Create(comment); err != nil {
+	if err := s.create(comment); err != nil {
+		return nil, err
+	}
+	enqueueImage(s.images, media.TargetComment, comment.ID, comment.ImageURL)
+	indexDocument(s.index, search.CommentDocument(comment))
+
+	s.notifier.Publish(NotificationEvent{
+		RecipientID: post.AuthorID,
+		ActorID:     authorID,
+		Type:        models.NotificationComment,
+		TargetType:  "post",
+		TargetID:    post.ID,
+	})
+
+	return comment, nil
+}
+
+// CreateReply answers an existing comment; the reply belongs to the same post
+func (s *CommentService) CreateReply(content string, image models.MediaKey, parentID, authorID uuid.UUID) (*models.Comment, error) {
+	if err := s.CanComment(authorID); err != nil {
+		return nil, err
+	}
+	parent, err := s.GetCommentByID(parentID)
+	if err != nil {
 		return nil, err
 	}
 
+	comment := &models.Comment{
+		Content:  content,
+		ImageURL: image,
+		PostID:   parent.PostID,
+		ParentID: &parent.ID,
+		AuthorID: authorID,
+	}
+
+	if err := s.create(comment); err != nil {
+		return nil, err
+	}
+	enqueueImage(s.images, media.TargetComment, comment.ID, comment.ImageURL)
+	indexDocument(s.index, search.CommentDocument(comment))
+
+	s.notifier.Publish(NotificationEvent{
+		RecipientID: parent.AuthorID,
+		ActorID:     authorID,
+		Type:        models.NotificationReply,
+		TargetType:  "comment",
+		TargetID:    parent.ID,
+	})
+
 	return comment, nil
 }
 
-func (s *CommentService) GetCommentsByPostID(postID uuid.UUID) ([]*models.Comment, error) {
-	comments, err := s.repo.FindByPostID(postID)
+// GetCommentTree returns a page of top-level comments with their replies nested up to depth levels
+func (s *CommentService) GetCommentTree(postID uuid.UUID, cursor string, limit, depth int) (*CommentPage, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.FindTopLevelByPostID(postID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildPage(comments, limit, depth)
+}
+
+// GetReplies returns a page of direct replies of a comment, nested up to depth levels
+func (s *CommentService) GetReplies(commentID uuid.UUID, cursor string, limit, depth int) (*CommentPage, error) {
+	after, err := utils.DecodeCursor(cursor)
 	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+
+	if _, err := s.GetCommentByID(commentID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.repo.FindReplies(commentID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildPage(comments, limit, depth)
+}
+
+func (s *CommentService) buildPage(comments []models.Comment, limit, depth int) (*CommentPage, error) {
+	page := &CommentPage{Items: []*CommentNode{}}
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[len(comments)-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	for _, comment := range comments {
+		page.Items = append(page.Items, &CommentNode{Comment: comment, Replies: []*CommentNode{}})
+	}
+
+	if depth > s.cfg.MaxTreeDepth {
+		depth = s.cfg.MaxTreeDepth
+	}
+	if err := s.loadReplies(page.Items, depth); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// loadReplies walks the tree level by level, issuing two queries per level
+func (s *CommentService) loadReplies(level []*CommentNode, depth int) error {
+	for current := 1; len(level) > 0; current++ {
+		ids := make([]uuid.UUID, len(level))
+		byID := make(map[uuid.UUID]*CommentNode, len(level))
+		for i, node := range level {
+			ids[i] = node.ID
+			byID[node.ID] = node
+		}
+
+		counts, err := s.repo.CountReplies(ids)
+		if err != nil {
+			return err
+		}
+		for _, node := range level {
+			node.ReplyCount = counts[node.ID]
+		}
+
+		if current >= depth {
+			return nil
+		}
+
+		replies, err := s.repo.FindFirstRepliesForParents(ids, s.cfg.RepliesPerComment)
+		if err != nil {
+			return err
+		}
+
+		next := make([]*CommentNode, 0, len(replies))
+		for _, reply := range replies {
+			parent := byID[*reply.ParentID]
+			child := &CommentNode{Comment: reply, Replies: []*CommentNode{}}
+			parent.Replies = append(parent.Replies, child)
+			next = append(next, child)
+		}
+
+		for _, node := range level {
+			if n := len(node.Replies); n > 0 && int64(n) < node.ReplyCount {
+				last := node.Replies[n-1]
+				node.RepliesCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+			}
+		}
+
+		level = next
+	}
+	return nil
 }
 
 func (s *CommentService) GetCommentByID(id uuid.UUID) (*models.Comment, error) {
@@ -48,21 +227,58 @@ func (s *CommentService) GetCommentByID(id uuid.UUID) (*models.Comment, error) {
 	return comment, nil
 }
 
-func (s *CommentService) UpdateComment(commentID uuid.UUID, updatedData *models.Comment) (*models.Comment, error) {
+// UpdateComment edits a comment; only its author may do it
+func (s *CommentService) UpdateComment(actorID, commentID uuid.UUID, updatedData *models.Comment) (*models.Comment, error) {
 	existingComment, err := s.GetCommentByID(commentID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.authz.RequireOwnerOr(actorID, existingComment.AuthorID); err != nil {
+		return nil, err
+	}
+
 	existingComment.Content = updatedData.Content
 	existingComment.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(existingComment); err != nil {
 		return nil, err
 	}
+	indexDocument(s.index, search.CommentDocument(existingComment))
 	return existingComment, nil
 }
 
-func (s *CommentService) DeleteComment(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// DeleteComment removes a comment; allowed for its author and for moderators
+func (s *CommentService) DeleteComment(actorID, id uuid.UUID) error {
+	comment, err := s.GetCommentByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authz.RequireOwnerOr(actorID, comment.AuthorID, models.RoleModerator, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	// replies removed along with the comment drop out of the results once their rows are gone
+	deleted, err := s.repo.Delete(id)
+	if err != nil {
+		return err
+	}
+	for _, c := range deleted {
+		releaseMedia(s.refs, imageKeys(c.ImageURL, c.ImageRenditions)...)
+	}
+	removeDocument(s.index, search.TypeComments, id)
+	return nil
+}
+
+// create saves a comment, holding a reference to its image
+func (s *CommentService) create(comment *models.Comment) error {
+	if err := retainMedia(s.refs, comment.ImageURL); err != nil {
+		return err
+	}
+	if err := s.repo.Create(comment); err != nil {
+		releaseMedia(s.refs, comment.ImageURL)
+		return err
+	}
+	return nil
 }
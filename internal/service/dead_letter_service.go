@@ -0,0 +1,171 @@
+package services
+
+import (
+	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/service/email"
+	"GoVersi/internal/utils"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	ErrAlreadyReplayed    = errors.New("dead letter was already replayed")
+	ErrRedactedLetter     = errors.New("dead letter carried a one-time link and was redacted; the user has to request a new one")
+)
+
+// DeadLetterView shows the payload as text; the queued messages are JSON
+type DeadLetterView struct {
+	models.DeadLetter
+	Payload string `json:"payload"`
+}
+
+type DeadLetterPage struct {
+	Items      []DeadLetterView `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// DeadLetterService archives the messages the workers gave up on and lets admins replay them
+type DeadLetterService struct {
+	repo  *repository.DeadLetterRepository
+	tx    *repository.TxManager
+	authz *Authorizer
+}
+
+func NewDeadLetterService(repo *repository.DeadLetterRepository, tx *repository.TxManager, authz *Authorizer) *DeadLetterService {
+	return &DeadLetterService{repo: repo, tx: tx, authz: authz}
+}
+
+// Archive stores a message taken from a dead-letter queue. The tokens of one-time links
+// in emails are blanked out first: admins read the archive, and it is kept indefinitely.
+func (s *DeadLetterService) Archive(letter queue.DeadLetter) error {
+	payload, redacted := redact(letter.Queue, letter.Body)
+	return s.repo.Create(&models.DeadLetter{
+		Queue:    letter.Queue,
+		Payload:  payload,
+		Error:    letter.Error,
+		Retries:  letter.Retries,
+		Redacted: redacted,
+	})
+}
+
+func redact(queueName string, payload []byte) ([]byte, bool) {
+	if queueName != email.QueueName {
+		return payload, false
+	}
+	return email.RedactLinks(payload)
+}
+
+// List returns archived dead letters newest first, optionally of a single queue; admins only
+func (s *DeadLetterService) List(actorID uuid.UUID, queueName, cursor string, limit int) (*DeadLetterPage, error) {
+	if err := s.authz.RequireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	letters, err := s.repo.FindPage(queueName, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &DeadLetterPage{Items: []DeadLetterView{}}
+	if len(letters) > limit {
+		letters = letters[:limit]
+		last := letters[len(letters)-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	for _, letter := range letters {
+		page.Items = append(page.Items, deadLetterView(letter))
+	}
+	return page, nil
+}
+
+func (s *DeadLetterService) Get(actorID, id uuid.UUID) (*DeadLetterView, error) {
+	if err := s.authz.RequireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	letter, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	view := deadLetterView(*letter)
+	return &view, nil
+}
+
+// Replay sends the message back to its original queue with a fresh retry budget.
+// It goes through the outbox, so the letter is only marked replayed once the message
+// is guaranteed to be published; admins only.
+func (s *DeadLetterService) Replay(actorID, id uuid.UUID) (*DeadLetterView, error) {
+	if err := s.authz.RequireRole(actorID, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	letter, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if letter.Redacted {
+		return nil, ErrRedactedLetter
+	}
+
+	now := time.Now()
+	err = s.tx.Do(func(tx *repository.Tx) error {
+		claimed, err := tx.DeadLetters().MarkReplayed(letter.ID, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrAlreadyReplayed
+		}
+		return tx.Outbox().Publish(letter.Queue, letter.Payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	letter.ReplayedAt = &now
+	view := deadLetterView(*letter)
+	return &view, nil
+}
+
+// ReplayOutbox sends the outbox events the relay gave up on, e.g. during a broker
+// outage, back to the relay with a fresh attempt budget. Only the events of queueName
+// are replayed when it is set; redacted emails are skipped. Admins only.
+func (s *DeadLetterService) ReplayOutbox(actorID uuid.UUID, queueName string) (int64, error) {
+	if err := s.authz.RequireRole(actorID, models.RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	var replayed int64
+	err := s.tx.Do(func(tx *repository.Tx) error {
+		var err error
+		replayed, err = tx.Outbox().ReplayFailed(queueName, time.Now())
+		return err
+	})
+	return replayed, err
+}
+
+func (s *DeadLetterService) find(id uuid.UUID) (*models.DeadLetter, error) {
+	letter, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeadLetterNotFound
+		}
+		return nil, err
+	}
+	return letter, nil
+}
+
+func deadLetterView(letter models.DeadLetter) DeadLetterView {
+	return DeadLetterView{DeadLetter: letter, Payload: string(letter.Payload)}
+}
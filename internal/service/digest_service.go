@@ -0,0 +1,108 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/service/email"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+)
+
+// how far back the daily digest looks
+const digestPeriod = 24 * time.Hour
+
+// DigestService emails users a summary of the notifications they have not read
+type DigestService struct {
+	notifications *repository.NotificationRepository
+	users         repository.UserRepository
+	emails        email.EmailQueueService
+	mails         *email.Templates
+	cron          *cron.Cron
+}
+
+func NewDigestService(notifications *repository.NotificationRepository, users repository.UserRepository, emails email.EmailQueueService, mails *email.Templates) *DigestService {
+	return &DigestService{notifications: notifications, users: users, emails: emails, mails: mails}
+}
+
+// SendDigests queues a digest for every verified, active user with unread activity
+// since the given time, and returns how many were queued
+func (s *DigestService) SendDigests(since time.Time) (int, error) {
+	activity, err := s.notifications.SumUnreadSince(since)
+	if err != nil {
+		return 0, err
+	}
+
+	counts := make(map[uuid.UUID]*email.DigestCounts)
+	for _, a := range activity {
+		c, ok := counts[a.RecipientID]
+		if !ok {
+			c = &email.DigestCounts{}
+			counts[a.RecipientID] = c
+		}
+		switch a.Type {
+		case models.NotificationComment:
+			c.Comments += a.Count
+		case models.NotificationReply:
+			c.Replies += a.Count
+		case models.NotificationReaction:
+			c.Reactions += a.Count
+		case models.NotificationFriendRequest:
+			c.FriendRequests += a.Count
+		case models.NotificationFriendAccepted:
+			c.FriendsAccepted += a.Count
+		}
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+
+	recipientIDs := make([]uuid.UUID, 0, len(counts))
+	for id := range counts {
+		recipientIDs = append(recipientIDs, id)
+	}
+	users, err := s.users.FindByIDs(recipientIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range users {
+		user := &users[i]
+		if !user.IsActive || !user.IsEmailVerified || user.IsPendingDeletion {
+			continue
+		}
+
+		msg, err := s.mails.Digest(user, *counts[user.ID])
+		if err != nil {
+			return sent, err
+		}
+		if err := s.emails.PublishEmail(msg); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// StartCronJob schedules the daily digest
+func (s *DigestService) StartCronJob() {
+	s.cron = cron.New()
+	s.cron.AddFunc("@daily", func() {
+		sent, err := s.SendDigests(time.Now().Add(-digestPeriod))
+		if err != nil {
+			log.Printf("Digest failed after queueing %d emails: %v", sent, err)
+			return
+		}
+		log.Printf("Digest queued %d emails", sent)
+	})
+	s.cron.Start()
+}
+
+func (s *DigestService) StopCronJob() {
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+}
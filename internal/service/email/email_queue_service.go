@@ -5,16 +5,21 @@ import (
 	"encoding/json"
 )
 
+// QueueName is the queue consumed by the email worker
+const QueueName = "email_queue"
+
 type EmailQueueService interface {
 	PublishEmail(msg EmailMessage) error
 }
 
 type emailQueueService struct {
-	rabbitmq queue.RabbitMQClient
+	publisher queue.Publisher
 }
 
-func NewEmailQueueService(rabbitmq queue.RabbitMQClient) EmailQueueService {
-	return &emailQueueService{rabbitmq: rabbitmq}
+// NewEmailQueueService queues emails through publisher, either the broker itself or
+// an outbox that relays them once the surrounding transaction commits
+func NewEmailQueueService(publisher queue.Publisher) EmailQueueService {
+	return &emailQueueService{publisher: publisher}
 }
 
 func (s *emailQueueService) PublishEmail(msg EmailMessage) error {
@@ -23,5 +28,5 @@ func (s *emailQueueService) PublishEmail(msg EmailMessage) error {
 		return err
 	}
 
-	return s.rabbitmq.Publish("email_queue", body)
+	return s.publisher.Publish(QueueName, body)
 }
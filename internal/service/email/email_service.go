@@ -1,44 +1,39 @@
 package email
 
 import (
-	"fmt"
-	"net/smtp"
+	"errors"
+	"net/textproto"
 )
 
+// EmailMessage is a rendered email as queued for the worker; Body is the plain-text
+// part and HTML, when set, its HTML alternative
 type EmailMessage struct {
 	To      string `json:"to"`
 	Subject string `json:"subject"`
 	Body    string `json:"body"`
+	HTML    string `json:"html,omitempty"`
 }
 
-type EmailService interface {
-	SendEmail(to, subject, body string) error
-	SendConfirmationEmail(email, username, token string) error
-}
-
-type emailService struct {
-	queueService EmailQueueService
-}
-
-func NewEmailService(queueService EmailQueueService) EmailService {
-	return &emailService{queueService: queueService}
-}
-
-func (s *emailService) SendEmail(to, subject, body string) error {
-	from := "test@test.com"
-	smtpHost := "mailhog"
-	smtpPort := "1025"
-
-	msg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s", to, subject, body))
-	return smtp.SendMail(smtpHost+":"+smtpPort, nil, from, []string{to}, msg)
+// IsPermanent reports whether retrying cannot succeed: the mail server rejected the
+// message for good (a 5xx reply) or an address is malformed. Failures to open the
+// session and authentication replies (530, 534, 535, 538) are not about the message:
+// they usually come from an outage or a configuration fixed later.
+func IsPermanent(err error) bool {
+	var session *sessionError
+	if errors.As(err, &session) {
+		return false
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 500 && !isAuthReply(reply.Code)
+	}
+	return errors.Is(err, ErrInvalidAddress)
 }
 
-func (s *emailService) SendConfirmationEmail(email, username, token string) error {
-	msg := EmailMessage{
-		To:      email,
-		Subject: "Confirmação de Registro",
-		Body:    fmt.Sprintf("Olá %s,\n\nConfirme seu email:\nhttp://localhost:8080/confirm-email?token=%s", username, token),
+func isAuthReply(code int) bool {
+	switch code {
+	case 530, 534, 535, 538:
+		return true
 	}
-
-	return s.queueService.PublishEmail(msg)
+	return false
 }
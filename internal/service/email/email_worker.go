@@ -0,0 +1,30 @@
+package email
+
+import (
+	"GoVersi/internal/infrastrucuture/queue"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// RunWorker delivers the queued emails through mailer until the queue is closed.
+// Failed deliveries are retried per policy; malformed messages and permanent
+// rejections from the mail server are dead-lettered at once.
+func RunWorker(client queue.RabbitMQClient, mailer Mailer, policy queue.RetryPolicy, prefetch int) error {
+	return client.Work(QueueName, policy, prefetch, func(m queue.Message) error {
+		var msg EmailMessage
+		if err := json.Unmarshal(m.Body, &msg); err != nil {
+			return queue.Permanent(fmt.Errorf("parsing message: %w", err))
+		}
+
+		if err := mailer.Send(msg); err != nil {
+			log.Printf("Error sending email to %s (retry %d): %v", msg.To, m.Retries, err)
+			if IsPermanent(err) {
+				return queue.Permanent(err)
+			}
+			return err
+		}
+		log.Printf("Email sent to %s successfully", msg.To)
+		return nil
+	})
+}
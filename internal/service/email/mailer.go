@@ -0,0 +1,83 @@
+package email
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mailer delivers a rendered email
+type Mailer interface {
+	Send(msg EmailMessage) error
+}
+
+// FileMailer drops every email as an .eml file into a directory, for development;
+// the files open in any mail client
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(msg EmailMessage) error {
+	now := time.Now()
+	data, err := BuildMIME(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), uuid.New())
+	// written under a temporary name so that watchers never see a partial file
+	tmp := filepath.Join(m.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, name))
+}
+
+// ConsoleMailer logs the plain-text version of every email instead of sending it
+type ConsoleMailer struct{}
+
+func NewConsoleMailer() *ConsoleMailer {
+	return &ConsoleMailer{}
+}
+
+func (m *ConsoleMailer) Send(msg EmailMessage) error {
+	log.Printf("Email to %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// MemoryMailer keeps the emails it receives, for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(msg EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the emails received so far, oldest first
+func (m *MemoryMailer) Messages() []EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]EmailMessage(nil), m.messages...)
+}
@@ -0,0 +1,35 @@
+package email
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// one-time links carry their token in the query string
+var tokenParam = regexp.MustCompile(`([?&;]token=)[A-Za-z0-9_\-.~%]+`)
+
+// RedactedToken replaces the tokens of one-time links in archived emails
+const RedactedToken = "REDACTED"
+
+// RedactLinks blanks the tokens of the one-time links in a queued email, so that an
+// archived copy cannot be used to take over an account. It reports whether anything
+// was redacted; the redacted email is only fit for inspection, not for sending.
+func RedactLinks(body []byte) ([]byte, bool) {
+	var msg EmailMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return body, false
+	}
+
+	text := tokenParam.ReplaceAllString(msg.Body, "${1}"+RedactedToken)
+	html := tokenParam.ReplaceAllString(msg.HTML, "${1}"+RedactedToken)
+	if text == msg.Body && html == msg.HTML {
+		return body, false
+	}
+
+	msg.Body, msg.HTML = text, html
+	redacted, err := json.Marshal(msg)
+	if err != nil {
+		return body, false
+	}
+	return redacted, true
+}
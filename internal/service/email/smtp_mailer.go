@@ -0,0 +1,269 @@
+package email
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTP connection security
+const (
+	SMTPSecurityNone     = "none"     // plain connection, for local catchers such as MailHog
+	SMTPSecuritySTARTTLS = "starttls" // upgraded after connecting, usually on port 587
+	SMTPSecurityTLS      = "tls"      // implicit TLS, usually on port 465
+)
+
+// SMTP authentication mechanisms
+const (
+	SMTPAuthPlain = "plain"
+	SMTPAuthLogin = "login"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	From     string
+	Username string // no authentication when empty
+	Password string
+	Security string // none, starttls or tls
+	Auth     string // plain or login
+	CAFile   string // PEM bundle trusted instead of the system roots, for relays with a private CA
+
+	MaxIdleConns int           // connections kept open between emails
+	IdleTimeout  time.Duration // idle connections older than this are closed instead of reused
+	Timeout      time.Duration // dialing, and each email on an open connection
+}
+
+// SMTPMailer sends emails over SMTP, reusing connections between emails
+type SMTPMailer struct {
+	cfg     SMTPConfig
+	rootCAs *x509.CertPool // nil for the system roots
+	idle    chan *smtpConn
+}
+
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	switch cfg.Security {
+	case SMTPSecurityNone, SMTPSecuritySTARTTLS, SMTPSecurityTLS:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security %q", cfg.Security)
+	}
+	switch cfg.Auth {
+	case SMTPAuthPlain, SMTPAuthLogin:
+	default:
+		return nil, fmt.Errorf("unknown SMTP auth %q", cfg.Auth)
+	}
+
+	m := &SMTPMailer{cfg: cfg, idle: make(chan *smtpConn, cfg.MaxIdleConns)}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		m.rootCAs = x509.NewCertPool()
+		if !m.rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+	}
+	return m, nil
+}
+
+// sessionError is a failure to open an SMTP session: dialing, the greeting, STARTTLS
+// or authentication. It says nothing about the email, whatever the reply code.
+type sessionError struct {
+	err error
+}
+
+func (e *sessionError) Error() string { return "smtp session: " + e.err.Error() }
+func (e *sessionError) Unwrap() error { return e.err }
+
+func (m *SMTPMailer) Send(msg EmailMessage) error {
+	data, err := BuildMIME(m.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, err := parseAddress(m.cfg.From)
+	if err != nil {
+		return err
+	}
+	to, err := parseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	c, err := m.get()
+	if err != nil {
+		return &sessionError{err: err}
+	}
+
+	c.conn.SetDeadline(time.Now().Add(m.cfg.Timeout))
+	if err := deliver(c.client, from, to, data); err != nil {
+		// the server refused this email but the session is still usable
+		var reply *textproto.Error
+		if errors.As(err, &reply) && c.client.Reset() == nil {
+			m.put(c)
+		} else {
+			c.client.Close()
+		}
+		return err
+	}
+	m.put(c)
+	return nil
+}
+
+// Close ends the idle connections
+func (m *SMTPMailer) Close() {
+	for {
+		select {
+		case c := <-m.idle:
+			c.client.Quit()
+		default:
+			return
+		}
+	}
+}
+
+func deliver(client *smtp.Client, from, to string, data []byte) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// get takes a live idle connection, or dials a new one
+func (m *SMTPMailer) get() (*smtpConn, error) {
+	for {
+		select {
+		case c := <-m.idle:
+			c.conn.SetDeadline(time.Now().Add(m.cfg.Timeout))
+			if time.Since(c.lastUsed) < m.cfg.IdleTimeout && c.client.Reset() == nil {
+				return c, nil
+			}
+			c.client.Close()
+		default:
+			return m.dial()
+		}
+	}
+}
+
+// put keeps the connection for the next email, or closes it when enough are idle
+func (m *SMTPMailer) put(c *smtpConn) {
+	c.lastUsed = time.Now()
+	c.conn.SetDeadline(time.Time{})
+	select {
+	case m.idle <- c:
+	default:
+		c.client.Quit()
+	}
+}
+
+func (m *SMTPMailer) dial() (*smtpConn, error) {
+	addr := net.JoinHostPort(m.cfg.Host, fmt.Sprint(m.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host, RootCAs: m.rootCAs, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: m.cfg.Timeout}
+
+	var conn net.Conn
+	var err error
+	if m.cfg.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(m.cfg.Timeout))
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.cfg.Security == SMTPSecuritySTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if m.cfg.Username != "" {
+		if err := client.Auth(m.auth()); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+func (m *SMTPMailer) auth() smtp.Auth {
+	if m.cfg.Auth == SMTPAuthLogin {
+		return &loginAuth{username: m.cfg.Username, password: m.cfg.Password, host: m.cfg.Host}
+	}
+	return smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp lacks but some servers
+// (older Exchange, a few hosted relays) still require
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// like PlainAuth, never send credentials in clear text to a remote server
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+// Next answers the challenges, which servers word differently: "Username:",
+// "username:", "User Name\x00"...
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	challenge := strings.ToLower(strings.TrimRight(strings.TrimSpace(string(fromServer)), ":\x00"))
+	switch strings.ReplaceAll(challenge, " ", "") {
+	case "username":
+		return []byte(a.username), nil
+	case "password":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,231 @@
+package email
+
+import (
+	"GoVersi/internal/models"
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// names of the templates every locale provides, as <name>.txt and <name>.html
+const (
+	templateConfirmation      = "confirmation"
+	templatePasswordReset     = "password_reset"
+	templateDeletionNotice    = "deletion_notice"
+	templateEmailChangeNotice = "email_change_notice"
+	templateDigest            = "digest"
+)
+
+var templateNames = []string{templateConfirmation, templatePasswordReset, templateDeletionNotice, templateEmailChangeNotice, templateDigest}
+
+// the .txt file defines "subject" and "body"; the .html file defines "content",
+// rendered inside the shared layout
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// localeFormat renders dates and durations the way a locale writes them
+type localeFormat struct {
+	date    string
+	hours   func(n int) string
+	minutes func(n int) string
+}
+
+var localeFormats = map[string]localeFormat{
+	"en": {
+		date:    "January 2, 2006",
+		hours:   func(n int) string { return plural(n, "hour", "hours") },
+		minutes: func(n int) string { return plural(n, "minute", "minutes") },
+	},
+	"pt-BR": {
+		date:    "02/01/2006",
+		hours:   func(n int) string { return plural(n, "hora", "horas") },
+		minutes: func(n int) string { return plural(n, "minuto", "minutos") },
+	},
+}
+
+// Templates renders the transactional emails in the locale of their recipient
+type Templates struct {
+	baseURL       string
+	defaultLocale string
+	locales       map[string]map[string]emailTemplate
+}
+
+// NewTemplates parses the embedded templates; every locale must provide all of them.
+// Links in emails are built on baseURL.
+func NewTemplates(baseURL, defaultLocale string) (*Templates, error) {
+	entries, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		defaultLocale: defaultLocale,
+		locales:       make(map[string]map[string]emailTemplate),
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		if _, ok := localeFormats[locale]; !ok {
+			return nil, fmt.Errorf("email templates: no date format for locale %s", locale)
+		}
+
+		t.locales[locale] = make(map[string]emailTemplate)
+		for _, name := range templateNames {
+			dir := path.Join("templates", locale)
+			text, err := texttemplate.ParseFS(templateFS, path.Join(dir, name+".txt"))
+			if err != nil {
+				return nil, fmt.Errorf("email templates: %w", err)
+			}
+			html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", path.Join(dir, name+".html"))
+			if err != nil {
+				return nil, fmt.Errorf("email templates: %w", err)
+			}
+			t.locales[locale][name] = emailTemplate{text: text, html: html}
+		}
+	}
+
+	if _, ok := t.locales[defaultLocale]; !ok {
+		return nil, fmt.Errorf("email templates: no templates for default locale %s", defaultLocale)
+	}
+	return t, nil
+}
+
+// ResolveLocale picks the supported locale closest to tag (e.g. "pt", "pt_br" or
+// "pt-PT" give pt-BR), falling back to the default locale
+func (t *Templates) ResolveLocale(tag string) string {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return t.defaultLocale
+	}
+
+	for locale := range t.locales {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+	}
+
+	language, _, _ := strings.Cut(tag, "-")
+	if base, _, _ := strings.Cut(t.defaultLocale, "-"); strings.EqualFold(base, language) {
+		return t.defaultLocale
+	}
+	for locale := range t.locales {
+		if base, _, _ := strings.Cut(locale, "-"); strings.EqualFold(base, language) {
+			return locale
+		}
+	}
+	return t.defaultLocale
+}
+
+// Confirmation asks a new user to confirm their address
+func (t *Templates) Confirmation(user *models.User, token string) (EmailMessage, error) {
+	return t.render(templateConfirmation, user, map[string]any{
+		"Link": t.link("/confirm-email", token),
+	})
+}
+
+// PasswordReset carries a reset link valid for validFor
+func (t *Templates) PasswordReset(user *models.User, token string, validFor time.Duration) (EmailMessage, error) {
+	format := localeFormats[t.ResolveLocale(user.Locale)]
+	return t.render(templatePasswordReset, user, map[string]any{
+		"Link":     t.link("/reset-password", token),
+		"ValidFor": format.duration(validFor),
+	})
+}
+
+// DeletionNotice tells a user that the deletion of their account was requested
+func (t *Templates) DeletionNotice(user *models.User, requestedAt time.Time) (EmailMessage, error) {
+	format := localeFormats[t.ResolveLocale(user.Locale)]
+	return t.render(templateDeletionNotice, user, map[string]any{
+		"RequestedAt": requestedAt.Format(format.date),
+	})
+}
+
+// EmailChangeNotice warns the current address of a user that a change to newEmail was requested
+func (t *Templates) EmailChangeNotice(user *models.User, newEmail string, requestedAt time.Time) (EmailMessage, error) {
+	format := localeFormats[t.ResolveLocale(user.Locale)]
+	return t.render(templateEmailChangeNotice, user, map[string]any{
+		"NewEmail":    newEmail,
+		"RequestedAt": requestedAt.Format(format.date),
+	})
+}
+
+// DigestCounts sums the unread activity of a digest period by notification type
+type DigestCounts struct {
+	Comments        int
+	Replies         int
+	Reactions       int
+	FriendRequests  int
+	FriendsAccepted int
+}
+
+// Digest summarizes the unread activity of a user
+func (t *Templates) Digest(user *models.User, counts DigestCounts) (EmailMessage, error) {
+	return t.render(templateDigest, user, map[string]any{
+		"Comments":        counts.Comments,
+		"Replies":         counts.Replies,
+		"Reactions":       counts.Reactions,
+		"FriendRequests":  counts.FriendRequests,
+		"FriendsAccepted": counts.FriendsAccepted,
+	})
+}
+
+func (t *Templates) render(name string, user *models.User, data map[string]any) (EmailMessage, error) {
+	locale := t.ResolveLocale(user.Locale)
+	tmpl := t.locales[locale][name]
+
+	data["Username"] = user.Username
+	data["BaseURL"] = t.baseURL
+	data["Locale"] = locale
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return EmailMessage{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return EmailMessage{}, err
+	}
+
+	return EmailMessage{
+		To:      user.Email,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+func (t *Templates) link(route, token string) string {
+	return t.baseURL + route + "?token=" + url.QueryEscape(token)
+}
+
+// duration rounds to whole hours when possible, minutes otherwise
+func (f localeFormat) duration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return f.hours(int(d / time.Hour))
+	}
+	return f.minutes(int(d.Round(time.Minute) / time.Minute))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/service/email"
+	"GoVersi/internal/utils"
+	"time"
+)
+
+// EmailVerifier issues confirmation links and decides whether an unverified
+// address may still sign in
+type EmailVerifier struct {
+	mails *email.Templates
+	cfg   config.AccountConfig
+}
+
+func NewEmailVerifier(mails *email.Templates, cfg config.AccountConfig) *EmailVerifier {
+	return &EmailVerifier{mails: mails, cfg: cfg}
+}
+
+// Issue stores a new verification token of user for address and queues the
+// confirmation email in the same transaction
+func (v *EmailVerifier) Issue(tx *repository.Tx, user *models.User, address string) error {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	recipient := *user
+	recipient.Email = address
+	msg, err := v.mails.Confirmation(&recipient, token)
+	if err != nil {
+		return err
+	}
+
+	err = tx.EmailVerifications().Create(&models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     address,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(v.cfg.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+	return email.NewEmailQueueService(tx.Outbox()).PublishEmail(msg)
+}
+
+// CanLogin rejects unverified users when REQUIRE_VERIFIED_EMAIL_TO_LOGIN is set
+func (v *EmailVerifier) CanLogin(user *models.User) error {
+	if v.cfg.RequireVerifiedEmailToLogin && !user.IsEmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
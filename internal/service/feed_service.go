@@ -0,0 +1,172 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+type AuthorSummary struct {
+	ID           uuid.UUID       `json:"id"`
+	Username     string          `json:"username"`
+	ImageProfile models.MediaKey `json:"image_url"`
+}
+
+type FeedItem struct {
+	models.Post
+	Author         *AuthorSummary      `json:"author"`
+	ReactionCount  int64               `json:"reaction_count"`
+	CommentCount   int64               `json:"comment_count"`
+	ViewerReaction models.ReactionType `json:"viewer_reaction,omitempty"`
+}
+
+type FeedPage struct {
+	Items      []FeedItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type FeedService struct {
+	postRepo       *repository.PostRepository
+	friendshipRepo *repository.FriendshipRepository
+	userRepo       repository.UserRepository
+	reactionRepo   *repository.ReactionRepository
+	commentRepo    *repository.CommentRepository
+}
+
+func NewFeedService(postRepo *repository.PostRepository, friendshipRepo *repository.FriendshipRepository, userRepo repository.UserRepository, reactionRepo *repository.ReactionRepository, commentRepo *repository.CommentRepository) *FeedService {
+	return &FeedService{
+		postRepo:       postRepo,
+		friendshipRepo: friendshipRepo,
+		userRepo:       userRepo,
+		reactionRepo:   reactionRepo,
+		commentRepo:    commentRepo,
+	}
+}
+
+// GetHomeFeed returns posts from the user and their accepted friends, newest first
+func (s *FeedService) GetHomeFeed(userID uuid.UUID, cursor string, limit int) (*FeedPage, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	friendships, err := s.friendshipRepo.GetFriendsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	authorIDs := []uuid.UUID{userID}
+	for _, f := range friendships {
+		if f.RequesterID == userID {
+			authorIDs = append(authorIDs, f.AddresseeID)
+		} else {
+			authorIDs = append(authorIDs, f.RequesterID)
+		}
+	}
+
+	// fetch one extra post to know whether there is a next page
+	posts, err := s.postRepo.FindByAuthors(authorIDs, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &FeedPage{Items: []FeedItem{}}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	if len(posts) == 0 {
+		return page, nil
+	}
+
+	items, err := s.enrich(userID, posts)
+	if err != nil {
+		return nil, err
+	}
+	page.Items = items
+	return page, nil
+}
+
+// GetTopicFeed returns the posts of a topic, newest first
+func (s *FeedService) GetTopicFeed(viewerID uuid.UUID, slug string, cursor string, limit int) (*FeedPage, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := s.postRepo.FindByTopic(slug, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &FeedPage{Items: []FeedItem{}}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	if len(posts) == 0 {
+		return page, nil
+	}
+
+	items, err := s.enrich(viewerID, posts)
+	if err != nil {
+		return nil, err
+	}
+	page.Items = items
+	return page, nil
+}
+
+// enrich loads authors and counters for the whole page at once
+func (s *FeedService) enrich(viewerID uuid.UUID, posts []models.Post) ([]FeedItem, error) {
+	postIDs := make([]uuid.UUID, len(posts))
+	authorSet := make(map[uuid.UUID]struct{})
+	for i, p := range posts {
+		postIDs[i] = p.ID
+		authorSet[p.AuthorID] = struct{}{}
+	}
+
+	authorIDs := make([]uuid.UUID, 0, len(authorSet))
+	for id := range authorSet {
+		authorIDs = append(authorIDs, id)
+	}
+
+	users, err := s.userRepo.FindByIDs(authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[uuid.UUID]*AuthorSummary, len(users))
+	for _, u := range users {
+		authors[u.ID] = &AuthorSummary{ID: u.ID, Username: u.Username, ImageProfile: u.ImageProfile}
+	}
+
+	reactionCounts, err := s.reactionRepo.CountByTargets(models.ReactionTargetPost, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	commentCounts, err := s.commentRepo.CountByPostIDs(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	viewerReactions, err := s.reactionRepo.FindUserReactions(viewerID, models.ReactionTargetPost, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]FeedItem, len(posts))
+	for i, p := range posts {
+		items[i] = FeedItem{
+			Post:           p,
+			Author:         authors[p.AuthorID],
+			ReactionCount:  reactionCounts[p.ID],
+			CommentCount:   commentCounts[p.ID],
+			ViewerReaction: viewerReactions[p.ID],
+		}
+	}
+	return items, nil
+}
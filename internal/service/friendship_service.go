@@ -2,6 +2,7 @@ package services
 
 import (
 	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
 	"GoVersi/internal/repository"
 	"errors"
 
@@ -9,48 +10,100 @@ import (
 )
 
 type FriendshipService struct {
-	repo *repository.FriendshipRepository
+	repo     *repository.FriendshipRepository
+	notifier NotificationPublisher
+	hub      realtime.Hub
 }
 
-func NewFriendshipService(repo *repository.FriendshipRepository) *FriendshipService {
-	return &FriendshipService{repo: repo}
+func NewFriendshipService(repo *repository.FriendshipRepository, notifier NotificationPublisher, hub realtime.Hub) *FriendshipService {
+	return &FriendshipService{repo: repo, notifier: notifier, hub: hub}
+}
+
+// publishChange pushes the new state of a friendship to both users
+func (s *FriendshipService) publishChange(friendship *models.Friendship) {
+	s.hub.Publish(friendship.RequesterID, "friendship", friendship)
+	s.hub.Publish(friendship.AddresseeID, "friendship", friendship)
 }
 
 func (s *FriendshipService) SendFriendRequest(requesterID, addresseeID uuid.UUID) error {
+	if requesterID == addresseeID {
+		return errors.New("cannot send a friend request to yourself")
+	}
+
 	existingFriendship, err := s.repo.GetFriendshipBetweenUsers(requesterID, addresseeID)
 	if err == nil && existingFriendship != nil {
 		return errors.New("friend request already exists or users are already friends")
 	}
 
-	return s.repo.SendFriendRequest(requesterID, addresseeID)
+	friendship, err := s.repo.SendFriendRequest(requesterID, addresseeID)
+	if err != nil {
+		return err
+	}
+
+	s.notifier.Publish(NotificationEvent{
+		RecipientID: addresseeID,
+		ActorID:     requesterID,
+		Type:        models.NotificationFriendRequest,
+		TargetType:  "friendship",
+		TargetID:    friendship.ID,
+	})
+	s.publishChange(friendship)
+	return nil
 }
 
-func (s *FriendshipService) AcceptFriendRequest(id uuid.UUID) error {
+// AcceptFriendRequest accepts a pending request; only its addressee may do it
+func (s *FriendshipService) AcceptFriendRequest(actorID, id uuid.UUID) error {
 	friendship, err := s.repo.FindByID(id)
 	if err != nil {
 		return err
 	}
 
+	if friendship.AddresseeID != actorID {
+		return ErrForbidden
+	}
+
 	if friendship.Status != models.StatusPending {
 		return errors.New("only pending requests can be accepted")
 	}
 
 	friendship.Status = models.StatusAccepted
-	return s.repo.Update(friendship)
+	if err := s.repo.Update(friendship); err != nil {
+		return err
+	}
+
+	s.notifier.Publish(NotificationEvent{
+		RecipientID: friendship.RequesterID,
+		ActorID:     actorID,
+		Type:        models.NotificationFriendAccepted,
+		TargetType:  "friendship",
+		TargetID:    friendship.ID,
+	})
+	s.publishChange(friendship)
+	return nil
 }
 
-func (s *FriendshipService) DeclineFriendRequest(id uuid.UUID) error {
+// DeclineFriendRequest declines a pending request; only its addressee may do it
+func (s *FriendshipService) DeclineFriendRequest(actorID, id uuid.UUID) error {
 	friendship, err := s.repo.FindByID(id)
 	if err != nil {
 		return err
 	}
 
+	if friendship.AddresseeID != actorID {
+		return ErrForbidden
+	}
+
 	if friendship.Status != models.StatusPending {
 		return errors.New("only pending requests can be declined")
 	}
 
 	friendship.Status = models.StatusDeclined
-	return s.repo.Update(friendship)
+	if err := s.repo.Update(friendship); err != nil {
+		return err
+	}
+
+	s.publishChange(friendship)
+	return nil
 }
 
 func (s *FriendshipService) GetFriendsForUser(userID uuid.UUID) ([]models.Friendship, error) {
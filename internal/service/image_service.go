@@ -0,0 +1,139 @@
+package services
+
+import (
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/storage"
+	"bytes"
+	"io"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ImageEnqueuer hands freshly uploaded images to the processing pipeline
+type ImageEnqueuer interface {
+	Enqueue(job media.ImageJob) error
+}
+
+// enqueueImage schedules processing of an uploaded image; failures only cost the renditions
+func enqueueImage(images ImageEnqueuer, target string, id uuid.UUID, key models.MediaKey) {
+	if key == "" {
+		return
+	}
+	if err := images.Enqueue(media.ImageJob{Target: target, ID: id, Key: string(key)}); err != nil {
+		log.Printf("Failed to enqueue image %s of %s %s: %v", key, target, id, err)
+	}
+}
+
+// ImageService runs the image pipeline: it strips metadata from the original,
+// stores the renditions and records them on the owning post, comment or user
+type ImageService struct {
+	store     storage.BlobStore
+	repo      *repository.ImageRepository
+	processor *media.Processor
+	refs      MediaRefs
+}
+
+func NewImageService(store storage.BlobStore, repo *repository.ImageRepository, processor *media.Processor, refs MediaRefs) *ImageService {
+	return &ImageService{store: store, repo: repo, processor: processor, refs: refs}
+}
+
+// Process runs the pipeline for one image. The owner keeps its processing status
+// on failure so that the job can be retried; see MarkFailed.
+func (s *ImageService) Process(job media.ImageJob) error {
+	key := storage.NormalizeKey(job.Key)
+
+	data, err := s.read(key)
+	if err != nil {
+		return err
+	}
+
+	processed, err := s.processor.Process(data)
+	if err != nil {
+		return err
+	}
+
+	// new files sit next to the original: images/x.jpg -> images/x_clean.jpg, images/x_thumb.jpg...
+	base := strings.TrimSuffix(key, path.Ext(key))
+	written := []string{}
+
+	cleanKey := key
+	if processed.Original != nil {
+		cleanKey = base + "_clean" + processed.OriginalExtension
+		if err := s.put(cleanKey, processed.Original, processed.OriginalExtension); err != nil {
+			return err
+		}
+		written = append(written, cleanKey)
+	}
+
+	renditions := make(models.Renditions, len(processed.Renditions))
+	for name, encoded := range processed.Renditions {
+		renditionKey := base + "_" + name + ".jpg"
+		if err := s.put(renditionKey, encoded, ".jpg"); err != nil {
+			s.deleteAll(written)
+			return err
+		}
+		written = append(written, renditionKey)
+		renditions[name] = models.MediaKey(renditionKey)
+	}
+
+	// the owner takes over the new files in place of the original, which is deleted below
+	if err := s.refs.Retain(written); err != nil {
+		s.deleteAll(written)
+		return err
+	}
+	updated, err := s.repo.SetProcessedImage(job.Target, job.ID, models.MediaKey(job.Key), models.MediaKey(cleanKey), renditions, processed.Width, processed.Height)
+	if err != nil {
+		s.deleteAll(written)
+		return err
+	}
+	if !updated {
+		// the record is gone or its image was replaced in the meantime
+		s.deleteAll(written)
+		return nil
+	}
+
+	if cleanKey != key {
+		if err := s.store.Delete(key); err != nil {
+			log.Printf("Failed to delete unprocessed image %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// MarkFailed records on the owner that its image will not be processed, when it
+// still has a processing status
+func (s *ImageService) MarkFailed(job media.ImageJob) {
+	if err := s.repo.SetImageFailed(job.Target, job.ID, models.MediaKey(job.Key)); err != nil {
+		log.Printf("Failed to record failed processing of %s: %v", job.Key, err)
+	}
+}
+
+func (s *ImageService) read(key string) ([]byte, error) {
+	rc, err := s.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (s *ImageService) put(key string, data []byte, ext string) error {
+	contentType := "image/jpeg"
+	if ext == ".png" {
+		contentType = "image/png"
+	}
+	return s.store.Put(key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+func (s *ImageService) deleteAll(keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(key); err != nil {
+			log.Printf("Failed to delete %s: %v", key, err)
+		}
+	}
+}
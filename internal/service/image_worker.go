@@ -0,0 +1,39 @@
+package services
+
+import (
+	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/media"
+	"GoVersi/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// RunImageWorker processes the queued image jobs until the queue is closed.
+// Failed jobs are retried per policy; malformed jobs, missing uploads and files
+// that are not images are dead-lettered at once. The owner of the image is
+// marked failed once its job is dead-lettered.
+func RunImageWorker(client queue.RabbitMQClient, images *ImageService, policy queue.RetryPolicy, prefetch int) error {
+	return client.Work(media.ImageProcessingQueue, policy, prefetch, func(m queue.Message) error {
+		var job media.ImageJob
+		if err := json.Unmarshal(m.Body, &job); err != nil {
+			return queue.Permanent(fmt.Errorf("parsing image job: %w", err))
+		}
+
+		err := images.Process(job)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Error processing image %s (retry %d): %v", job.Key, m.Retries, err)
+
+		permanent := errors.Is(err, media.ErrUndecodableImage) || errors.Is(err, storage.ErrNotFound)
+		if permanent || m.Retries >= policy.MaxRetries {
+			images.MarkFailed(job)
+		}
+		if permanent {
+			return queue.Permanent(err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/storage"
+	"log"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// MediaRefs counts the records using each stored file. Owners retain their files
+// before they are saved and release them once deleted, so a failure in between
+// can only leave a file around longer, never remove one still in use.
+type MediaRefs interface {
+	Retain(keys []string) error
+	Release(keys []string) error
+}
+
+// imageKeys lists an image and its renditions
+func imageKeys(key models.MediaKey, renditions models.Renditions) []models.MediaKey {
+	keys := []models.MediaKey{key}
+	for _, rendition := range renditions {
+		keys = append(keys, rendition)
+	}
+	return keys
+}
+
+// attachmentKeys lists the files of post attachments
+func attachmentKeys(attachments []models.PostMedia) []models.MediaKey {
+	var keys []models.MediaKey
+	for _, m := range attachments {
+		keys = append(keys, imageKeys(m.URL, m.Renditions)...)
+	}
+	return keys
+}
+
+func storageKeys(keys []models.MediaKey) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			normalized = append(normalized, storage.NormalizeKey(string(key)))
+		}
+	}
+	return normalized
+}
+
+func retainMedia(refs MediaRefs, keys ...models.MediaKey) error {
+	normalized := storageKeys(keys)
+	if len(normalized) == 0 {
+		return nil
+	}
+	return refs.Retain(normalized)
+}
+
+// releaseMedia drops references; failures only delay the cleanup of the files
+func releaseMedia(refs MediaRefs, keys ...models.MediaKey) {
+	normalized := storageKeys(keys)
+	if len(normalized) == 0 {
+		return
+	}
+	if err := refs.Release(normalized); err != nil {
+		log.Printf("Failed to release media %v: %v", normalized, err)
+	}
+}
+
+// SweepReport tells what a sweep removed
+type SweepReport struct {
+	Files int
+	Bytes int64
+}
+
+// MediaService deletes stored files that stayed unreferenced for longer than the grace period
+type MediaService struct {
+	repo  *repository.MediaObjectRepository
+	store storage.BlobStore
+	grace time.Duration
+	cron  *cron.Cron
+}
+
+func NewMediaService(repo *repository.MediaObjectRepository, store storage.BlobStore, grace time.Duration) *MediaService {
+	return &MediaService{repo: repo, store: store, grace: grace}
+}
+
+const sweepBatchSize = 500
+
+// Sweep deletes the files unreferenced for longer than the grace period
+func (s *MediaService) Sweep() (SweepReport, error) {
+	var report SweepReport
+	cutoff := time.Now().Add(-s.grace)
+
+	for {
+		objects, err := s.repo.FindUnreferenced(cutoff, sweepBatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, object := range objects {
+			// a file retained since it was listed is skipped
+			claimed, err := s.repo.ClaimUnreferenced(object.Key, cutoff)
+			if err != nil {
+				return report, err
+			}
+			if !claimed {
+				continue
+			}
+
+			if err := s.store.Delete(object.Key); err != nil {
+				log.Printf("Failed to delete unreferenced file %s: %v", object.Key, err)
+				// track it again, with its size, so a later sweep retries
+				if err := s.repo.Register(object.Key, object.Size, object.ContentType); err != nil {
+					log.Printf("Failed to track %s again: %v", object.Key, err)
+				}
+				continue
+			}
+			report.Files++
+			report.Bytes += object.Size
+		}
+
+		if len(objects) < sweepBatchSize {
+			return report, nil
+		}
+	}
+}
+
+// StartCronJob schedules the sweep of unreferenced files
+func (s *MediaService) StartCronJob() {
+	s.cron = cron.New()
+	s.cron.AddFunc("@hourly", func() {
+		report, err := s.Sweep()
+		if err != nil {
+			log.Printf("Media sweep failed after removing %d files: %v", report.Files, err)
+			return
+		}
+		log.Printf("Media sweep removed %d unreferenced files, reclaimed %d bytes", report.Files, report.Bytes)
+	})
+	s.cron.Start()
+}
+
+func (s *MediaService) StopCronJob() {
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotFriends = errors.New("messages can only be exchanged between friends")
+
+// ConversationView is a conversation as seen by one of its participants
+type ConversationView struct {
+	models.Conversation
+	Participant *AuthorSummary  `json:"participant"`
+	UnreadCount int             `json:"unread_count"`
+	LastMessage *models.Message `json:"last_message,omitempty"`
+}
+
+type ConversationPage struct {
+	Items      []ConversationView `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type MessagePage struct {
+	Items      []models.Message `json:"items"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type MessageService struct {
+	repo           *repository.ConversationRepository
+	friendshipRepo *repository.FriendshipRepository
+	userRepo       repository.UserRepository
+	hub            realtime.Hub
+	refs           MediaRefs
+}
+
+func NewMessageService(repo *repository.ConversationRepository, friendshipRepo *repository.FriendshipRepository, userRepo repository.UserRepository, hub realtime.Hub, refs MediaRefs) *MessageService {
+	return &MessageService{repo: repo, friendshipRepo: friendshipRepo, userRepo: userRepo, hub: hub, refs: refs}
+}
+
+// StartConversation returns the conversation with a friend, creating it on first use
+func (s *MessageService) StartConversation(userID, friendID uuid.UUID) (*models.Conversation, error) {
+	if err := s.requireFriendship(userID, friendID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindOrCreate(userID, friendID)
+}
+
+func (s *MessageService) ListConversations(userID uuid.UUID, cursor string, limit int) (*ConversationPage, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, err := s.repo.FindByUser(userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ConversationPage{Items: []ConversationView{}}
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+		last := conversations[len(conversations)-1]
+		page.NextCursor = utils.EncodeCursor(last.LastActivityAt, last.ID)
+	}
+	if len(conversations) == 0 {
+		return page, nil
+	}
+
+	ids := make([]uuid.UUID, len(conversations))
+	otherIDs := make([]uuid.UUID, len(conversations))
+	for i, conv := range conversations {
+		ids[i] = conv.ID
+		otherIDs[i] = otherParticipant(conv, userID)
+	}
+
+	memberships, err := s.repo.FindMemberships(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	lastMessages, err := s.repo.FindLastMessages(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepo.FindByIDs(otherIDs)
+	if err != nil {
+		return nil, err
+	}
+	participants := make(map[uuid.UUID]*AuthorSummary, len(users))
+	for _, u := range users {
+		participants[u.ID] = &AuthorSummary{ID: u.ID, Username: u.Username, ImageProfile: u.ImageProfile}
+	}
+
+	for _, conv := range conversations {
+		view := ConversationView{
+			Conversation: conv,
+			Participant:  participants[otherParticipant(conv, userID)],
+			UnreadCount:  memberships[conv.ID].UnreadCount,
+		}
+		if msg, ok := lastMessages[conv.ID]; ok {
+			view.LastMessage = &msg
+		}
+		page.Items = append(page.Items, view)
+	}
+	return page, nil
+}
+
+// GetMessages pages through a conversation, newest first
+func (s *MessageService) GetMessages(userID, conversationID uuid.UUID, cursor string, limit int) (*MessagePage, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.conversationFor(userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	messages, err := s.repo.FindMessages(conversationID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &MessagePage{Items: messages}
+	if len(messages) > limit {
+		page.Items = messages[:limit]
+		last := page.Items[limit-1]
+		page.NextCursor = utils.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	if page.Items == nil {
+		page.Items = []models.Message{}
+	}
+	return page, nil
+}
+
+// CanSend checks that the user may write in the conversation, so that uploads can be refused early
+func (s *MessageService) CanSend(userID, conversationID uuid.UUID) error {
+	_, _, err := s.recipientFor(userID, conversationID)
+	return err
+}
+
+// SendMessage posts text and/or an image; the participants must still be friends
+func (s *MessageService) SendMessage(userID, conversationID uuid.UUID, content string, image models.MediaKey) (*models.Message, error) {
+	content = strings.TrimSpace(content)
+	if content == "" && image == "" {
+		return nil, errors.New("message must have content or an image")
+	}
+
+	conversation, recipientID, err := s.recipientFor(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &models.Message{
+		ID:             uuid.New(),
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		Content:        content,
+		ImageURL:       image,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := retainMedia(s.refs, message.ImageURL); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateMessage(message, recipientID); err != nil {
+		releaseMedia(s.refs, message.ImageURL)
+		return nil, err
+	}
+
+	s.hub.Publish(recipientID, "message", message)
+	return message, nil
+}
+
+func (s *MessageService) MarkRead(userID, conversationID uuid.UUID) error {
+	conversation, err := s.conversationFor(userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.MarkRead(conversationID, userID); err != nil {
+		return err
+	}
+
+	s.hub.Publish(otherParticipant(*conversation, userID), "conversation.read", map[string]uuid.UUID{
+		"conversation_id": conversationID,
+		"reader_id":       userID,
+	})
+	return nil
+}
+
+// conversationFor loads a conversation the user takes part in
+func (s *MessageService) conversationFor(userID, conversationID uuid.UUID) (*models.Conversation, error) {
+	conversation, err := s.repo.FindByID(conversationID)
+	if err != nil {
+		return nil, errors.New("conversation not found")
+	}
+	if conversation.UserAID != userID && conversation.UserBID != userID {
+		return nil, ErrForbidden
+	}
+	return conversation, nil
+}
+
+// recipientFor loads a conversation of the user and the other participant, who must still be a friend
+func (s *MessageService) recipientFor(userID, conversationID uuid.UUID) (*models.Conversation, uuid.UUID, error) {
+	conversation, err := s.conversationFor(userID, conversationID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	recipientID := otherParticipant(*conversation, userID)
+	if err := s.requireFriendship(userID, recipientID); err != nil {
+		return nil, uuid.Nil, err
+	}
+	return conversation, recipientID, nil
+}
+
+func (s *MessageService) requireFriendship(userID, otherID uuid.UUID) error {
+	if userID == otherID {
+		return ErrNotFriends
+	}
+	friendship, err := s.friendshipRepo.GetFriendshipBetweenUsers(userID, otherID)
+	if err != nil || friendship.Status != models.StatusAccepted {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+func otherParticipant(conversation models.Conversation, userID uuid.UUID) uuid.UUID {
+	if conversation.UserAID == userID {
+		return conversation.UserBID
+	}
+	return conversation.UserAID
+}
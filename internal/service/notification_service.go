@@ -0,0 +1,172 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/utils"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// NotificationEvent is published by the domain services when something happens to a user's content
+type NotificationEvent struct {
+	RecipientID uuid.UUID
+	ActorID     uuid.UUID
+	Type        models.NotificationType
+	TargetType  string
+	TargetID    uuid.UUID
+}
+
+// NotificationPublisher is the dependency other services use to emit events
+type NotificationPublisher interface {
+	Publish(event NotificationEvent)
+}
+
+// NotificationView is a notification ready to be displayed
+type NotificationView struct {
+	models.Notification
+	Actor   *AuthorSummary `json:"actor"`
+	Message string         `json:"message"`
+}
+
+type NotificationPage struct {
+	Items      []NotificationView `json:"items"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type NotificationService struct {
+	repo     *repository.NotificationRepository
+	userRepo repository.UserRepository
+	hub      realtime.Hub
+}
+
+func NewNotificationService(repo *repository.NotificationRepository, userRepo repository.UserRepository, hub realtime.Hub) *NotificationService {
+	return &NotificationService{repo: repo, userRepo: userRepo, hub: hub}
+}
+
+// Publish records the event; failures are logged so they never break the action that caused them
+func (s *NotificationService) Publish(event NotificationEvent) {
+	if event.RecipientID == event.ActorID || event.RecipientID == uuid.Nil {
+		return
+	}
+
+	notification, err := s.repo.RecordGrouped(&models.Notification{
+		RecipientID: event.RecipientID,
+		Type:        event.Type,
+		TargetType:  event.TargetType,
+		TargetID:    event.TargetID,
+		LastActorID: event.ActorID,
+	})
+	if err != nil {
+		log.Printf("Failed to record %s notification for %s: %v", event.Type, event.RecipientID, err)
+		return
+	}
+
+	var actor *AuthorSummary
+	if user, err := s.userRepo.FindByID(event.ActorID); err == nil {
+		actor = &AuthorSummary{ID: user.ID, Username: user.Username, ImageProfile: user.ImageProfile}
+	}
+	s.hub.Publish(event.RecipientID, "notification", NotificationView{
+		Notification: *notification,
+		Actor:        actor,
+		Message:      notificationMessage(*notification, actor),
+	})
+}
+
+func (s *NotificationService) ListNotifications(userID uuid.UUID, cursor string, limit int) (*NotificationPage, error) {
+	after, err := utils.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := s.repo.FindByRecipient(userID, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &NotificationPage{Items: []NotificationView{}}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[len(notifications)-1]
+		page.NextCursor = utils.EncodeCursor(last.UpdatedAt, last.ID)
+	}
+	if len(notifications) == 0 {
+		return page, nil
+	}
+
+	actorIDs := make([]uuid.UUID, len(notifications))
+	for i, n := range notifications {
+		actorIDs[i] = n.LastActorID
+	}
+	users, err := s.userRepo.FindByIDs(actorIDs)
+	if err != nil {
+		return nil, err
+	}
+	actors := make(map[uuid.UUID]*AuthorSummary, len(users))
+	for _, u := range users {
+		actors[u.ID] = &AuthorSummary{ID: u.ID, Username: u.Username, ImageProfile: u.ImageProfile}
+	}
+
+	for _, n := range notifications {
+		actor := actors[n.LastActorID]
+		page.Items = append(page.Items, NotificationView{
+			Notification: n,
+			Actor:        actor,
+			Message:      notificationMessage(n, actor),
+		})
+	}
+	return page, nil
+}
+
+func (s *NotificationService) UnreadCount(userID uuid.UUID) (int64, error) {
+	return s.repo.CountUnread(userID)
+}
+
+func (s *NotificationService) MarkAsRead(userID, notificationID uuid.UUID) error {
+	found, err := s.repo.MarkRead(userID, notificationID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+// MarkManyAsRead marks the given notifications as read, or every unread one when ids is empty
+func (s *NotificationService) MarkManyAsRead(userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	return s.repo.MarkManyRead(userID, ids)
+}
+
+func notificationMessage(n models.Notification, actor *AuthorSummary) string {
+	who := "Someone"
+	if actor != nil {
+		who = actor.Username
+	}
+	if n.ActorCount > 1 {
+		others := "others"
+		if n.ActorCount == 2 {
+			others = "other"
+		}
+		who = fmt.Sprintf("%s and %d %s", who, n.ActorCount-1, others)
+	}
+
+	switch n.Type {
+	case models.NotificationComment:
+		return who + " commented on your post"
+	case models.NotificationReply:
+		return who + " replied to your comment"
+	case models.NotificationReaction:
+		return who + " reacted to your " + n.TargetType
+	case models.NotificationFriendRequest:
+		return who + " sent you a friend request"
+	case models.NotificationFriendAccepted:
+		return who + " accepted your friend request"
+	default:
+		return who + " interacted with you"
+	}
+}
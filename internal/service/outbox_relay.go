@@ -0,0 +1,129 @@
+package services
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"log"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// OutboxRelay publishes committed outbox events to the queue. Failed publications
+// are retried with exponential backoff until the configured number of attempts.
+type OutboxRelay struct {
+	repo      *repository.OutboxRepository
+	publisher queue.Publisher
+	cfg       config.OutboxConfig
+
+	stop chan struct{}
+	done chan struct{}
+	cron *cron.Cron
+}
+
+func NewOutboxRelay(repo *repository.OutboxRepository, publisher queue.Publisher, cfg config.OutboxConfig) *OutboxRelay {
+	return &OutboxRelay{repo: repo, publisher: publisher, cfg: cfg}
+}
+
+// RelayBatch publishes up to one batch of due events and returns how many were sent.
+// The batch stops at the first failure: the queue is most likely unavailable and the
+// remaining events would only wait for the same timeout.
+func (r *OutboxRelay) RelayBatch() (int, error) {
+	sent := 0
+	err := r.repo.WithDue(time.Now(), r.cfg.BatchSize, func(events []models.OutboxEvent, tx *repository.OutboxRepository) error {
+		for _, event := range events {
+			published, err := r.relay(event, tx)
+			if err != nil || !published {
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	return sent, err
+}
+
+// relay publishes one event and records the outcome; it reports whether the event was sent
+func (r *OutboxRelay) relay(event models.OutboxEvent, tx *repository.OutboxRepository) (bool, error) {
+	publishErr := r.publisher.Publish(event.Queue, event.Payload)
+	if publishErr == nil {
+		return true, tx.MarkSent(event.ID, time.Now())
+	}
+
+	attempts := event.Attempts + 1
+	if attempts >= r.cfg.MaxAttempts {
+		log.Printf("Giving up on outbox event %s for %s after %d attempts: %v", event.ID, event.Queue, attempts, publishErr)
+		payload, redacted := redact(event.Queue, event.Payload)
+		return false, tx.MarkFailed(event.ID, attempts, publishErr.Error(), payload, redacted)
+	}
+	log.Printf("Failed to publish outbox event %s for %s (attempt %d): %v", event.ID, event.Queue, attempts, publishErr)
+	return false, tx.MarkRetry(event.ID, attempts, time.Now().Add(r.backoff(attempts)), publishErr.Error())
+}
+
+// backoff doubles the delay after each failed attempt, up to RetryMax
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.cfg.RetryBase
+	for i := 1; i < attempts && delay < r.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, r.cfg.RetryMax)
+}
+
+// drain relays batches until one is not sent in full
+func (r *OutboxRelay) drain() {
+	for {
+		sent, err := r.RelayBatch()
+		if err != nil {
+			log.Printf("Failed to relay outbox events: %v", err)
+			return
+		}
+		if sent < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// Start polls the outbox in the background and schedules the cleanup of sent events
+func (r *OutboxRelay) Start() {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			r.drain()
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	r.cron = cron.New()
+	r.cron.AddFunc("@daily", func() {
+		removed, err := r.repo.DeleteSentBefore(time.Now().Add(-r.cfg.Retention))
+		if err != nil {
+			log.Printf("Failed to clean up the outbox: %v", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d sent outbox events", removed)
+		}
+	})
+	r.cron.Start()
+}
+
+// Stop waits for the batch in progress to finish
+func (r *OutboxRelay) Stop() {
+	if r.cron != nil {
+		r.cron.Stop()
+	}
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+	}
+}
@@ -1,39 +1,196 @@
 package services
 
 import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/media"
 	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
 	"GoVersi/internal/repository"
+	"GoVersi/internal/search"
+	"GoVersi/internal/utils"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrUnknownTopic is returned when a post names a topic that is not in the catalogue
+	ErrUnknownTopic = errors.New("unknown topic")
+	// ErrTooManyMedia is returned when a post would carry more attachments than allowed
+	ErrTooManyMedia = errors.New("too many attachments")
+	// ErrUnknownMedia is returned when an update names an attachment the post does not have
+	ErrUnknownMedia = errors.New("unknown attachment")
+	// ErrMediaChanged is returned when the attachments of a post changed while it was being updated
+	ErrMediaChanged = errors.New("the attachments of the post changed, reload it and try again")
+)
+
+// PostMediaUpdate is an attachment kept by UpdatePost; the list order becomes the new order
+type PostMediaUpdate struct {
+	ID      uuid.UUID `json:"id" binding:"required"`
+	AltText *string   `json:"alt_text"` // nil keeps the current text
+}
+
 type PostService struct {
-	repo *repository.PostRepository
+	repo           *repository.PostRepository
+	friendshipRepo *repository.FriendshipRepository
+	topicRepo      *repository.TopicRepository
+	authz          *Authorizer
+	hub            realtime.Hub
+	index          search.SearchIndex
+	images         ImageEnqueuer
+	refs           MediaRefs
+	cfg            config.PostConfig
+}
+
+func NewPostService(repo *repository.PostRepository, friendshipRepo *repository.FriendshipRepository, topicRepo *repository.TopicRepository, authz *Authorizer, hub realtime.Hub, index search.SearchIndex, images ImageEnqueuer, refs MediaRefs, cfg config.PostConfig) *PostService {
+	return &PostService{repo: repo, friendshipRepo: friendshipRepo, topicRepo: topicRepo, authz: authz, hub: hub, index: index, images: images, refs: refs, cfg: cfg}
 }
 
-func NewPostService(repo *repository.PostRepository) *PostService {
-	return &PostService{repo: repo}
+// MaxMedia is the number of attachments a post may carry
+func (s *PostService) MaxMedia() int {
+	return s.cfg.MaxMedia
 }
 
-func (s *PostService) CreatePost(title, content, topic, imageURL, videoURL string, authorID uuid.UUID) (*models.Post, error) {
+// CanPost checks that the author may publish, so that uploads can be refused early
+func (s *PostService) CanPost(authorID uuid.UUID) error {
+	if !s.cfg.RequireVerifiedEmail {
+		return nil
+	}
+	return s.authz.RequireVerifiedEmail(authorID)
+}
+
+// CreatePost stores a post with its attachments in the given order
+func (s *PostService) CreatePost(title, content, topic string, attachments []models.PostMedia, authorID uuid.UUID) (*models.Post, error) {
+	if err := s.CanPost(authorID); err != nil {
+		return nil, err
+	}
+	topic, err := s.normalizeTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) > s.cfg.MaxMedia {
+		return nil, ErrTooManyMedia
+	}
+
+	for i := range attachments {
+		attachments[i].Position = i
+		attachments[i].Status = initialMediaStatus(attachments[i].Type)
+	}
+
 	post := &models.Post{
 		Title:    title,
 		Content:  content,
 		Topic:    topic,
-		ImageURL: imageURL,
-		VideoURL: videoURL, // Associando o vídeo
+		Media:    attachments,
 		AuthorID: authorID,
 	}
 
+	files := attachmentKeys(attachments)
+	if err := retainMedia(s.refs, files...); err != nil {
+		return nil, err
+	}
 	if err := s.repo.Create(post); err != nil {
+		releaseMedia(s.refs, files...)
 		return nil, err
 	}
 
+	for _, m := range post.Media {
+		s.enqueueAttachment(m)
+	}
+	indexDocument(s.index, search.PostDocument(post))
+	s.publishToFriends(post)
+
 	return post, nil
 }
 
+// images wait for the pipeline; videos are served as uploaded
+func initialMediaStatus(mediaType models.MediaType) models.MediaStatus {
+	if mediaType == models.MediaTypeImage {
+		return models.MediaProcessing
+	}
+	return models.MediaReady
+}
+
+func (s *PostService) enqueueAttachment(m models.PostMedia) {
+	if m.Type == models.MediaTypeImage {
+		enqueueImage(s.images, media.TargetPostMedia, m.ID, m.URL)
+	}
+}
+
+// CanAttachMedia checks that the actor may add one more attachment to the post
+func (s *PostService) CanAttachMedia(actorID, postID uuid.UUID) error {
+	post, err := s.GetPostByID(postID)
+	if err != nil {
+		return err
+	}
+	if err := s.authz.RequireOwnerOr(actorID, post.AuthorID); err != nil {
+		return err
+	}
+	if len(post.Media) >= s.cfg.MaxMedia {
+		return ErrTooManyMedia
+	}
+	return nil
+}
+
+// AttachMedia appends an attachment to a post of the actor
+func (s *PostService) AttachMedia(actorID, postID uuid.UUID, attachment models.PostMedia) (*models.Post, error) {
+	if err := s.CanAttachMedia(actorID, postID); err != nil {
+		return nil, err
+	}
+
+	attachment.PostID = postID
+	attachment.Status = initialMediaStatus(attachment.Type)
+	if err := retainMedia(s.refs, attachment.URL); err != nil {
+		return nil, err
+	}
+	added, err := s.repo.AddMedia(&attachment, s.cfg.MaxMedia)
+	if err == nil && !added {
+		err = ErrTooManyMedia
+	}
+	if err != nil {
+		releaseMedia(s.refs, attachment.URL)
+		return nil, err
+	}
+	s.enqueueAttachment(attachment)
+	return s.GetPostByID(postID)
+}
+
+// normalizeTopic turns the topic sent by the client into the slug of a catalogue entry
+func (s *PostService) normalizeTopic(topic string) (string, error) {
+	slug := utils.Slugify(topic)
+	if slug == "" {
+		return "", ErrUnknownTopic
+	}
+
+	if _, err := s.topicRepo.FindBySlug(slug); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrUnknownTopic
+		}
+		return "", err
+	}
+	return slug, nil
+}
+
+// publishToFriends pushes a new post to the live feed of the author's friends
+func (s *PostService) publishToFriends(post *models.Post) {
+	friendships, err := s.friendshipRepo.GetFriendsForUser(post.AuthorID)
+	if err != nil {
+		log.Printf("Failed to load friends of %s for realtime delivery: %v", post.AuthorID, err)
+		return
+	}
+
+	for _, f := range friendships {
+		friendID := f.RequesterID
+		if friendID == post.AuthorID {
+			friendID = f.AddresseeID
+		}
+		s.hub.Publish(friendID, "post", post)
+	}
+}
+
 func (s *PostService) GetPostByID(id uuid.UUID) (*models.Post, error) {
 	post, err := s.repo.FindByID(id)
 	if err != nil {
@@ -42,23 +199,100 @@ func (s *PostService) GetPostByID(id uuid.UUID) (*models.Post, error) {
 	return post, nil
 }
 
-func (s *PostService) UpdatePost(postID uuid.UUID, updatedData *models.Post) (*models.Post, error) {
+// UpdatePost edits a post; only its author may do it. A non-nil attachments list
+// keeps only the attachments it names, in its order; nil leaves them untouched.
+func (s *PostService) UpdatePost(actorID, postID uuid.UUID, updatedData *models.Post, attachments []PostMediaUpdate) (*models.Post, error) {
 	existingPost, err := s.GetPostByID(postID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.authz.RequireOwnerOr(actorID, existingPost.AuthorID); err != nil {
+		return nil, err
+	}
+
 	existingPost.Title = updatedData.Title
 	existingPost.Content = updatedData.Content
-	existingPost.Topic = updatedData.Topic
+	// an empty topic keeps the current one
+	if updatedData.Topic != "" {
+		topic, err := s.normalizeTopic(updatedData.Topic)
+		if err != nil {
+			return nil, err
+		}
+		existingPost.Topic = topic
+	}
 	existingPost.UpdatedAt = time.Now()
 
-	if err := s.repo.Update(existingPost); err != nil {
+	if attachments == nil {
+		if err := s.repo.Update(existingPost); err != nil {
+			return nil, err
+		}
+		indexDocument(s.index, search.PostDocument(existingPost))
+		return existingPost, nil
+	}
+
+	kept, err := reorderMedia(existingPost.Media, attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	// the post and its attachments are saved together, and only if no attachment was
+	// added or removed since they were read
+	read := make([]uuid.UUID, len(existingPost.Media))
+	for i, m := range existingPost.Media {
+		read[i] = m.ID
+	}
+	removed, replaced, err := s.repo.ReplaceMedia(existingPost, read, kept)
+	if err != nil {
 		return nil, err
 	}
+	if !replaced {
+		return nil, ErrMediaChanged
+	}
+	releaseMedia(s.refs, attachmentKeys(removed)...)
+	existingPost.Media = kept
+	indexDocument(s.index, search.PostDocument(existingPost))
 	return existingPost, nil
 }
 
-func (s *PostService) DeletePost(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// reorderMedia returns the current attachments named by updates, in the order of updates
+func reorderMedia(current []models.PostMedia, updates []PostMediaUpdate) ([]models.PostMedia, error) {
+	byID := make(map[uuid.UUID]models.PostMedia, len(current))
+	for _, m := range current {
+		byID[m.ID] = m
+	}
+
+	kept := make([]models.PostMedia, 0, len(updates))
+	for i, u := range updates {
+		m, ok := byID[u.ID]
+		if !ok {
+			return nil, ErrUnknownMedia
+		}
+		delete(byID, u.ID) // each attachment at most once
+		if u.AltText != nil {
+			m.AltText = *u.AltText
+		}
+		m.Position = i
+		kept = append(kept, m)
+	}
+	return kept, nil
+}
+
+// DeletePost removes a post; allowed for its author and for moderators
+func (s *PostService) DeletePost(actorID, id uuid.UUID) error {
+	post, err := s.GetPostByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authz.RequireOwnerOr(actorID, post.AuthorID, models.RoleModerator, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	releaseMedia(s.refs, attachmentKeys(post.Media)...)
+	removeDocument(s.index, search.TypePosts, id)
+	return nil
 }
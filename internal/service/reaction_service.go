@@ -0,0 +1,115 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidReactionType = errors.New("invalid reaction type")
+
+// ReactionSummary aggregates the reactions of a single post or comment
+type ReactionSummary struct {
+	Counts         map[models.ReactionType]int64 `json:"counts"`
+	Total          int64                         `json:"total"`
+	ViewerReaction models.ReactionType           `json:"viewer_reaction,omitempty"`
+}
+
+type ReactionService struct {
+	repo        *repository.ReactionRepository
+	postRepo    *repository.PostRepository
+	commentRepo *repository.CommentRepository
+	notifier    NotificationPublisher
+}
+
+func NewReactionService(repo *repository.ReactionRepository, postRepo *repository.PostRepository, commentRepo *repository.CommentRepository, notifier NotificationPublisher) *ReactionService {
+	return &ReactionService{repo: repo, postRepo: postRepo, commentRepo: commentRepo, notifier: notifier}
+}
+
+// React sets the user's reaction to the target, replacing any previous one
+func (s *ReactionService) React(userID uuid.UUID, targetType models.ReactionTarget, targetID uuid.UUID, reactionType models.ReactionType) (*models.Reaction, error) {
+	if !reactionType.IsValid() {
+		return nil, ErrInvalidReactionType
+	}
+
+	ownerID, err := s.targetOwner(targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := &models.Reaction{
+		ID:         uuid.New(),
+		UserID:     userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Type:       reactionType,
+	}
+
+	if err := s.repo.Upsert(reaction); err != nil {
+		return nil, err
+	}
+
+	s.notifier.Publish(NotificationEvent{
+		RecipientID: ownerID,
+		ActorID:     userID,
+		Type:        models.NotificationReaction,
+		TargetType:  string(targetType),
+		TargetID:    targetID,
+	})
+
+	return s.repo.FindUserReaction(userID, targetType, targetID)
+}
+
+func (s *ReactionService) RemoveReaction(userID uuid.UUID, targetType models.ReactionTarget, targetID uuid.UUID) error {
+	return s.repo.Delete(userID, targetType, targetID)
+}
+
+// GetSummary returns per-type counts for the target and the viewer's own reaction
+func (s *ReactionService) GetSummary(viewerID uuid.UUID, targetType models.ReactionTarget, targetID uuid.UUID) (*ReactionSummary, error) {
+	if _, err := s.targetOwner(targetType, targetID); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.repo.CountByType(targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReactionSummary{Counts: make(map[models.ReactionType]int64, len(models.ReactionTypes))}
+	for _, t := range models.ReactionTypes {
+		summary.Counts[t] = counts[t]
+		summary.Total += counts[t]
+	}
+
+	own, err := s.repo.FindUserReaction(viewerID, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if own != nil {
+		summary.ViewerReaction = own.Type
+	}
+
+	return summary, nil
+}
+
+// targetOwner returns the author of the target, failing when it does not exist
+func (s *ReactionService) targetOwner(targetType models.ReactionTarget, targetID uuid.UUID) (uuid.UUID, error) {
+	switch targetType {
+	case models.ReactionTargetPost:
+		post, err := s.postRepo.FindByID(targetID)
+		if err != nil {
+			return uuid.Nil, errors.New("post not found")
+		}
+		return post.AuthorID, nil
+	case models.ReactionTargetComment:
+		comment, err := s.commentRepo.FindByID(targetID)
+		if err != nil {
+			return uuid.Nil, errors.New("comment not found")
+		}
+		return comment.AuthorID, nil
+	default:
+		return uuid.Nil, errors.New("invalid reaction target")
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/search"
+	"GoVersi/internal/utils"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const maxSearchQueryLength = 200
+
+var (
+	ErrInvalidSearchType = errors.New("type must be one of posts, users, comments")
+	ErrEmptySearchQuery  = errors.New("search query is required")
+)
+
+// SearchResult is a ranked hit with the matching post, comment or user
+type SearchResult struct {
+	Type      search.DocumentType `json:"type"`
+	Score     float64             `json:"score"`
+	Highlight string              `json:"highlight"`
+	Post      *models.Post        `json:"post,omitempty"`
+	Comment   *models.Comment     `json:"comment,omitempty"`
+	User      *AuthorSummary      `json:"user,omitempty"`
+}
+
+type SearchPage struct {
+	Items      []SearchResult `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type SearchService struct {
+	index       search.SearchIndex
+	postRepo    *repository.PostRepository
+	commentRepo *repository.CommentRepository
+	userRepo    repository.UserRepository
+}
+
+func NewSearchService(index search.SearchIndex, postRepo *repository.PostRepository, commentRepo *repository.CommentRepository, userRepo repository.UserRepository) *SearchService {
+	return &SearchService{index: index, postRepo: postRepo, commentRepo: commentRepo, userRepo: userRepo}
+}
+
+// Search runs a ranked query over one document type. Hits whose record is gone
+// (or whose user is suspended) are dropped, so a page may hold fewer than limit items.
+func (s *SearchService) Search(text string, docType search.DocumentType, cursor string, limit int) (*SearchPage, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if runes := []rune(text); len(runes) > maxSearchQueryLength {
+		text = string(runes[:maxSearchQueryLength])
+	}
+	if !docType.IsValid() {
+		return nil, ErrInvalidSearchType
+	}
+
+	offset, err := utils.DecodeOffsetCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := s.index.Search(search.Query{Text: text, Type: docType, Offset: offset, Limit: limit + 1})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &SearchPage{Items: []SearchResult{}}
+	if len(hits) > limit {
+		hits = hits[:limit]
+		page.NextCursor = utils.EncodeOffsetCursor(offset + limit)
+	}
+	if len(hits) == 0 {
+		return page, nil
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	attach, err := s.loader(docType, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range hits {
+		result := SearchResult{Type: hit.Type, Score: hit.Score, Highlight: hit.Highlight}
+		if attach(&result, hit.ID) {
+			page.Items = append(page.Items, result)
+		}
+	}
+	return page, nil
+}
+
+// loader fetches the records behind the hits and returns a function filling them into results
+func (s *SearchService) loader(docType search.DocumentType, ids []uuid.UUID) (func(*SearchResult, uuid.UUID) bool, error) {
+	switch docType {
+	case search.TypePosts:
+		posts, err := s.postRepo.FindByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]*models.Post, len(posts))
+		for i := range posts {
+			byID[posts[i].ID] = &posts[i]
+		}
+		return func(r *SearchResult, id uuid.UUID) bool {
+			r.Post = byID[id]
+			return r.Post != nil
+		}, nil
+
+	case search.TypeComments:
+		comments, err := s.commentRepo.FindByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]*models.Comment, len(comments))
+		for i := range comments {
+			byID[comments[i].ID] = &comments[i]
+		}
+		return func(r *SearchResult, id uuid.UUID) bool {
+			r.Comment = byID[id]
+			return r.Comment != nil
+		}, nil
+
+	default:
+		users, err := s.userRepo.FindByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[uuid.UUID]*AuthorSummary, len(users))
+		for _, u := range users {
+			if u.IsActive {
+				byID[u.ID] = &AuthorSummary{ID: u.ID, Username: u.Username, ImageProfile: u.ImageProfile}
+			}
+		}
+		return func(r *SearchResult, id uuid.UUID) bool {
+			r.User = byID[id]
+			return r.User != nil
+		}, nil
+	}
+}
+
+// indexDocument keeps the search index in sync; failures are logged, never returned to the caller
+func indexDocument(index search.SearchIndex, doc search.Document) {
+	if err := index.Index(doc); err != nil {
+		log.Printf("Failed to index %s %s: %v", doc.Type, doc.ID, err)
+	}
+}
+
+func removeDocument(index search.SearchIndex, docType search.DocumentType, id uuid.UUID) {
+	if err := index.Remove(docType, id); err != nil {
+		log.Printf("Failed to remove %s %s from the search index: %v", docType, id, err)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"GoVersi/internal/cache"
 	"GoVersi/internal/models"
 	"log"
 	"time"
@@ -11,10 +12,20 @@ import (
 
 type TokenBlacklistService struct {
 	DB *gorm.DB
+
+	// cache remembers lookups until the token expires; "not blacklisted" answers
+	// are kept for at most negativeTTL so revocations made by other instances show up
+	cache       *cache.TTLCache[string, bool]
+	negativeTTL time.Duration
+	cron        *cron.Cron
 }
 
-func NewTokenBlacklistService(db *gorm.DB) *TokenBlacklistService {
-	return &TokenBlacklistService{DB: db}
+func NewTokenBlacklistService(db *gorm.DB, cacheSize int, negativeTTL time.Duration) *TokenBlacklistService {
+	return &TokenBlacklistService{
+		DB:          db,
+		cache:       cache.NewTTLCache[string, bool](cacheSize),
+		negativeTTL: negativeTTL,
+	}
 }
 
 func (s *TokenBlacklistService) AddToTokenBlacklist(token string, expiresAt time.Time) error {
@@ -22,25 +33,47 @@ func (s *TokenBlacklistService) AddToTokenBlacklist(token string, expiresAt time
 		Token:     token,
 		ExpiresAt: expiresAt,
 	}
-	return s.DB.Create(&tokenBlacklistEntry).Error
+	if err := s.DB.Create(&tokenBlacklistEntry).Error; err != nil {
+		return err
+	}
+
+	s.cache.Set(token, true, expiresAt)
+	return nil
 }
 
-func (s *TokenBlacklistService) IsTokenBlacklisted(token string) (bool, error) {
+// IsTokenBlacklisted reports whether the token was revoked. expiresAt is the
+// token's own expiry and bounds how long the answer is cached.
+func (s *TokenBlacklistService) IsTokenBlacklisted(token string, expiresAt time.Time) (bool, error) {
+	if blacklisted, ok := s.cache.Get(token); ok {
+		return blacklisted, nil
+	}
+
 	var count int64
 	err := s.DB.Model(&models.TokenBlacklist{}).Where("token = ?", token).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
-	return count > 0, nil
+
+	blacklisted := count > 0
+	cacheUntil := expiresAt
+	if !blacklisted {
+		if limit := time.Now().Add(s.negativeTTL); limit.Before(cacheUntil) {
+			cacheUntil = limit
+		}
+	}
+	s.cache.Set(token, blacklisted, cacheUntil)
+
+	return blacklisted, nil
 }
 
 func (s *TokenBlacklistService) RemoveExpiredTokens() error {
 	return s.DB.Where("expires_at < ?", time.Now()).Delete(&models.TokenBlacklist{}).Error
 }
 
+// StartCronJob schedules the cleanup of expired blacklist rows and cache entries
 func (s *TokenBlacklistService) StartCronJob() {
-	c := cron.New()
-	c.AddFunc("@daily", func() {
+	s.cron = cron.New()
+	s.cron.AddFunc("@daily", func() {
 		err := s.RemoveExpiredTokens()
 		if err != nil {
 			log.Printf("Failed to remove expired tokens: %v", err)
@@ -48,5 +81,16 @@ func (s *TokenBlacklistService) StartCronJob() {
 			log.Println("Expired tokens removed from blacklist")
 		}
 	})
-	c.Start()
+	s.cron.AddFunc("@every 5m", func() {
+		if removed := s.cache.PurgeExpired(); removed > 0 {
+			log.Printf("Purged %d expired entries from token blacklist cache", removed)
+		}
+	})
+	s.cron.Start()
+}
+
+func (s *TokenBlacklistService) StopCronJob() {
+	if s.cron != nil {
+		s.cron.Stop()
+	}
 }
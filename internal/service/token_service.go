@@ -0,0 +1,145 @@
+package services
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/utils"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// TokenPair is returned to clients on login and on refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type TokenService struct {
+	repo *repository.RefreshTokenRepository
+	cfg  config.AuthConfig
+}
+
+func NewTokenService(repo *repository.RefreshTokenRepository, cfg config.AuthConfig) *TokenService {
+	return &TokenService{repo: repo, cfg: cfg}
+}
+
+// IssueTokenPair starts a new session (refresh token family) for the user
+func (s *TokenService) IssueTokenPair(userID uuid.UUID) (*TokenPair, error) {
+	refreshToken, record, err := s.newRefreshToken(userID, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(record); err != nil {
+		return nil, err
+	}
+
+	return s.buildPair(userID, record.FamilyID, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new pair. Presenting a token that was
+// already rotated revokes the whole family, since it means the token leaked.
+func (s *TokenService) Refresh(refreshToken string) (*TokenPair, error) {
+	current, err := s.repo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if current.ReplacedByID != nil {
+		log.Printf("Refresh token reuse detected for user %s, revoking session %s", current.UserID, current.FamilyID)
+		if err := s.repo.RevokeFamily(current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if current.RevokedAt != nil || time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	nextToken, next, err := s.newRefreshToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Rotate(current, next); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// another request rotated the same token first
+			if err := s.repo.RevokeFamily(current.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, err
+	}
+
+	return s.buildPair(current.UserID, current.FamilyID, nextToken)
+}
+
+// RevokeSession revokes every refresh token of the given session
+func (s *TokenService) RevokeSession(familyID uuid.UUID) error {
+	return s.repo.RevokeFamily(familyID)
+}
+
+// RevokeAllSessions revokes every refresh token of the user
+func (s *TokenService) RevokeAllSessions(userID uuid.UUID) error {
+	return s.repo.RevokeAllForUser(userID)
+}
+
+func (s *TokenService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	value, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	record := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(value),
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTokenTTL),
+	}
+	return value, record, nil
+}
+
+func (s *TokenService) buildPair(userID, familyID uuid.UUID, refreshToken string) (*TokenPair, error) {
+	accessToken, err := utils.GenerateJWT(userID.String(), familyID.String(), s.cfg.SecretKey, s.cfg.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *TokenService) StartCronJob() {
+	c := cron.New()
+	c.AddFunc("@daily", func() {
+		if err := s.repo.DeleteExpired(); err != nil {
+			log.Printf("Failed to remove expired refresh tokens: %v", err)
+		} else {
+			log.Println("Expired refresh tokens removed")
+		}
+	})
+	c.Start()
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTopicNotFound         = errors.New("topic not found")
+	ErrInvalidTrendingWindow = errors.New("invalid trending window")
+)
+
+// TopicView is a topic as seen by a user
+type TopicView struct {
+	models.Topic
+	Following bool `json:"following"`
+}
+
+type TopicService struct {
+	repo  *repository.TopicRepository
+	authz *Authorizer
+	cfg   config.TopicConfig
+}
+
+func NewTopicService(repo *repository.TopicRepository, authz *Authorizer, cfg config.TopicConfig) *TopicService {
+	return &TopicService{repo: repo, authz: authz, cfg: cfg}
+}
+
+// CreateTopic adds a topic to the catalogue; reserved to moderators and admins.
+// The slug is derived from the name unless one is given.
+func (s *TopicService) CreateTopic(actorID uuid.UUID, name, slug, description string) (*models.Topic, error) {
+	if err := s.authz.RequireRole(actorID, models.RoleModerator, models.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if slug == "" {
+		slug = name
+	}
+	slug = utils.Slugify(slug)
+	if name == "" || slug == "" {
+		return nil, errors.New("topic name is required")
+	}
+
+	topic := &models.Topic{Slug: slug, Name: name, Description: strings.TrimSpace(description)}
+	if err := s.repo.Create(topic); err != nil {
+		return nil, err
+	}
+	return topic, nil
+}
+
+func (s *TopicService) ListTopics() ([]models.Topic, error) {
+	return s.repo.FindAll()
+}
+
+func (s *TopicService) ListFollowedTopics(userID uuid.UUID) ([]models.Topic, error) {
+	return s.repo.FindFollowedBy(userID)
+}
+
+func (s *TopicService) GetTopic(viewerID uuid.UUID, slug string) (*TopicView, error) {
+	topic, err := s.findBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	following, err := s.repo.IsFollowing(topic.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return &TopicView{Topic: *topic, Following: following}, nil
+}
+
+func (s *TopicService) Follow(userID uuid.UUID, slug string) error {
+	topic, err := s.findBySlug(slug)
+	if err != nil {
+		return err
+	}
+	return s.repo.Follow(topic.ID, userID)
+}
+
+func (s *TopicService) Unfollow(userID uuid.UUID, slug string) error {
+	topic, err := s.findBySlug(slug)
+	if err != nil {
+		return err
+	}
+	return s.repo.Unfollow(topic.ID, userID)
+}
+
+// Trending ranks topics by the number of posts created in the last window.
+// An empty window uses the configured default.
+func (s *TopicService) Trending(window string, limit int) ([]repository.TrendingTopic, error) {
+	duration := s.cfg.TrendingWindow
+	if window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 || d > s.cfg.MaxTrendingWindow {
+			return nil, ErrInvalidTrendingWindow
+		}
+		duration = d
+	}
+
+	topics, err := s.repo.FindTrending(time.Now().Add(-duration), limit)
+	if err != nil {
+		return nil, err
+	}
+	if topics == nil {
+		topics = []repository.TrendingTopic{}
+	}
+	return topics, nil
+}
+
+func (s *TopicService) findBySlug(slug string) (*models.Topic, error) {
+	topic, err := s.repo.FindBySlug(utils.Slugify(slug))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTopicNotFound
+		}
+		return nil, err
+	}
+	return topic, nil
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/internal/storage"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+)
+
+var (
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrUploadTooLarge   = errors.New("upload exceeds the maximum size")
+	ErrInvalidUpload    = errors.New("upload length must be a positive number")
+	ErrOffsetMismatch   = errors.New("upload offset does not match")
+	ErrUploadIncomplete = errors.New("upload is not complete")
+	ErrUploadFinalized  = errors.New("upload was already finalized")
+)
+
+// UploadService implements resumable uploads: a session is created with the
+// final size, chunks are appended at the current offset and, once complete,
+// the file is validated, moved to the blob store and attached to a post
+type UploadService struct {
+	repo  *repository.UploadSessionRepository
+	posts *PostService
+	store storage.BlobStore
+	cfg   config.ResumableUploadConfig
+
+	// serialises chunks of the same session within this instance;
+	// AdvanceOffset guards against concurrent writers elsewhere
+	locks sync.Map
+	cron  *cron.Cron
+}
+
+func NewUploadService(repo *repository.UploadSessionRepository, posts *PostService, store storage.BlobStore, cfg config.ResumableUploadConfig) (*UploadService, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &UploadService{repo: repo, posts: posts, store: store, cfg: cfg}, nil
+}
+
+func (s *UploadService) MaxSize() int64 {
+	return int64(s.cfg.MaxSizeMB) * 1024 * 1024
+}
+
+func (s *UploadService) path(id uuid.UUID) string {
+	return filepath.Join(s.cfg.Dir, id.String())
+}
+
+func (s *UploadService) lock(id uuid.UUID) func() {
+	mu, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// CreateSession opens an upload of size bytes for the user
+func (s *UploadService) CreateSession(userID uuid.UUID, size int64, filename string) (*models.UploadSession, error) {
+	if size <= 0 {
+		return nil, ErrInvalidUpload
+	}
+	if size > s.MaxSize() {
+		return nil, ErrUploadTooLarge
+	}
+
+	session := &models.UploadSession{
+		UserID:    userID,
+		Filename:  filepath.Base(filename),
+		Size:      size,
+		Status:    models.UploadPending,
+		ExpiresAt: time.Now().Add(s.cfg.SessionTTL),
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(s.path(session.ID))
+	if err != nil {
+		s.repo.Delete(session.ID)
+		return nil, err
+	}
+	file.Close()
+	return session, nil
+}
+
+// GetSession returns an upload of the user; other users' uploads are reported as missing
+func (s *UploadService) GetSession(userID, id uuid.UUID) (*models.UploadSession, error) {
+	session, err := s.repo.FindByID(id)
+	if err != nil || session.UserID != userID || session.ExpiresAt.Before(time.Now()) {
+		return nil, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// WriteChunk appends body at offset, which must be the session's current offset.
+// Whatever was received is kept even if the connection drops, so the client can
+// resume from the offset returned.
+func (s *UploadService) WriteChunk(userID, id uuid.UUID, offset int64, body io.Reader) (*models.UploadSession, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	session, err := s.GetSession(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadPending {
+		return nil, ErrUploadFinalized
+	}
+	if offset != session.Offset {
+		return session, ErrOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.path(id), os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// drop bytes a previous interrupted write may have left past the recorded offset
+	if err := file.Truncate(offset); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// one extra byte tells us the client sent more than it announced
+	remaining := session.Size - offset
+	written, copyErr := io.Copy(file, io.LimitReader(body, remaining+1))
+	if written > remaining {
+		file.Truncate(offset)
+		return session, ErrUploadTooLarge
+	}
+	if written > 0 {
+		if err := file.Sync(); err != nil {
+			return nil, err
+		}
+		ok, err := s.repo.AdvanceOffset(id, offset, offset+written, time.Now().Add(s.cfg.SessionTTL))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return session, ErrOffsetMismatch
+		}
+		session.Offset += written
+	}
+	if copyErr != nil {
+		return session, copyErr
+	}
+	return session, nil
+}
+
+// Finalize validates a complete upload as a video, stores it and appends it to the attachments of the post
+func (s *UploadService) Finalize(userID, id, postID uuid.UUID, altText string) (*models.Post, error) {
+	unlock := s.lock(id)
+	defer unlock()
+
+	session, err := s.GetSession(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadPending {
+		return nil, ErrUploadFinalized
+	}
+	if session.Offset != session.Size {
+		return nil, ErrUploadIncomplete
+	}
+
+	if err := s.posts.CanAttachMedia(userID, postID); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := media.Inspect(file, session.Size, media.KindVideo, media.Limits{MaxVideoBytes: s.MaxSize()})
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("videos/%s_%s%s", time.Now().Format("20060102-150405"), uuid.New().String(), info.Extension)
+	if err := s.store.Put(key, file, session.Size, info.MIME); err != nil {
+		return nil, fmt.Errorf("failed to upload video")
+	}
+	post, err := s.posts.AttachMedia(userID, postID, models.PostMedia{
+		Type:       models.MediaTypeVideo,
+		URL:        models.MediaKey(key),
+		MimeType:   info.MIME,
+		Width:      info.Width,
+		Height:     info.Height,
+		DurationMS: info.Duration.Milliseconds(),
+		AltText:    altText,
+	})
+	if err != nil {
+		s.store.Delete(key)
+		return nil, err
+	}
+
+	if err := s.repo.MarkCompleted(id); err != nil {
+		log.Printf("Failed to mark upload %s completed: %v", id, err)
+	}
+	s.removeFile(id)
+	return post, nil
+}
+
+// Cancel discards an upload and whatever was received
+func (s *UploadService) Cancel(userID, id uuid.UUID) error {
+	unlock := s.lock(id)
+	defer unlock()
+
+	if _, err := s.GetSession(userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.removeFile(id)
+	return nil
+}
+
+func (s *UploadService) removeFile(id uuid.UUID) {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove partial upload %s: %v", id, err)
+	}
+	s.locks.Delete(id)
+}
+
+// CleanupStale deletes expired sessions together with their partial files
+func (s *UploadService) CleanupStale() (int, error) {
+	sessions, err := s.repo.FindExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		s.removeFile(session.ID)
+		if err := s.repo.Delete(session.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+// StartCronJob schedules the garbage collection of abandoned uploads
+func (s *UploadService) StartCronJob() {
+	s.cron = cron.New()
+	s.cron.AddFunc("@hourly", func() {
+		removed, err := s.CleanupStale()
+		if err != nil {
+			log.Printf("Failed to clean up stale uploads: %v", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d stale upload sessions", removed)
+		}
+	})
+	s.cron.Start()
+}
+
+func (s *UploadService) StopCronJob() {
+	if s.cron != nil {
+		s.cron.Stop()
+	}
+}
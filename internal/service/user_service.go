@@ -1,26 +1,42 @@
 package services
 
 import (
+	"GoVersi/internal/media"
 	"GoVersi/internal/models"
 	"GoVersi/internal/repository"
+	"GoVersi/internal/search"
 	"GoVersi/internal/service/email"
 	"GoVersi/internal/utils"
 	"errors"
 	"log"
-	"os"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type UserService struct {
 	UserRepo     repository.UserRepository
-	EmailService email.EmailService
+	TokenService *TokenService
+	tx           *repository.TxManager
+	authz        *Authorizer
+	index        search.SearchIndex
+	images       ImageEnqueuer
+	refs         MediaRefs
+	mails        *email.Templates
+	verifier     *EmailVerifier
 }
 
-func NewUserService(repo repository.UserRepository, emailService email.EmailService) *UserService {
+func NewUserService(repo repository.UserRepository, tx *repository.TxManager, tokenService *TokenService, authz *Authorizer, index search.SearchIndex, images ImageEnqueuer, refs MediaRefs, mails *email.Templates, verifier *EmailVerifier) *UserService {
 	return &UserService{
 		UserRepo:     repo,
-		EmailService: emailService,
+		TokenService: tokenService,
+		tx:           tx,
+		authz:        authz,
+		index:        index,
+		images:       images,
+		refs:         refs,
+		mails:        mails,
+		verifier:     verifier,
 	}
 }
 
@@ -41,59 +57,148 @@ func (s *UserService) RegisterUser(user *models.User) error {
 		return err
 	}
 	user.Password = hashedPassword
+	user.Locale = s.mails.ResolveLocale(user.Locale)
 
-	if err := s.UserRepo.Create(user); err != nil {
-		log.Printf("Erro ao criar usuário: %v", err)
+	// an image uploaded for a registration that fails is never retained and gets swept
+	if err := retainMedia(s.refs, user.ImageProfile); err != nil {
 		return err
 	}
-
-	if err := s.EmailService.SendConfirmationEmail(user.Email, user.Username, user.EmailConfirmToken); err != nil {
-		log.Printf("Erro ao enviar email: %v", err)
+	// the confirmation email goes through the outbox: it is sent if and only if the user is committed
+	err = s.tx.Do(func(tx *repository.Tx) error {
+		if err := tx.Users().Create(user); err != nil {
+			return err
+		}
+		return s.verifier.Issue(tx, user, user.Email)
+	})
+	if err != nil {
+		log.Printf("Erro ao criar usuário: %v", err)
+		releaseMedia(s.refs, user.ImageProfile)
+		return err
 	}
+	enqueueImage(s.images, media.TargetUser, user.ID, user.ImageProfile)
+	indexDocument(s.index, search.UserDocument(user))
 
 	log.Printf("Usuário registrado com sucesso: %s", user.Username)
 	return nil
 }
 
-func (s *UserService) LoginUser(email, password string) (string, error) {
+func (s *UserService) LoginUser(email, password string) (*TokenPair, error) {
 	user, err := s.UserRepo.FindByEmail(email)
 	if err != nil {
-		return "", errors.New("something went wrong")
+		return nil, errors.New("something went wrong")
 	}
 
 	if user == nil {
-		return "", errors.New("invalid credentials")
+		return nil, errors.New("invalid credentials")
 	}
 
 	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", errors.New("invalid credentials")
+		return nil, errors.New("invalid credentials")
+	}
+	if err := s.verifier.CanLogin(user); err != nil {
+		return nil, err
 	}
 
-	secretKey := os.Getenv("JWT_SECRET_KEY")
+	return s.TokenService.IssueTokenPair(user.ID)
+}
 
-	return utils.GenerateJWT(user.ID.String(), secretKey)
+func (s *UserService) RefreshTokens(refreshToken string) (*TokenPair, error) {
+	return s.TokenService.Refresh(refreshToken)
+}
+
+// Logout ends the session the access token belongs to
+func (s *UserService) Logout(sessionID string) error {
+	familyID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errors.New("invalid session")
+	}
+	return s.TokenService.RevokeSession(familyID)
 }
 
 func (s *UserService) UpdateUser(user *models.User) error {
-	return s.UserRepo.UpdateUser(user)
+	if err := s.UserRepo.UpdateUser(user); err != nil {
+		return err
+	}
+	indexDocument(s.index, search.UserDocument(user))
+	return nil
 }
 
-func (s *UserService) SuspendUser(id string) error {
+// SuspendUser is reserved to moderators and admins, who can only suspend lower roles
+func (s *UserService) SuspendUser(actorID uuid.UUID, id string) error {
 	userID, err := uuid.Parse(id)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
 
+	actorRole, err := s.authz.RoleOf(actorID)
+	if err != nil {
+		return err
+	}
+	if actorRole != models.RoleModerator && actorRole != models.RoleAdmin {
+		return ErrForbidden
+	}
+
+	target, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if actorRole.Rank() <= target.Role.Rank() {
+		return ErrForbidden
+	}
+
 	return s.UserRepo.SuspendUser(userID)
 }
 
-func (s *UserService) RequestAccountDeletion(id string) error {
+func (s *UserService) RequestAccountDeletion(actorID uuid.UUID, id string) error {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	if err := s.authz.RequireOwnerOr(actorID, userID, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	notice, err := s.mails.DeletionNotice(user, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// the notice is only sent once the request is committed
+	return s.tx.Do(func(tx *repository.Tx) error {
+		if err := tx.Users().RequestAccountDeletion(userID); err != nil {
+			return err
+		}
+		return email.NewEmailQueueService(tx.Outbox()).PublishEmail(notice)
+	})
+}
+
+// SetUserRole changes the role of a user; admins only
+func (s *UserService) SetUserRole(actorID uuid.UUID, id string, role models.Role) error {
 	userID, err := uuid.Parse(id)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
 
-	return s.UserRepo.RequestAccountDeletion(userID)
+	if !role.IsValid() {
+		return errors.New("invalid role")
+	}
+
+	if err := s.authz.RequireRole(actorID, models.RoleAdmin); err != nil {
+		return err
+	}
+
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	user.Role = role
+	return s.UserRepo.UpdateUser(user)
 }
 
 func (s *UserService) GetUserById(id string) (*models.User, error) {
@@ -112,22 +217,38 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 	return s.UserRepo.FindByEmail(email)
 }
 
-func (s *UserService) DeleteUser(id string) error {
+func (s *UserService) DeleteUser(actorID uuid.UUID, id string) error {
 	userID, err := uuid.Parse(id)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
-	return s.UserRepo.PermanentlyDeleteUser(userID)
+	if err := s.authz.RequireOwnerOr(actorID, userID, models.RoleAdmin); err != nil {
+		return err
+	}
+	return s.deleteUser(userID)
 }
 
-func (s *UserService) PermanentlyDeleteUser(id string) error {
+func (s *UserService) PermanentlyDeleteUser(actorID uuid.UUID, id string) error {
 	userID, err := uuid.Parse(id)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
-	return s.UserRepo.PermanentlyDeleteUser(userID)
+	if err := s.authz.RequireOwnerOr(actorID, userID, models.RoleAdmin); err != nil {
+		return err
+	}
+	return s.deleteUser(userID)
 }
 
-func (s *UserService) FindByEmailConfirmToken(token string) (*models.User, error) {
-	return s.UserRepo.FindByEmailConfirmToken(token)
+// deleteUser removes the account for good and releases its profile image
+func (s *UserService) deleteUser(userID uuid.UUID) error {
+	user, err := s.UserRepo.GetUserByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if err := s.UserRepo.PermanentlyDeleteUser(userID); err != nil {
+		return err
+	}
+	releaseMedia(s.refs, imageKeys(user.ImageProfile, user.ImageRenditions)...)
+	removeDocument(s.index, search.TypeUsers, userID)
+	return nil
 }
@@ -0,0 +1,54 @@
+// Package storage abstracts where uploaded files live. Files are addressed by
+// keys such as "images/20240101-120000_<uuid>.jpg"; clients never see the keys
+// directly but signed, time-limited URLs produced by the store.
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type BlobStore interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// SignedURL returns a URL granting read access to the key until ttl elapses
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// NormalizeKey accepts both keys and the "/uploads/..." paths stored before the
+// blob stores existed, which map to the same files under the local root
+func NormalizeKey(key string) string {
+	key = strings.TrimPrefix(key, "/")
+	return strings.TrimPrefix(key, "uploads/")
+}
+
+// URLSigner adapts a store to models.SetMediaURLSigner
+func URLSigner(store BlobStore, ttl time.Duration) func(string) string {
+	return func(key string) string {
+		url, err := store.SignedURL(NormalizeKey(key), ttl)
+		if err != nil {
+			log.Printf("Failed to sign media URL for %s: %v", key, err)
+			return ""
+		}
+		return url
+	}
+}
+
+// validKey rejects keys that could escape the store root
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
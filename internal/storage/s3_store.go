@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const maxPresignTTL = 7 * 24 * time.Hour // longest expiry accepted by S3
+
+// S3Config describes an S3-compatible bucket (AWS, MinIO, ...)
+type S3Config struct {
+	Endpoint       string // e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	PublicEndpoint string // endpoint used in signed URLs when clients reach the bucket through another host
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool // https://host/bucket/key instead of https://bucket.host/key; required by MinIO
+}
+
+// S3Store talks to the S3 REST API directly, signing requests with Signature Version 4
+type S3Store struct {
+	cfg    S3Config
+	signer SigV4Signer
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("s3 storage requires an endpoint, bucket and credentials")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.PublicEndpoint == "" {
+		cfg.PublicEndpoint = cfg.Endpoint
+	}
+	return &S3Store{
+		cfg:    cfg,
+		signer: SigV4Signer{AccessKey: cfg.AccessKey, SecretKey: cfg.SecretKey, Region: cfg.Region},
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+	}, nil
+}
+
+func (s *S3Store) Put(key string, body io.Reader, size int64, contentType string) error {
+	if !validKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(s.cfg.Endpoint, key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Get(key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		return nil, ErrNotFound
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(s.cfg.Endpoint, key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Delete(key string) error {
+	if !validKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(s.cfg.Endpoint, key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// SignedURL returns a presigned GET URL; S3 caps its lifetime at seven days
+func (s *S3Store) SignedURL(key string, ttl time.Duration) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	if ttl > maxPresignTTL {
+		ttl = maxPresignTTL
+	}
+	return s.signer.Presign(http.MethodGet, s.objectURL(s.cfg.PublicEndpoint, key), ttl, s.now()), nil
+}
+
+// do signs the request with the Authorization header and maps error statuses
+func (s *S3Store) do(req *http.Request) (*http.Response, error) {
+	s.signer.Sign(req, UnsignedPayload, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
+
+func (s *S3Store) objectURL(endpoint, key string) *url.URL {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		u = &url.URL{Scheme: "https", Host: endpoint}
+	}
+
+	path := "/" + key
+	if s.cfg.PathStyle {
+		path = "/" + s.cfg.Bucket + path
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	return u
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"io"
+	"log"
+)
+
+// Tracker records which files a store holds
+type Tracker interface {
+	Register(key string, size int64, contentType string) error
+	Forget(key string) error
+}
+
+// TrackedStore records every file written to the wrapped store, so that files
+// no longer referenced by any record can be found and swept
+type TrackedStore struct {
+	BlobStore
+	tracker Tracker
+}
+
+func NewTrackedStore(store BlobStore, tracker Tracker) *TrackedStore {
+	return &TrackedStore{BlobStore: store, tracker: tracker}
+}
+
+func (s *TrackedStore) Put(key string, body io.Reader, size int64, contentType string) error {
+	key = NormalizeKey(key)
+	if err := s.BlobStore.Put(key, body, size, contentType); err != nil {
+		return err
+	}
+
+	// an untracked file would never be swept, so it is not kept
+	if err := s.tracker.Register(key, size, contentType); err != nil {
+		if delErr := s.BlobStore.Delete(key); delErr != nil {
+			log.Printf("Failed to delete untracked file %s: %v", key, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *TrackedStore) Delete(key string) error {
+	key = NormalizeKey(key)
+	if err := s.BlobStore.Delete(key); err != nil {
+		return err
+	}
+	return s.tracker.Forget(key)
+}
@@ -1,25 +1,34 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID string, secretKey string) (string, error) {
-	log.Printf("Secret Key: %s", secretKey)
-
+// GenerateJWT issues a short-lived access token bound to a refresh token session
+func GenerateJWT(userID, sessionID string, secretKey string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -28,7 +37,6 @@ func GenerateJWT(userID string, secretKey string) (string, error) {
 		log.Printf("Error signing token: %v", err)
 		return "", err
 	}
-	log.Printf("Generated token: %s", tokenString)
 	return tokenString, nil
 }
 
@@ -50,3 +58,18 @@ func ParseTokenClaims(tokenString string, secretKey string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// GenerateOpaqueToken returns a random URL-safe token, used for refresh tokens
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hex digest of an opaque token so it is never stored in clear
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
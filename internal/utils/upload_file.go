@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/storage"
 	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -13,123 +16,106 @@ import (
 	"github.com/google/uuid"
 )
 
-func isValidImageExt(ext string) bool {
-	validExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-		".webp": true,
-	}
-	return validExts[ext]
+// HandleImageUpload stores the "image" form file under prefix and returns its key;
+// an empty key means the request carried no image. The file type is detected from
+// its content, and *media.UnsupportedMediaError / *media.TooLargeError report rejections.
+func HandleImageUpload(c *gin.Context, store storage.BlobStore, prefix string, limits media.Limits) (models.MediaKey, error) {
+	return handleUpload(c, store, "image", media.KindImage, prefix, limits)
 }
 
-func isValidVideoExt(ext string) bool {
-	validExts := map[string]bool{
-		".mp4":  true,
-		".mov":  true,
-		".avi":  true,
-		".wmv":  true,
-		".mkv":  true,
-		".webm": true,
-	}
-	return validExts[ext]
-}
+// ErrTooManyFiles is returned when a form carries more files than allowed
+var ErrTooManyFiles = errors.New("too many files")
 
-func HandleImageUpload(c *gin.Context, uploadPath string) (string, error) {
-	contentType := c.GetHeader("Content-Type")
-
-	if contentType == "application/json" {
-		return "", nil
-	}
+// UploadedMedia is a stored file and what was detected about it
+type UploadedMedia struct {
+	Key  models.MediaKey
+	Info *media.Info
+}
 
-	if !strings.Contains(contentType, "multipart/form-data") {
-		return "", nil
+// HandleMediaUploads stores every file of the multipart field, images under "images"
+// and videos under "videos", in the order they were sent. If one of them is refused
+// the files already stored are deleted.
+func HandleMediaUploads(c *gin.Context, store storage.BlobStore, field string, max int, limits media.Limits) ([]UploadedMedia, error) {
+	if !strings.Contains(c.GetHeader("Content-Type"), "multipart/form-data") {
+		return nil, nil
 	}
-
-	file, err := c.FormFile("image")
+	form, err := c.MultipartForm()
 	if err != nil {
-		if err == http.ErrMissingFile {
-			return "", nil
-		}
-		return "", err
+		return nil, err
 	}
 
-	if file.Size > 10*1024*1024 {
-		return "", errors.New("image file too large (max 10MB)")
+	files := form.File[field]
+	if len(files) > max {
+		return nil, fmt.Errorf("%w (max %d)", ErrTooManyFiles, max)
 	}
 
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if !isValidImageExt(ext) {
-		return "", errors.New("invalid image format")
-	}
-
-	uniqueID := uuid.New().String()
-	timestamp := time.Now().Format("20060102-150405")
-	safeFilename := fmt.Sprintf("%s_%s%s", timestamp, uniqueID, ext)
-
-	absPath, err := filepath.Abs(uploadPath)
-	if err != nil {
-		return "", errors.New("failed to get absolute path")
-	}
-
-	if err := os.MkdirAll(absPath, os.ModePerm); err != nil {
-		return "", errors.New("failed to create upload directory")
-	}
-
-	filePath := filepath.Join(absPath, safeFilename)
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		return "", errors.New("failed to upload image")
+	uploaded := make([]UploadedMedia, 0, len(files))
+	for _, file := range files {
+		key, info, err := storeFile(store, file, func(src io.ReadSeeker) (*media.Info, error) {
+			return media.InspectAny(src, file.Size, limits)
+		}, "")
+		if err != nil {
+			for _, u := range uploaded {
+				store.Delete(string(u.Key))
+			}
+			return nil, err
+		}
+		uploaded = append(uploaded, UploadedMedia{Key: key, Info: info})
 	}
-
-	return "/" + filepath.Join(uploadPath, safeFilename), nil
+	return uploaded, nil
 }
 
-func HandleVideoUpload(c *gin.Context, uploadPath string) (string, error) {
-	contentType := c.GetHeader("Content-Type")
-
-	if contentType == "application/json" {
-		return "", nil
+func handleUpload(c *gin.Context, store storage.BlobStore, field string, kind media.Kind, prefix string, limits media.Limits) (models.MediaKey, error) {
+	file, err := formFile(c, field)
+	if file == nil || err != nil {
+		return "", err
 	}
 
-	if !strings.Contains(contentType, "multipart/form-data") {
-		return "", nil
-	}
+	key, _, err := storeFile(store, file, func(src io.ReadSeeker) (*media.Info, error) {
+		return media.Inspect(src, file.Size, kind, limits)
+	}, prefix)
+	return key, err
+}
 
-	file, err := c.FormFile("video")
+// storeFile validates an uploaded file with inspect and puts it in the store under
+// prefix, or under the plural of its kind ("images", "videos") when prefix is empty
+func storeFile(store storage.BlobStore, file *multipart.FileHeader, inspect func(io.ReadSeeker) (*media.Info, error), prefix string) (models.MediaKey, *media.Info, error) {
+	src, err := file.Open()
 	if err != nil {
-		if err == http.ErrMissingFile {
-			return "", nil
-		}
-		return "", err
+		return "", nil, err
 	}
+	defer src.Close()
 
-	if file.Size > 100*1024*1024 {
-		return "", errors.New("video file too large (max 100MB)")
+	info, err := inspect(src)
+	if err != nil {
+		return "", nil, err
 	}
 
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if !isValidVideoExt(ext) {
-		return "", errors.New("invalid video format")
+	if prefix == "" {
+		prefix = string(info.Kind) + "s"
 	}
-
 	uniqueID := uuid.New().String()
 	timestamp := time.Now().Format("20060102-150405")
-	safeFilename := fmt.Sprintf("%s_%s%s", timestamp, uniqueID, ext)
+	key := fmt.Sprintf("%s/%s_%s%s", prefix, timestamp, uniqueID, info.Extension)
 
-	absPath, err := filepath.Abs(uploadPath)
-	if err != nil {
-		return "", errors.New("failed to get absolute path")
+	if err := store.Put(key, src, file.Size, info.MIME); err != nil {
+		return "", nil, fmt.Errorf("failed to upload %s", info.Kind)
 	}
+	return models.MediaKey(key), info, nil
+}
 
-	if err := os.MkdirAll(absPath, os.ModePerm); err != nil {
-		return "", errors.New("failed to create upload directory")
+// formFile returns the named multipart file, or nil when the request has none
+func formFile(c *gin.Context, field string) (*multipart.FileHeader, error) {
+	if !strings.Contains(c.GetHeader("Content-Type"), "multipart/form-data") {
+		return nil, nil
 	}
 
-	filePath := filepath.Join(absPath, safeFilename)
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		return "", errors.New("failed to upload video")
+	file, err := c.FormFile(field)
+	if err != nil {
+		if err == http.ErrMissingFile {
+			return nil, nil
+		}
+		return nil, err
 	}
-
-	return "/" + filepath.Join("uploads/videos", safeFilename), nil
+	return file, nil
 }
@@ -0,0 +1,97 @@
+package account_test
+
+import (
+	"GoVersi/internal/models"
+	services "GoVersi/internal/service"
+	"errors"
+	"testing"
+)
+
+func TestPasswordChangeKeepsTheCurrentSession(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	user := e.newUser(t, "ana@example.com", true)
+	current := e.login(t, "ana@example.com")
+	other := e.login(t, "ana@example.com")
+
+	err := e.account.ChangePassword(user.ID, sessionOf(t, current), "wrong password", "new password", services.RequestMeta{})
+	if !errors.Is(err, services.ErrWrongPassword) {
+		t.Fatalf("wrong current password: %v, want ErrWrongPassword", err)
+	}
+
+	if err := e.account.ChangePassword(user.ID, sessionOf(t, current), password, "new password", services.RequestMeta{IP: "203.0.113.7"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.users.RefreshTokens(current.RefreshToken); err != nil {
+		t.Fatalf("current session was revoked: %v", err)
+	}
+	if _, err := e.users.RefreshTokens(other.RefreshToken); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Fatalf("other session: %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := e.users.LoginUser("ana@example.com", "new password"); err != nil {
+		t.Fatalf("login with the new password: %v", err)
+	}
+
+	var events int64
+	e.db.Table("audit_events").Where("user_id = ? AND action = 'password_changed' AND ip = '203.0.113.7'", user.ID).Count(&events)
+	if events != 1 {
+		t.Fatalf("%d audit events, want 1", events)
+	}
+}
+
+func TestEmailChangeIsStagedUntilConfirmed(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	user := e.newUser(t, "ana@example.com", true)
+	e.newUser(t, "bia@example.com", true)
+
+	meta := services.RequestMeta{IP: "203.0.113.7"}
+	if err := e.account.RequestEmailChange(user.ID, "wrong password", "ana@new.example", meta); !errors.Is(err, services.ErrWrongPassword) {
+		t.Fatalf("wrong password: %v, want ErrWrongPassword", err)
+	}
+	if err := e.account.RequestEmailChange(user.ID, password, "bia@example.com", meta); !errors.Is(err, services.ErrEmailTaken) {
+		t.Fatalf("taken address: %v, want ErrEmailTaken", err)
+	}
+	if err := e.account.RequestEmailChange(user.ID, password, "ana@new.example", meta); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	var stored models.User
+	if err := e.db.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Email != "ana@example.com" {
+		t.Fatalf("email changed to %s before confirmation", stored.Email)
+	}
+
+	notice, confirmation := -1, -1
+	mails := e.emails(t)
+	for i, msg := range mails {
+		switch msg.To {
+		case "ana@example.com":
+			notice = i
+		case "ana@new.example":
+			confirmation = i
+		}
+	}
+	if len(mails) != 2 || notice < 0 || confirmation < 0 {
+		t.Fatalf("queued %+v, want a notice to the old address and a link to the new one", mails)
+	}
+
+	if err := e.account.ConfirmEmail(tokenIn(t, mails[confirmation]), meta); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if err := e.db.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Email != "ana@new.example" {
+		t.Fatalf("email = %s, want ana@new.example", stored.Email)
+	}
+	if _, err := e.users.LoginUser("ana@new.example", password); err != nil {
+		t.Fatalf("login with the new address: %v", err)
+	}
+
+	var actions []string
+	e.db.Table("audit_events").Where("user_id = ?", user.ID).Order("created_at").Pluck("action", &actions)
+	if len(actions) != 2 || actions[0] != string(models.AuditEmailChangeRequested) || actions[1] != string(models.AuditEmailChanged) {
+		t.Fatalf("audit trail %v", actions)
+	}
+}
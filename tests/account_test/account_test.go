@@ -0,0 +1,133 @@
+package account_test
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/service/email"
+	"GoVersi/internal/utils"
+	"GoVersi/tests/testdb"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	password  = "correct horse"
+	secretKey = "test-secret"
+)
+
+var linkToken = regexp.MustCompile(`[?&]token=([A-Za-z0-9_\-.~%]+)`)
+
+type env struct {
+	db      *gorm.DB
+	users   *services.UserService
+	account *services.AccountService
+}
+
+func accountConfig() config.AccountConfig {
+	return config.AccountConfig{
+		PasswordMinLength:               8,
+		PasswordResetTTL:                time.Hour,
+		PasswordResetInterval:           time.Minute,
+		EmailVerificationTTL:            24 * time.Hour,
+		EmailVerificationResendInterval: time.Minute,
+		EmailVerificationDailyLimit:     3,
+	}
+}
+
+func authConfig() config.AuthConfig {
+	return config.AuthConfig{SecretKey: secretKey, AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: 24 * time.Hour}
+}
+
+// setup wires the account services on a fresh database; emails stay in the outbox
+func setup(t *testing.T, account config.AccountConfig, auth config.AuthConfig) env {
+	t.Helper()
+
+	db := testdb.Open(t,
+		&models.User{}, &models.RefreshToken{}, &models.PasswordResetToken{},
+		&models.EmailVerificationToken{}, &models.OutboxEvent{}, &models.AuditEvent{},
+	)
+	mails, err := email.NewTemplates("https://goverse.example/", "en-US")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	tx := repository.NewTxManager(db)
+	verifier := services.NewEmailVerifier(mails, account)
+	tokens := services.NewTokenService(repository.NewRefreshTokenRepository(db), auth)
+
+	return env{
+		db:    db,
+		users: services.NewUserService(userRepo, tx, tokens, services.NewAuthorizer(userRepo), nil, nil, nil, mails, verifier),
+		account: services.NewAccountService(userRepo, repository.NewPasswordResetRepository(db),
+			repository.NewEmailVerificationRepository(db), verifier, tx, mails, account),
+	}
+}
+
+func (e env) newUser(t *testing.T, address string, verified bool) *models.User {
+	t.Helper()
+
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: address, Email: address, Password: hash, IsActive: true, IsEmailVerified: verified, Locale: "en-US"}
+	if err := e.db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func (e env) login(t *testing.T, address string) *services.TokenPair {
+	t.Helper()
+
+	pair, err := e.users.LoginUser(address, password)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	return pair
+}
+
+// emails returns the messages queued in the outbox, oldest first
+func (e env) emails(t *testing.T) []email.EmailMessage {
+	t.Helper()
+
+	var events []models.OutboxEvent
+	if err := e.db.Where("queue = ?", email.QueueName).Order("created_at").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	messages := make([]email.EmailMessage, len(events))
+	for i, event := range events {
+		if err := json.Unmarshal(event.Payload, &messages[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return messages
+}
+
+// tokenIn returns the token of the link carried by msg
+func tokenIn(t *testing.T, msg email.EmailMessage) string {
+	t.Helper()
+
+	match := linkToken.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no link in %q", msg.Body)
+	}
+	return match[1]
+}
+
+func sessionOf(t *testing.T, pair *services.TokenPair) string {
+	t.Helper()
+
+	claims, err := utils.ParseTokenClaims(pair.AccessToken, secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims.SessionID
+}
@@ -0,0 +1,83 @@
+package account_test
+
+import (
+	"GoVersi/internal/models"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestForgotPasswordDoesNotRevealAccounts(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	e.newUser(t, "ana@example.com", true)
+
+	if err := e.account.RequestPasswordReset("nobody@example.com"); err != nil {
+		t.Fatalf("unknown address: %v", err)
+	}
+	if n := len(e.emails(t)); n != 0 {
+		t.Fatalf("%d emails queued for an unknown address", n)
+	}
+
+	if err := e.account.RequestPasswordReset("ana@example.com"); err != nil {
+		t.Fatalf("known address: %v", err)
+	}
+	// throttled requests succeed silently too
+	if err := e.account.RequestPasswordReset("ana@example.com"); err != nil {
+		t.Fatalf("throttled request: %v", err)
+	}
+	mails := e.emails(t)
+	if len(mails) != 1 || mails[0].To != "ana@example.com" {
+		t.Fatalf("queued %+v, want one email to ana@example.com", mails)
+	}
+}
+
+func TestResetTokenIsSingleUse(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	e.newUser(t, "ana@example.com", true)
+	session := e.login(t, "ana@example.com")
+
+	if err := e.account.RequestPasswordReset("ana@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenIn(t, e.emails(t)[0])
+
+	if err := e.account.ResetPassword(token, "short"); !errors.Is(err, services.ErrWeakPassword) {
+		t.Fatalf("short password: %v, want ErrWeakPassword", err)
+	}
+	if err := e.account.ResetPassword(token, "new password"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := e.account.ResetPassword(token, "another password"); !errors.Is(err, services.ErrInvalidResetToken) {
+		t.Fatalf("second use: %v, want ErrInvalidResetToken", err)
+	}
+
+	if _, err := e.users.LoginUser("ana@example.com", "new password"); err != nil {
+		t.Fatalf("login with the new password: %v", err)
+	}
+	if _, err := e.users.RefreshTokens(session.RefreshToken); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Fatalf("session opened before the reset: %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestExpiredResetTokenIsRejected(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	user := e.newUser(t, "ana@example.com", true)
+
+	token := "expired-token"
+	expired := models.PasswordResetToken{UserID: user.ID, TokenHash: utils.HashToken(token), ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := e.db.Create(&expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.account.ResetPassword(token, "new password"); !errors.Is(err, services.ErrInvalidResetToken) {
+		t.Fatalf("expired token: %v, want ErrInvalidResetToken", err)
+	}
+	if err := e.account.ResetPassword("unknown-token", "new password"); !errors.Is(err, services.ErrInvalidResetToken) {
+		t.Fatalf("unknown token: %v, want ErrInvalidResetToken", err)
+	}
+	if _, err := e.users.LoginUser("ana@example.com", password); err != nil {
+		t.Fatalf("password changed by a rejected token: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package account_test
+
+import (
+	services "GoVersi/internal/service"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	e.newUser(t, "ana@example.com", true)
+	first := e.login(t, "ana@example.com")
+
+	second, err := e.users.RefreshTokens(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("refresh did not issue a new pair")
+	}
+	if sessionOf(t, second) != sessionOf(t, first) {
+		t.Fatal("rotation left the session")
+	}
+	if _, err := e.users.RefreshTokens(second.RefreshToken); err != nil {
+		t.Fatalf("refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesTheFamily(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	e.newUser(t, "ana@example.com", true)
+	stolen := e.login(t, "ana@example.com")
+
+	current, err := e.users.RefreshTokens(stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := e.login(t, "ana@example.com")
+
+	if _, err := e.users.RefreshTokens(stolen.RefreshToken); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("reusing a rotated token: %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := e.users.RefreshTokens(current.RefreshToken); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Fatalf("latest token of the family: %v, want ErrInvalidRefreshToken", err)
+	}
+	if _, err := e.users.RefreshTokens(other.RefreshToken); err != nil {
+		t.Fatalf("another session was revoked: %v", err)
+	}
+}
+
+func TestExpiredRefreshTokenIsRejected(t *testing.T) {
+	auth := authConfig()
+	auth.RefreshTokenTTL = -time.Minute
+	e := setup(t, accountConfig(), auth)
+	e.newUser(t, "ana@example.com", true)
+	pair := e.login(t, "ana@example.com")
+
+	if _, err := e.users.RefreshTokens(pair.RefreshToken); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Fatalf("expired token: %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutRevokesTheSession(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	e.newUser(t, "ana@example.com", true)
+	pair := e.login(t, "ana@example.com")
+
+	if err := e.users.Logout(sessionOf(t, pair)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.users.RefreshTokens(pair.RefreshToken); !errors.Is(err, services.ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after logout: %v, want ErrInvalidRefreshToken", err)
+	}
+}
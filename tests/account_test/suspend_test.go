@@ -0,0 +1,49 @@
+package account_test
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (e env) suspend(t *testing.T, actor *models.User, id string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handlers.SetUserService(e.users)
+
+	router := gin.New()
+	router.PATCH("/users/:id/suspend", func(c *gin.Context) { c.Set("user_id", actor.ID.String()) }, handlers.SuspendUser)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/users/"+id+"/suspend", nil))
+	return w.Code
+}
+
+func TestSuspendUser(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	moderator := e.newUser(t, "mod@example.com", true)
+	if err := e.db.Model(moderator).Update("role", models.RoleModerator).Error; err != nil {
+		t.Fatal(err)
+	}
+	member := e.newUser(t, "ana@example.com", true)
+
+	for _, tc := range []struct {
+		name  string
+		actor *models.User
+		id    string
+		want  int
+	}{
+		{"unknown user", moderator, uuid.NewString(), http.StatusNotFound},
+		{"malformed id", moderator, "not-a-uuid", http.StatusBadRequest},
+		{"member suspending a moderator", member, moderator.ID.String(), http.StatusForbidden},
+		{"moderator suspending a member", moderator, member.ID.String(), http.StatusOK},
+	} {
+		if got := e.suspend(t, tc.actor, tc.id); got != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
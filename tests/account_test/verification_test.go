@@ -0,0 +1,83 @@
+package account_test
+
+import (
+	"GoVersi/internal/models"
+	services "GoVersi/internal/service"
+	"errors"
+	"testing"
+)
+
+func TestResendVerificationIsThrottled(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	e.newUser(t, "ana@example.com", false)
+	e.newUser(t, "bia@example.com", true)
+
+	for i := 0; i < 3; i++ {
+		if err := e.account.ResendVerification("ana@example.com"); err != nil {
+			t.Fatalf("resend %d: %v", i, err)
+		}
+	}
+	// verified and unknown addresses get nothing, and are not told apart
+	for _, address := range []string{"bia@example.com", "nobody@example.com"} {
+		if err := e.account.ResendVerification(address); err != nil {
+			t.Fatalf("resend to %s: %v", address, err)
+		}
+	}
+
+	mails := e.emails(t)
+	if len(mails) != 1 || mails[0].To != "ana@example.com" {
+		t.Fatalf("queued %+v, want one email to ana@example.com", mails)
+	}
+}
+
+func TestResendVerificationDailyLimit(t *testing.T) {
+	account := accountConfig()
+	account.EmailVerificationResendInterval = 0
+	e := setup(t, account, authConfig())
+	e.newUser(t, "ana@example.com", false)
+
+	for i := 0; i < account.EmailVerificationDailyLimit+2; i++ {
+		if err := e.account.ResendVerification("ana@example.com"); err != nil {
+			t.Fatalf("resend %d: %v", i, err)
+		}
+	}
+	if n := len(e.emails(t)); n != account.EmailVerificationDailyLimit {
+		t.Fatalf("%d emails queued, want %d", n, account.EmailVerificationDailyLimit)
+	}
+}
+
+func TestConfirmationVerifiesOnce(t *testing.T) {
+	e := setup(t, accountConfig(), authConfig())
+	user := e.newUser(t, "ana@example.com", false)
+
+	if err := e.account.ResendVerification("ana@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := tokenIn(t, e.emails(t)[0])
+
+	if err := e.account.ConfirmEmail(token, services.RequestMeta{}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if err := e.account.ConfirmEmail(token, services.RequestMeta{}); !errors.Is(err, services.ErrInvalidVerificationToken) {
+		t.Fatalf("second confirmation: %v, want ErrInvalidVerificationToken", err)
+	}
+
+	var stored models.User
+	if err := e.db.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.IsEmailVerified {
+		t.Fatal("email not verified")
+	}
+}
+
+func TestUnverifiedLoginIsGated(t *testing.T) {
+	account := accountConfig()
+	account.RequireVerifiedEmailToLogin = true
+	e := setup(t, account, authConfig())
+	e.newUser(t, "ana@example.com", false)
+
+	if _, err := e.users.LoginUser("ana@example.com", password); !errors.Is(err, services.ErrEmailNotVerified) {
+		t.Fatalf("login before verification: %v, want ErrEmailNotVerified", err)
+	}
+}
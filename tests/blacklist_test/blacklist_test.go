@@ -0,0 +1,141 @@
+package blacklist_test
+
+import (
+	"GoVersi/internal/middleware"
+	"GoVersi/internal/models"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"GoVersi/tests/testdb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func setup(t *testing.T, negativeTTL time.Duration) (*gorm.DB, *services.TokenBlacklistService) {
+	db := testdb.Open(t, &models.TokenBlacklist{})
+	return db, services.NewTokenBlacklistService(db, 100, negativeTTL)
+}
+
+// revokeElsewhere blacklists the token the way another instance does, bypassing this cache
+func revokeElsewhere(t *testing.T, db *gorm.DB, token string, expiresAt time.Time) {
+	t.Helper()
+	if err := db.Create(&models.TokenBlacklist{Token: token, ExpiresAt: expiresAt}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func blacklisted(t *testing.T, s *services.TokenBlacklistService, token string, expiresAt time.Time) bool {
+	t.Helper()
+	revoked, err := s.IsTokenBlacklisted(token, expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return revoked
+}
+
+func TestRevocationsOfOtherInstancesShowUpAfterTheNegativeTTL(t *testing.T) {
+	db, s := setup(t, 100*time.Millisecond)
+	expiresAt := time.Now().Add(time.Hour)
+
+	if blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("fresh token blacklisted")
+	}
+	revokeElsewhere(t, db, "token", expiresAt)
+
+	if blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("negative answer not cached")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if !blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("revocation hidden after the negative TTL")
+	}
+}
+
+func TestNegativeAnswersDoNotOutliveTheToken(t *testing.T) {
+	db, s := setup(t, time.Hour)
+	expiresAt := time.Now().Add(100 * time.Millisecond)
+
+	if blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("fresh token blacklisted")
+	}
+	revokeElsewhere(t, db, "token", expiresAt)
+
+	time.Sleep(150 * time.Millisecond)
+	if !blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("negative answer cached past the token expiry")
+	}
+}
+
+func TestRevocationsAreCachedUntilTheTokenExpires(t *testing.T) {
+	db, s := setup(t, 10*time.Millisecond)
+	expiresAt := time.Now().Add(200 * time.Millisecond)
+
+	if err := s.AddToTokenBlacklist("token", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	// the row is gone but the cached revocation stands, past the negative TTL
+	if err := db.Delete(&models.TokenBlacklist{}, "token = ?", "token").Error; err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("revocation not served from the cache")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if blacklisted(t, s, "token", expiresAt) {
+		t.Fatal("revocation cached past the token expiry")
+	}
+}
+
+func TestRemoveExpiredTokens(t *testing.T) {
+	db, s := setup(t, time.Minute)
+	revokeElsewhere(t, db, "expired", time.Now().Add(-time.Minute))
+	revokeElsewhere(t, db, "live", time.Now().Add(time.Hour))
+
+	if err := s.RemoveExpiredTokens(); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	db.Model(&models.TokenBlacklist{}).Pluck("token", &tokens)
+	if len(tokens) != 1 || tokens[0] != "live" {
+		t.Fatalf("tokens left %v", tokens)
+	}
+}
+
+func TestAuthMiddlewareRejectsRevokedTokens(t *testing.T) {
+	_, s := setup(t, time.Minute)
+	const secretKey = "blacklist-test"
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", middleware.AuthMiddleware(secretKey, s), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	token, err := utils.GenerateJWT(uuid.NewString(), uuid.NewString(), secretKey, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := call(); code != http.StatusNoContent {
+		t.Fatalf("valid token: status %d", code)
+	}
+	// a logout on this instance takes effect at once despite the cached answer
+	if err := s.AddToTokenBlacklist(token, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if code := call(); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: status %d", code)
+	}
+}
@@ -0,0 +1,66 @@
+package blacklist_test
+
+import (
+	"GoVersi/internal/cache"
+	"testing"
+	"time"
+)
+
+func TestEntriesExpireIndividually(t *testing.T) {
+	c := cache.NewTTLCache[string, bool](10)
+	c.Set("short", true, time.Now().Add(20*time.Millisecond))
+	c.Set("long", true, time.Now().Add(time.Hour))
+
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Fatal("expired entry returned")
+	}
+	if v, ok := c.Get("long"); !ok || !v {
+		t.Fatal("live entry missing")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expired entry kept after a miss: %d entries", c.Len())
+	}
+}
+
+func TestSetReplacesValueAndExpiry(t *testing.T) {
+	c := cache.NewTTLCache[string, bool](10)
+	c.Set("token", false, time.Now().Add(10*time.Millisecond))
+	c.Set("token", true, time.Now().Add(time.Hour))
+
+	time.Sleep(20 * time.Millisecond)
+
+	if v, ok := c.Get("token"); !ok || !v {
+		t.Fatalf("got %v, %v; want the replaced entry", v, ok)
+	}
+}
+
+func TestLeastRecentlyUsedIsEvicted(t *testing.T) {
+	c := cache.NewTTLCache[string, int](2)
+	later := time.Now().Add(time.Hour)
+	c.Set("a", 1, later)
+	c.Set("b", 2, later)
+	c.Get("a")
+	c.Set("c", 3, later)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("%s evicted", key)
+		}
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	c := cache.NewTTLCache[string, bool](10)
+	c.Set("a", true, time.Now().Add(-time.Second))
+	c.Set("b", true, time.Now().Add(-time.Second))
+	c.Set("c", true, time.Now().Add(time.Hour))
+
+	if removed := c.PurgeExpired(); removed != 2 || c.Len() != 1 {
+		t.Fatalf("removed %d, %d left", removed, c.Len())
+	}
+}
@@ -0,0 +1,142 @@
+package dead_letter_test
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/service/email"
+	"GoVersi/tests/testdb"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func setup(t *testing.T) (*gorm.DB, *services.DeadLetterService, *models.User) {
+	db := testdb.Open(t, &models.User{}, &models.DeadLetter{}, &models.OutboxEvent{})
+	admin := &models.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: models.RoleAdmin, IsActive: true}
+	if err := db.Create(admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := services.NewDeadLetterService(repository.NewDeadLetterRepository(db), repository.NewTxManager(db), services.NewAuthorizer(repository.NewUserRepository(db)))
+	return db, service, admin
+}
+
+func resetEmail(t *testing.T) []byte {
+	body, err := json.Marshal(email.EmailMessage{
+		To:      "ana@example.com",
+		Subject: "Reset your password",
+		Body:    "Open https://goverse.example/reset-password?token=secret-token to continue",
+		HTML:    `<a href="https://goverse.example/reset-password?token=secret-token">reset</a>`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestArchivedEmailsHaveTheirLinksRedacted(t *testing.T) {
+	db, service, admin := setup(t)
+
+	if err := service.Archive(queue.DeadLetter{Queue: email.QueueName, Body: resetEmail(t), Error: "550"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored models.DeadLetter
+	if err := db.First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored.Payload, []byte("secret-token")) || !stored.Redacted {
+		t.Fatalf("archived letter keeps the token: %s", stored.Payload)
+	}
+
+	view, err := service.Get(admin.ID, stored.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(view.Payload, "secret-token") {
+		t.Fatalf("view shows the token: %s", view.Payload)
+	}
+
+	if _, err := service.Replay(admin.ID, stored.ID); !errors.Is(err, services.ErrRedactedLetter) {
+		t.Fatalf("replay of a redacted letter: %v, want ErrRedactedLetter", err)
+	}
+}
+
+func TestLettersWithoutLinksCanBeReplayed(t *testing.T) {
+	db, service, admin := setup(t)
+
+	body := []byte(`{"to":"ana@example.com","subject":"Digest","body":"3 new comments"}`)
+	if err := service.Archive(queue.DeadLetter{Queue: email.QueueName, Body: body}); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.DeadLetter
+	if err := db.First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Replay(admin.ID, stored.ID); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	var event models.OutboxEvent
+	if err := db.First(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(event.Payload, body) {
+		t.Fatalf("replayed %s, want %s", event.Payload, body)
+	}
+}
+
+func TestLegacyArchivedLinksAreRedacted(t *testing.T) {
+	db, _, _ := setup(t)
+
+	legacy := models.DeadLetter{Queue: email.QueueName, Payload: resetEmail(t)}
+	if err := db.Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.RedactArchivedLinks(db, email.QueueName, email.RedactedToken); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored models.DeadLetter
+	if err := db.First(&stored, "id = ?", legacy.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored.Payload, []byte("secret-token")) || !stored.Redacted {
+		t.Fatalf("legacy letter keeps the token: %s", stored.Payload)
+	}
+	var msg email.EmailMessage
+	if err := json.Unmarshal(stored.Payload, &msg); err != nil || !strings.Contains(msg.Body, "token="+email.RedactedToken) {
+		t.Fatalf("redacted payload %s is not the expected email: %v", stored.Payload, err)
+	}
+}
+
+func TestSettledOutboxEventsKeepNoPayload(t *testing.T) {
+	db, _, _ := setup(t)
+
+	outbox := repository.NewOutboxRepository(db)
+	if err := email.NewEmailQueueService(outbox).PublishEmail(email.EmailMessage{To: "ana@example.com", Body: "?token=secret-token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	q := queue.NewMemoryQueue()
+	t.Cleanup(q.Close)
+	relay := services.NewOutboxRelay(outbox, q, config.OutboxConfig{BatchSize: 10, MaxAttempts: 3, RetryBase: time.Second, RetryMax: time.Second})
+	if sent, err := relay.RelayBatch(); err != nil || sent != 1 {
+		t.Fatalf("relayed %d events: %v", sent, err)
+	}
+
+	var event models.OutboxEvent
+	if err := db.First(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	if event.Status != models.OutboxSent || len(event.Payload) != 0 {
+		t.Fatalf("sent event kept its payload: %+v", event)
+	}
+}
@@ -0,0 +1,171 @@
+package dead_letter_test
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/service/email"
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// broker fails every publication while down and records the others
+type broker struct {
+	mu        sync.Mutex
+	down      bool
+	published map[string][][]byte
+}
+
+func (b *broker) Publish(queueName string, body []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.down {
+		return errors.New("connection refused")
+	}
+	b.published[queueName] = append(b.published[queueName], body)
+	return nil
+}
+
+func (b *broker) setDown(down bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.down = down
+}
+
+// failedOutbox queues an image job and a reset email, then lets the relay give up on both
+func failedOutbox(t *testing.T, db *gorm.DB) (*services.OutboxRelay, *broker) {
+	t.Helper()
+
+	outbox := repository.NewOutboxRepository(db)
+	if err := outbox.Publish(media.ImageProcessingQueue, []byte(`{"target":"post_media"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := outbox.Publish(email.QueueName, resetEmail(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &broker{down: true, published: map[string][][]byte{}}
+	relay := services.NewOutboxRelay(outbox, b, config.OutboxConfig{BatchSize: 10, MaxAttempts: 1, RetryBase: time.Second, RetryMax: time.Second})
+	for i := 0; i < 2; i++ {
+		if _, err := relay.RelayBatch(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return relay, b
+}
+
+func event(t *testing.T, db *gorm.DB, queueName string) models.OutboxEvent {
+	t.Helper()
+	var stored models.OutboxEvent
+	if err := db.First(&stored, "queue = ?", queueName).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func TestFailedOutboxEventsKeepTheirPayload(t *testing.T) {
+	db, _, _ := setup(t)
+	failedOutbox(t, db)
+
+	job := event(t, db, media.ImageProcessingQueue)
+	if job.Status != models.OutboxFailed || string(job.Payload) != `{"target":"post_media"}` || job.Redacted {
+		t.Fatalf("failed image job lost its payload: %+v", job)
+	}
+
+	mail := event(t, db, email.QueueName)
+	if mail.Status != models.OutboxFailed || !mail.Redacted || bytes.Contains(mail.Payload, []byte("secret-token")) {
+		t.Fatalf("failed email keeps its link: %+v", mail)
+	}
+	if !bytes.Contains(mail.Payload, []byte("token="+email.RedactedToken)) {
+		t.Fatalf("failed email lost its payload: %s", mail.Payload)
+	}
+}
+
+func TestFailedOutboxEventsCanBeReplayed(t *testing.T) {
+	db, service, admin := setup(t)
+	relay, b := failedOutbox(t, db)
+
+	member := &models.User{Username: "ana", Email: "ana@example.com", Password: "x", IsActive: true}
+	if err := db.Create(member).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ReplayOutbox(member.ID, ""); !errors.Is(err, services.ErrForbidden) {
+		t.Fatalf("member replay: %v, want ErrForbidden", err)
+	}
+
+	replayed, err := service.ReplayOutbox(admin.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 1 {
+		t.Fatalf("replayed %d events, want only the image job", replayed)
+	}
+
+	b.setDown(false)
+	if sent, err := relay.RelayBatch(); err != nil || sent != 1 {
+		t.Fatalf("relayed %d events: %v", sent, err)
+	}
+	if jobs := b.published[media.ImageProcessingQueue]; len(jobs) != 1 || string(jobs[0]) != `{"target":"post_media"}` {
+		t.Fatalf("published %q", jobs)
+	}
+	if len(b.published[email.QueueName]) != 0 {
+		t.Fatal("redacted email was published")
+	}
+	if mail := event(t, db, email.QueueName); mail.Status != models.OutboxFailed {
+		t.Fatalf("redacted email status %s, want failed", mail.Status)
+	}
+}
+
+func TestReplayOutboxOfOneQueue(t *testing.T) {
+	db, service, admin := setup(t)
+	failedOutbox(t, db)
+
+	replayed, err := service.ReplayOutbox(admin.ID, email.QueueName)
+	if err != nil || replayed != 0 {
+		t.Fatalf("replayed %d email events: %v", replayed, err)
+	}
+	if job := event(t, db, media.ImageProcessingQueue); job.Status != models.OutboxFailed {
+		t.Fatalf("image job of another queue replayed: %+v", job)
+	}
+}
+
+func TestScrubSettledPayloadsOnlyTouchesEmails(t *testing.T) {
+	db, _, _ := setup(t)
+
+	legacy := []models.OutboxEvent{
+		{Queue: email.QueueName, Payload: resetEmail(t), Status: models.OutboxSent, NextAttemptAt: time.Now()},
+		{Queue: email.QueueName, Payload: resetEmail(t), Status: models.OutboxFailed, NextAttemptAt: time.Now()},
+		{Queue: media.ImageProcessingQueue, Payload: []byte(`{"target":"post_media"}`), Status: models.OutboxFailed, NextAttemptAt: time.Now()},
+	}
+	if err := db.Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.ScrubSettledPayloads(db, email.QueueName, email.RedactedToken); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored []models.OutboxEvent
+	if err := db.Order("created_at").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]models.OutboxEvent{}
+	for _, e := range stored {
+		byID[e.ID.String()] = e
+	}
+	if sent := byID[legacy[0].ID.String()]; len(sent.Payload) != 0 {
+		t.Errorf("sent email kept its payload: %s", sent.Payload)
+	}
+	if failed := byID[legacy[1].ID.String()]; !failed.Redacted || bytes.Contains(failed.Payload, []byte("secret-token")) || len(failed.Payload) == 0 {
+		t.Errorf("failed email not redacted: %+v", failed)
+	}
+	if job := byID[legacy[2].ID.String()]; string(job.Payload) != `{"target":"post_media"}` || job.Redacted {
+		t.Errorf("image job was scrubbed: %+v", job)
+	}
+}
@@ -0,0 +1,253 @@
+package email_flow_test
+
+import (
+	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/models"
+	"GoVersi/internal/service/email"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender fails the first failures attempts with err, then captures the emails
+type fakeSender struct {
+	*email.MemoryMailer
+	mu       sync.Mutex
+	failures int
+	err      error
+	attempts int
+	sent     chan email.EmailMessage
+}
+
+func newFakeSender(failures int, err error) *fakeSender {
+	return &fakeSender{MemoryMailer: email.NewMemoryMailer(), failures: failures, err: err, sent: make(chan email.EmailMessage, 10)}
+}
+
+func (s *fakeSender) Send(msg email.EmailMessage) error {
+	s.mu.Lock()
+	s.attempts++
+	failing := s.attempts <= s.failures
+	s.mu.Unlock()
+
+	if failing {
+		return s.err
+	}
+	if err := s.MemoryMailer.Send(msg); err != nil {
+		return err
+	}
+	s.sent <- msg
+	return nil
+}
+
+func (s *fakeSender) Attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+var policy = queue.RetryPolicy{MaxRetries: 3, BaseDelay: 10 * time.Millisecond}
+
+// confirmation renders the confirmation email of a user with the given locale
+func confirmation(t *testing.T, address, locale string) email.EmailMessage {
+	t.Helper()
+
+	mails, err := email.NewTemplates("https://goverse.example/", "pt-BR")
+	if err != nil {
+		t.Fatalf("templates: %v", err)
+	}
+	msg, err := mails.Confirmation(&models.User{Username: "ana", Email: address, Locale: locale}, "token-123")
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	return msg
+}
+
+// startFlow runs the email worker and the dead-letter consumer on an in-memory queue
+func startFlow(t *testing.T, mailer email.Mailer) (email.EmailQueueService, <-chan queue.DeadLetter) {
+	t.Helper()
+
+	q := queue.NewMemoryQueue()
+	t.Cleanup(q.Close)
+
+	deadLetters := make(chan queue.DeadLetter, 10)
+	go email.RunWorker(q, mailer, policy, 2)
+	go q.WorkDeadLetters(email.QueueName, func(letter queue.DeadLetter) error {
+		deadLetters <- letter
+		return nil
+	})
+
+	return email.NewEmailQueueService(q), deadLetters
+}
+
+func TestConfirmationEmailIsDelivered(t *testing.T) {
+	sender := newFakeSender(0, nil)
+	emails, _ := startFlow(t, sender)
+
+	if err := emails.PublishEmail(confirmation(t, "ana@example.com", "en-US")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case msg := <-sender.sent:
+		if msg.To != "ana@example.com" {
+			t.Errorf("sent to %q, want ana@example.com", msg.To)
+		}
+		if msg.Subject != "Confirm your GoVerse email" {
+			t.Errorf("subject = %q, want the English template", msg.Subject)
+		}
+		link := "https://goverse.example/confirm-email?token=token-123"
+		if !strings.Contains(msg.Body, link) || !strings.Contains(msg.HTML, link) {
+			t.Errorf("confirmation link missing from %q", msg.Body)
+		}
+		if n := len(sender.Messages()); n != 1 {
+			t.Errorf("captured %d emails, want 1", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("email was not delivered")
+	}
+}
+
+func TestTransientFailureIsRetried(t *testing.T) {
+	sender := newFakeSender(2, errors.New("connection refused"))
+	emails, deadLetters := startFlow(t, sender)
+
+	if err := emails.PublishEmail(confirmation(t, "ana@example.com", "en-US")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case <-sender.sent:
+	case letter := <-deadLetters:
+		t.Fatalf("email was dead-lettered: %s", letter.Error)
+	case <-time.After(2 * time.Second):
+		t.Fatal("email was not delivered")
+	}
+	if got := sender.Attempts(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestExhaustedRetriesAreDeadLettered(t *testing.T) {
+	sender := newFakeSender(100, errors.New("connection refused"))
+	emails, deadLetters := startFlow(t, sender)
+
+	if err := emails.PublishEmail(confirmation(t, "ana@example.com", "en-US")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case letter := <-deadLetters:
+		if letter.Retries != policy.MaxRetries+1 || letter.Error != "connection refused" {
+			t.Errorf("dead letter = %+v", letter)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("email was not dead-lettered")
+	}
+	if got := sender.Attempts(); got != policy.MaxRetries+1 {
+		t.Errorf("attempts = %d, want %d", got, policy.MaxRetries+1)
+	}
+}
+
+func TestPermanentRejectionIsNotRetried(t *testing.T) {
+	sender := newFakeSender(100, &textproto.Error{Code: 550, Msg: "mailbox unavailable"})
+	emails, deadLetters := startFlow(t, sender)
+
+	if err := emails.PublishEmail(confirmation(t, "nobody@example.com", "")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	select {
+	case letter := <-deadLetters:
+		if letter.Queue != email.QueueName {
+			t.Errorf("dead letter queue = %q", letter.Queue)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("email was not dead-lettered")
+	}
+	if got := sender.Attempts(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestMIMEHasTextAndHTMLAlternatives(t *testing.T) {
+	msg := confirmation(t, "ana@example.com", "pt")
+
+	data, err := email.BuildMIME("GoVerse <no-reply@goverse.example>", msg, time.Now())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Confirmação de Registro" {
+		t.Errorf("subject = %q (%v), want the Portuguese template", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q (%v)", mediaType, err)
+	}
+
+	var types []string
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("part: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		if !strings.Contains(string(body), "token-123") {
+			t.Errorf("%s part lacks the link", part.Header.Get("Content-Type"))
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("parts = %v, want text/plain then text/html", types)
+	}
+}
+
+func TestDeadLetteredLinksCanBeRedacted(t *testing.T) {
+	sender := newFakeSender(100, errors.New("connection refused"))
+	emails, deadLetters := startFlow(t, sender)
+
+	if err := emails.PublishEmail(confirmation(t, "ana@example.com", "en-US")); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	var letter queue.DeadLetter
+	select {
+	case letter = <-deadLetters:
+	case <-time.After(2 * time.Second):
+		t.Fatal("email was not dead-lettered")
+	}
+
+	body, redacted := email.RedactLinks(letter.Body)
+	if !redacted {
+		t.Fatal("confirmation link was not redacted")
+	}
+	if bytes.Contains(body, []byte("token-123")) {
+		t.Fatalf("token survived redaction: %s", body)
+	}
+	if !bytes.Contains(body, []byte("confirm-email?token="+email.RedactedToken)) {
+		t.Fatalf("redacted link missing: %s", body)
+	}
+
+	plain := []byte(`{"to":"ana@example.com","subject":"Hi","body":"no links here"}`)
+	if out, redacted := email.RedactLinks(plain); redacted || !bytes.Equal(out, plain) {
+		t.Fatalf("email without links was changed: %s", out)
+	}
+}
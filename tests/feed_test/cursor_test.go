@@ -0,0 +1,56 @@
+package feed_test
+
+import (
+	"GoVersi/internal/utils"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	cursor, err := utils.DecodeCursor(utils.EncodeCursor(createdAt, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cursor.CreatedAt.Equal(createdAt) || cursor.ID != id {
+		t.Fatalf("decoded %+v", cursor)
+	}
+}
+
+func TestEmptyCursorIsTheFirstPage(t *testing.T) {
+	cursor, err := utils.DecodeCursor("")
+	if err != nil || cursor != nil {
+		t.Fatalf("got %+v, %v", cursor, err)
+	}
+}
+
+func TestMalformedCursorsAreRefused(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	for _, cursor := range []string{
+		"not base64!",
+		encode("1714566600000000000"),
+		encode("yesterday|" + uuid.NewString()),
+		encode("1714566600000000000|not-a-uuid"),
+		utils.EncodeOffsetCursor(20),
+	} {
+		if _, err := utils.DecodeCursor(cursor); !errors.Is(err, utils.ErrInvalidCursor) {
+			t.Errorf("cursor %q: %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	cases := map[string]int{"": 20, "abc": 20, "0": 20, "-5": 20, "7": 7, "100": 100, "500": 100}
+	for value, want := range cases {
+		if got := utils.ParseLimit(value, 20, 100); got != want {
+			t.Errorf("ParseLimit(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
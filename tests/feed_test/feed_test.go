@@ -0,0 +1,214 @@
+package feed_test
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"GoVersi/tests/testdb"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type env struct {
+	db   *gorm.DB
+	feed *services.FeedService
+}
+
+func setup(t *testing.T) *env {
+	db := testdb.Open(t, &models.User{}, &models.Friendship{}, &models.Post{}, &models.PostMedia{}, &models.Comment{}, &models.Reaction{})
+	feed := services.NewFeedService(repository.NewPostRepository(db), repository.NewFriendshipRepository(db), repository.NewUserRepository(db),
+		repository.NewReactionRepository(db), repository.NewCommentRepository(db))
+	return &env{db: db, feed: feed}
+}
+
+func (e *env) user(t *testing.T, name string) uuid.UUID {
+	t.Helper()
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "x", IsActive: true}
+	if err := e.db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func (e *env) befriend(t *testing.T, a, b uuid.UUID, status models.FriendshipStatus) {
+	t.Helper()
+	if err := e.db.Create(&models.Friendship{RequesterID: a, AddresseeID: b, Status: status}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (e *env) post(t *testing.T, author uuid.UUID, topic string, createdAt time.Time) *models.Post {
+	t.Helper()
+	post := &models.Post{Title: "post", Topic: topic, AuthorID: author, CreatedAt: createdAt}
+	if err := e.db.Create(post).Error; err != nil {
+		t.Fatal(err)
+	}
+	return post
+}
+
+// newestFirst tells whether b comes right after a in (created_at, id) descending order
+func newestFirst(a, b models.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
+
+// walk reads every page and checks that no post is skipped, repeated or out of order
+func walk(t *testing.T, load func(cursor string) (*services.FeedPage, error)) []services.FeedItem {
+	t.Helper()
+	var all []services.FeedItem
+	seen := map[uuid.UUID]bool{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			t.Fatal("pagination does not end")
+		}
+		page, err := load(cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Fatalf("post %s listed twice", item.ID)
+			}
+			seen[item.ID] = true
+			if n := len(all); n > 0 && !newestFirst(all[n-1].Post, item.Post) {
+				t.Fatalf("post %s listed out of order", item.ID)
+			}
+			all = append(all, item)
+		}
+		if page.NextCursor == "" {
+			return all
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// posts sharing a created_at across a page boundary are neither skipped nor repeated
+func TestHomeFeedPagesThroughTies(t *testing.T) {
+	e := setup(t)
+	me, friend := e.user(t, "me"), e.user(t, "friend")
+	pending, stranger := e.user(t, "pending"), e.user(t, "stranger")
+	e.befriend(t, friend, me, models.StatusAccepted)
+	e.befriend(t, me, pending, models.StatusPending)
+
+	tie := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	want := 0
+	for i := 0; i < 5; i++ {
+		e.post(t, me, "", tie)
+		e.post(t, friend, "", tie)
+		want += 2
+	}
+	e.post(t, friend, "", tie.Add(time.Minute))
+	e.post(t, me, "", tie.Add(-time.Minute))
+	want += 2
+	e.post(t, pending, "", tie)
+	e.post(t, stranger, "", tie)
+
+	for _, limit := range []int{1, 3, 4, 20} {
+		items := walk(t, func(cursor string) (*services.FeedPage, error) {
+			return e.feed.GetHomeFeed(me, cursor, limit)
+		})
+		if len(items) != want {
+			t.Fatalf("limit %d: listed %d posts, want %d", limit, len(items), want)
+		}
+		for _, item := range items {
+			if item.AuthorID != me && item.AuthorID != friend {
+				t.Fatalf("post of %s in the home feed", item.AuthorID)
+			}
+		}
+	}
+}
+
+func TestLastPageHasNoCursor(t *testing.T) {
+	e := setup(t)
+	me := e.user(t, "me")
+	for i := 0; i < 4; i++ {
+		e.post(t, me, "", time.Now().Add(-time.Duration(i)*time.Minute))
+	}
+
+	page, err := e.feed.GetHomeFeed(me, "", 4)
+	if err != nil || len(page.Items) != 4 || page.NextCursor != "" {
+		t.Fatalf("exact page: %d items, cursor %q, %v", len(page.Items), page.NextCursor, err)
+	}
+	if page, err = e.feed.GetHomeFeed(me, "", 3); err != nil || len(page.Items) != 3 || page.NextCursor == "" {
+		t.Fatalf("partial page: %d items, cursor %q, %v", len(page.Items), page.NextCursor, err)
+	}
+	last, err := e.feed.GetHomeFeed(me, page.NextCursor, 3)
+	if err != nil || len(last.Items) != 1 || last.NextCursor != "" {
+		t.Fatalf("last page: %d items, cursor %q, %v", len(last.Items), last.NextCursor, err)
+	}
+}
+
+func TestEmptyFeed(t *testing.T) {
+	e := setup(t)
+
+	page, err := e.feed.GetHomeFeed(e.user(t, "me"), "", 10)
+	if err != nil || page.Items == nil || len(page.Items) != 0 || page.NextCursor != "" {
+		t.Fatalf("page %+v, %v", page, err)
+	}
+}
+
+func TestInvalidCursorIsRefused(t *testing.T) {
+	e := setup(t)
+
+	if _, err := e.feed.GetHomeFeed(uuid.New(), "garbage", 10); !errors.Is(err, utils.ErrInvalidCursor) {
+		t.Fatalf("got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestTopicFeedPagesThroughTies(t *testing.T) {
+	e := setup(t)
+	author := e.user(t, "author")
+
+	tie := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	for i := 0; i < 6; i++ {
+		e.post(t, author, "go", tie)
+	}
+	e.post(t, author, "rust", tie)
+
+	items := walk(t, func(cursor string) (*services.FeedPage, error) {
+		return e.feed.GetTopicFeed(uuid.New(), "go", cursor, 4)
+	})
+	if len(items) != 6 {
+		t.Fatalf("listed %d posts, want 6", len(items))
+	}
+}
+
+func TestFeedItemsCarryTheirCounters(t *testing.T) {
+	e := setup(t)
+	me := e.user(t, "me")
+	post := e.post(t, me, "", time.Now())
+	quiet := e.post(t, me, "", time.Now().Add(-time.Minute))
+
+	for i := 0; i < 2; i++ {
+		if err := e.db.Create(&models.Comment{Content: "hi", PostID: post.ID.String(), AuthorID: uuid.New()}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.db.Create(&models.Reaction{ID: uuid.New(), UserID: me, TargetType: models.ReactionTargetPost, TargetID: post.ID, Type: models.ReactionLove}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := e.feed.GetHomeFeed(me, "", 10)
+	if err != nil || len(page.Items) != 2 {
+		t.Fatalf("page %+v, %v", page, err)
+	}
+	first, second := page.Items[0], page.Items[1]
+	if first.ID != post.ID || first.CommentCount != 2 || first.ReactionCount != 1 || first.ViewerReaction != models.ReactionLove {
+		t.Fatalf("first item %+v", first)
+	}
+	if second.ID != quiet.ID || second.CommentCount != 0 || second.ReactionCount != 0 || second.ViewerReaction != "" {
+		t.Fatalf("second item %+v", second)
+	}
+	if first.Author == nil || first.Author.Username != "me" {
+		t.Fatalf("author %+v", first.Author)
+	}
+}
@@ -0,0 +1,170 @@
+package media_test
+
+import (
+	"GoVersi/internal/infrastrucuture/queue"
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/storage"
+	"GoVersi/tests/testdb"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var imagePolicy = queue.RetryPolicy{MaxRetries: 2, BaseDelay: 10 * time.Millisecond}
+
+// flakyStore fails the first failures reads, then serves the wrapped store
+type flakyStore struct {
+	storage.BlobStore
+	mu       sync.Mutex
+	failures int
+	reads    int
+}
+
+func (s *flakyStore) Get(key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.reads++
+	failing := s.reads <= s.failures
+	s.mu.Unlock()
+
+	if failing {
+		return nil, errors.New("connection reset by peer")
+	}
+	return s.BlobStore.Get(key)
+}
+
+func (s *flakyStore) Reads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
+
+// startImageWorker runs the image worker and the dead-letter consumer on an in-memory queue.
+// db may be nil for jobs targeting comments, which keep no processing status.
+func startImageWorker(t *testing.T, db *gorm.DB, store storage.BlobStore) (*media.ImageQueue, <-chan queue.DeadLetter) {
+	t.Helper()
+
+	q := queue.NewMemoryQueue()
+	t.Cleanup(q.Close)
+
+	images := services.NewImageService(store, repository.NewImageRepository(db), media.NewProcessor(85), nil)
+	deadLetters := make(chan queue.DeadLetter, 10)
+	go services.RunImageWorker(q, images, imagePolicy, 2)
+	go q.WorkDeadLetters(media.ImageProcessingQueue, func(letter queue.DeadLetter) error {
+		deadLetters <- letter
+		return nil
+	})
+
+	return media.NewImageQueue(q), deadLetters
+}
+
+func expectDeadLetter(t *testing.T, deadLetters <-chan queue.DeadLetter, retries int) queue.DeadLetter {
+	t.Helper()
+
+	select {
+	case letter := <-deadLetters:
+		if letter.Retries != retries {
+			t.Errorf("dead-lettered after %d retries, want %d", letter.Retries, retries)
+		}
+		return letter
+	case <-time.After(2 * time.Second):
+		t.Fatal("job was not dead-lettered")
+		return queue.DeadLetter{}
+	}
+}
+
+func TestUndecodableImageIsDeadLetteredAtOnce(t *testing.T) {
+	store := storage.NewLocalStore(t.TempDir(), "/media", []byte("key"))
+	if err := store.Put("images/a.jpg", strings.NewReader("not an image"), 12, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	images, deadLetters := startImageWorker(t, nil, store)
+
+	if err := images.Enqueue(media.ImageJob{Target: media.TargetComment, ID: uuid.New(), Key: "images/a.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	letter := expectDeadLetter(t, deadLetters, 1)
+	if !strings.Contains(letter.Error, "undecodable image") {
+		t.Errorf("error = %q", letter.Error)
+	}
+}
+
+func TestMissingUploadIsDeadLetteredAtOnce(t *testing.T) {
+	images, deadLetters := startImageWorker(t, nil, storage.NewLocalStore(t.TempDir(), "/media", []byte("key")))
+
+	if err := images.Enqueue(media.ImageJob{Target: media.TargetComment, ID: uuid.New(), Key: "images/gone.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectDeadLetter(t, deadLetters, 1)
+}
+
+func TestFailingImageJobIsRetried(t *testing.T) {
+	store := &flakyStore{BlobStore: storage.NewLocalStore(t.TempDir(), "/media", []byte("key")), failures: 100}
+	images, deadLetters := startImageWorker(t, nil, store)
+
+	if err := images.Enqueue(media.ImageJob{Target: media.TargetComment, ID: uuid.New(), Key: "images/a.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	letter := expectDeadLetter(t, deadLetters, imagePolicy.MaxRetries+1)
+	if !strings.Contains(letter.Error, "connection reset") {
+		t.Errorf("error = %q", letter.Error)
+	}
+	if reads := store.Reads(); reads != imagePolicy.MaxRetries+1 {
+		t.Errorf("job ran %d times, want %d", reads, imagePolicy.MaxRetries+1)
+	}
+}
+
+func TestMalformedImageJobIsDeadLettered(t *testing.T) {
+	q := queue.NewMemoryQueue()
+	t.Cleanup(q.Close)
+	images := services.NewImageService(nil, repository.NewImageRepository(nil), media.NewProcessor(85), nil)
+	go services.RunImageWorker(q, images, imagePolicy, 1)
+
+	deadLetters := make(chan queue.DeadLetter, 1)
+	go q.WorkDeadLetters(media.ImageProcessingQueue, func(letter queue.DeadLetter) error {
+		deadLetters <- letter
+		return nil
+	})
+
+	if err := q.Publish(media.ImageProcessingQueue, []byte("{")); err != nil {
+		t.Fatal(err)
+	}
+	expectDeadLetter(t, deadLetters, 1)
+}
+
+// an attachment stays processing while its job is retried and is marked failed once dead-lettered
+func TestDeadLetteredAttachmentIsMarkedFailed(t *testing.T) {
+	db := testdb.Open(t, &models.PostMedia{})
+
+	attachment := models.PostMedia{PostID: uuid.New(), Type: models.MediaTypeImage, URL: "images/a.jpg", Status: models.MediaProcessing}
+	if err := db.Create(&attachment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	store := &flakyStore{BlobStore: storage.NewLocalStore(t.TempDir(), "/media", []byte("key")), failures: 100}
+	images, deadLetters := startImageWorker(t, db, store)
+	if err := images.Enqueue(media.ImageJob{Target: media.TargetPostMedia, ID: attachment.ID, Key: "images/a.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectDeadLetter(t, deadLetters, imagePolicy.MaxRetries+1)
+
+	var stored models.PostMedia
+	if err := db.First(&stored, "id = ?", attachment.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.MediaFailed {
+		t.Fatalf("status = %s, want failed", stored.Status)
+	}
+}
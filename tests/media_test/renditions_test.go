@@ -0,0 +1,66 @@
+package media_test
+
+import (
+	"GoVersi/internal/models"
+	"encoding/json"
+	"testing"
+)
+
+func withSigner(t *testing.T) {
+	models.SetMediaURLSigner(func(key string) string { return "https://signed/" + key + "?exp=1" })
+	t.Cleanup(func() { models.SetMediaURLSigner(nil) })
+}
+
+// the column keeps keys, not the signed URLs served in responses
+func TestRenditionsStoreKeys(t *testing.T) {
+	withSigner(t)
+
+	value, err := models.Renditions{"thumb": "images/a_thumb.jpg"}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(value.([]byte)); got != `{"thumb":"images/a_thumb.jpg"}` {
+		t.Fatalf("stored %s", got)
+	}
+}
+
+func TestRenditionsRoundTrip(t *testing.T) {
+	withSigner(t)
+
+	in := models.Renditions{"thumb": "images/a_thumb.jpg", "large": "images/a_large.jpg"}
+	value, err := in.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out models.Renditions
+	if err := out.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(in) || out["thumb"] != in["thumb"] || out["large"] != in["large"] {
+		t.Fatalf("round trip gave %v, want %v", out, in)
+	}
+
+	// responses still sign each key exactly once
+	body, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls map[string]string
+	if err := json.Unmarshal(body, &urls); err != nil {
+		t.Fatal(err)
+	}
+	if urls["thumb"] != "https://signed/images/a_thumb.jpg?exp=1" {
+		t.Fatalf("rendered %q", urls["thumb"])
+	}
+}
+
+func TestRenditionsScanNull(t *testing.T) {
+	out := models.Renditions{"thumb": "x"}
+	if err := out.Scan(nil); err != nil || out != nil {
+		t.Fatalf("scan nil gave %v, %v", out, err)
+	}
+	if err := out.Scan("null"); err != nil || out != nil {
+		t.Fatalf("scan null gave %v, %v", out, err)
+	}
+}
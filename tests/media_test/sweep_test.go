@@ -0,0 +1,230 @@
+package media_test
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/tests/testdb"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// memoryStore keeps the deleted keys and fails the deletes it is told to
+type memoryStore struct {
+	deleted  []string
+	failing  map[string]bool
+	onDelete func(key string)
+}
+
+func (s *memoryStore) Put(string, io.Reader, int64, string) error { return nil }
+func (s *memoryStore) Get(string) (io.ReadCloser, error)          { return nil, errors.New("not stored") }
+func (s *memoryStore) SignedURL(key string, _ time.Duration) (string, error) {
+	return "/media/" + key, nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	if s.onDelete != nil {
+		s.onDelete(key)
+	}
+	if s.failing[key] {
+		return errors.New("store unavailable")
+	}
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func openObjects(t *testing.T) (*gorm.DB, *repository.MediaObjectRepository) {
+	db := testdb.Open(t, &models.MediaObject{})
+	return db, repository.NewMediaObjectRepository(db)
+}
+
+// unreferencedSince registers a file left unreferenced for the given duration
+func unreferencedSince(t *testing.T, db *gorm.DB, repo *repository.MediaObjectRepository, key string, size int64, age time.Duration) {
+	t.Helper()
+	if err := repo.Register(key, size, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.MediaObject{}).Where("key = ?", key).
+		Update("unreferenced_at", time.Now().Add(-age)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func object(t *testing.T, db *gorm.DB, key string) *models.MediaObject {
+	t.Helper()
+	var objects []models.MediaObject
+	if err := db.Where("key = ?", key).Find(&objects).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+	return &objects[0]
+}
+
+func TestRetainAndReleaseCountReferences(t *testing.T) {
+	db, repo := openObjects(t)
+	if err := repo.Register("images/a.png", 10, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Retain([]string{"images/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Retain([]string{"images/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := object(t, db, "images/a.png"); got.RefCount != 2 || got.UnreferencedAt != nil {
+		t.Fatalf("after two retains: %+v", got)
+	}
+
+	if err := repo.Release([]string{"images/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := object(t, db, "images/a.png"); got.RefCount != 1 || got.UnreferencedAt != nil {
+		t.Fatalf("after one release: %+v", got)
+	}
+
+	if err := repo.Release([]string{"images/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := object(t, db, "images/a.png"); got.RefCount != 0 || got.UnreferencedAt == nil || got.Size != 10 {
+		t.Fatalf("after the last release: %+v", got)
+	}
+
+	// an extra release does not go below zero
+	if err := repo.Release([]string{"images/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := object(t, db, "images/a.png"); got.RefCount != 0 {
+		t.Fatalf("ref count %d after an extra release", got.RefCount)
+	}
+}
+
+func TestRetainTracksFilesStoredBeforeTracking(t *testing.T) {
+	db, repo := openObjects(t)
+
+	if err := repo.Retain([]string{"images/legacy.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := object(t, db, "images/legacy.png"); got == nil || got.RefCount != 1 || got.UnreferencedAt != nil {
+		t.Fatalf("legacy file: %+v", got)
+	}
+}
+
+func TestClaimUnreferencedSkipsRetainedFiles(t *testing.T) {
+	db, repo := openObjects(t)
+	unreferencedSince(t, db, repo, "images/a.png", 10, time.Hour)
+	cutoff := time.Now().Add(-time.Minute)
+
+	objects, err := repo.FindUnreferenced(cutoff, 10)
+	if err != nil || len(objects) != 1 {
+		t.Fatalf("found %d files: %v", len(objects), err)
+	}
+
+	// a post takes the file between the listing and the claim
+	if err := repo.Retain([]string{"images/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := repo.ClaimUnreferenced("images/a.png", cutoff)
+	if err != nil || claimed {
+		t.Fatalf("claimed %v, %v; want a retained file left alone", claimed, err)
+	}
+	if object(t, db, "images/a.png") == nil {
+		t.Fatal("record of a retained file deleted")
+	}
+}
+
+func TestSweepRespectsTheGracePeriod(t *testing.T) {
+	db, repo := openObjects(t)
+	unreferencedSince(t, db, repo, "images/old.png", 100, 2*time.Hour)
+	unreferencedSince(t, db, repo, "images/recent.png", 50, 10*time.Minute)
+	if err := repo.Retain([]string{"images/used.png"}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &memoryStore{}
+	report, err := services.NewMediaService(repo, store, time.Hour).Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Files != 1 || report.Bytes != 100 {
+		t.Fatalf("report %+v, want 1 file of 100 bytes", report)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "images/old.png" {
+		t.Fatalf("deleted %v", store.deleted)
+	}
+	if object(t, db, "images/old.png") != nil {
+		t.Fatal("record of a swept file kept")
+	}
+	if object(t, db, "images/recent.png") == nil || object(t, db, "images/used.png") == nil {
+		t.Fatal("record of a kept file deleted")
+	}
+}
+
+func TestSweepSkipsFilesRetainedDuringTheSweep(t *testing.T) {
+	db, repo := openObjects(t)
+	unreferencedSince(t, db, repo, "images/first.png", 10, 3*time.Hour)
+	unreferencedSince(t, db, repo, "images/second.png", 20, 2*time.Hour)
+
+	// the second file is taken by a post once the batch is listed
+	store := &memoryStore{onDelete: func(key string) {
+		if key == "images/first.png" {
+			if err := repo.Retain([]string{"images/second.png"}); err != nil {
+				t.Error(err)
+			}
+		}
+	}}
+	report, err := services.NewMediaService(repo, store, time.Hour).Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Files != 1 || report.Bytes != 10 {
+		t.Fatalf("report %+v, want only the first file", report)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "images/first.png" {
+		t.Fatalf("deleted %v", store.deleted)
+	}
+	if got := object(t, db, "images/second.png"); got == nil || got.RefCount != 1 {
+		t.Fatalf("retained file: %+v", got)
+	}
+}
+
+func TestSweepTracksFailedDeletesAgain(t *testing.T) {
+	db, repo := openObjects(t)
+	unreferencedSince(t, db, repo, "images/stuck.png", 100, 2*time.Hour)
+
+	store := &memoryStore{failing: map[string]bool{"images/stuck.png": true}}
+	sweeper := services.NewMediaService(repo, store, time.Hour)
+	report, err := sweeper.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 0 || report.Bytes != 0 {
+		t.Fatalf("report %+v for a failed delete", report)
+	}
+
+	got := object(t, db, "images/stuck.png")
+	if got == nil || got.RefCount != 0 || got.UnreferencedAt == nil || got.Size != 100 {
+		t.Fatalf("file not tracked again: %+v", got)
+	}
+
+	// the retry happens once the grace period has passed again
+	if err := db.Model(&models.MediaObject{}).Where("key = ?", "images/stuck.png").
+		Update("unreferenced_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	store.failing = nil
+	if report, err = sweeper.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Bytes != 100 {
+		t.Fatalf("retry report %+v, want the size kept", report)
+	}
+}
@@ -0,0 +1,217 @@
+package media_test
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/media"
+	"GoVersi/internal/storage"
+	"GoVersi/internal/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func inspect(data []byte, kind media.Kind, limits media.Limits) (*media.Info, error) {
+	return media.Inspect(bytes.NewReader(data), int64(len(data)), kind, limits)
+}
+
+func TestInspectDetectsTheTypeFromTheContent(t *testing.T) {
+	info, err := inspect(pngBytes(t, 4, 3), media.KindImage, media.Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.MIME != "image/png" || info.Extension != ".png" || info.Width != 4 || info.Height != 3 {
+		t.Fatalf("info %+v", info)
+	}
+}
+
+func TestInspectRefusesARenamedFile(t *testing.T) {
+	_, err := inspect([]byte("<?php echo 'not a picture'; ?>"), media.KindImage, media.Limits{})
+
+	var unsupported *media.UnsupportedMediaError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("got %v, want UnsupportedMediaError", err)
+	}
+	if !strings.HasPrefix(unsupported.Reason, "unsupported image type ") {
+		t.Fatalf("reason %q", unsupported.Reason)
+	}
+}
+
+func TestInspectRefusesAnImageAsAVideo(t *testing.T) {
+	_, err := inspect(pngBytes(t, 4, 4), media.KindVideo, media.Limits{})
+
+	var unsupported *media.UnsupportedMediaError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("got %v, want UnsupportedMediaError", err)
+	}
+}
+
+func TestInspectEnforcesImageDimensions(t *testing.T) {
+	limits := media.Limits{MaxImageWidth: 100, MaxImageHeight: 50}
+
+	for _, size := range [][2]int{{101, 10}, {10, 51}} {
+		_, err := inspect(pngBytes(t, size[0], size[1]), media.KindImage, limits)
+		var unsupported *media.UnsupportedMediaError
+		if !errors.As(err, &unsupported) || !strings.Contains(unsupported.Reason, "larger than the allowed 100x50") {
+			t.Fatalf("%dx%d image: %v", size[0], size[1], err)
+		}
+	}
+
+	if _, err := inspect(pngBytes(t, 100, 50), media.KindImage, limits); err != nil {
+		t.Fatalf("image at the limits refused: %v", err)
+	}
+}
+
+func TestInspectEnforcesThePixelCount(t *testing.T) {
+	limits := media.Limits{MaxImagePixels: 100}
+
+	_, err := inspect(pngBytes(t, 11, 10), media.KindImage, limits)
+	var unsupported *media.UnsupportedMediaError
+	if !errors.As(err, &unsupported) || !strings.Contains(unsupported.Reason, "110 pixels, more than the allowed 100") {
+		t.Fatalf("got %v", err)
+	}
+
+	if _, err := inspect(pngBytes(t, 10, 10), media.KindImage, limits); err != nil {
+		t.Fatalf("image at the limit refused: %v", err)
+	}
+}
+
+func TestInspectEnforcesTheSizeOfEachKind(t *testing.T) {
+	data := pngBytes(t, 4, 4)
+	limits := media.Limits{MaxImageBytes: int64(len(data)) - 1, MaxVideoBytes: 1 << 20}
+
+	_, err := inspect(data, media.KindImage, limits)
+	var tooLarge *media.TooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Kind != media.KindImage {
+		t.Fatalf("got %v, want TooLargeError for an image", err)
+	}
+
+	// the video limit does not apply to a detected image
+	_, err = media.InspectAny(bytes.NewReader(data), int64(len(data)), limits)
+	if !errors.As(err, &tooLarge) || tooLarge.Kind != media.KindImage {
+		t.Fatalf("got %v, want TooLargeError for an image", err)
+	}
+}
+
+// multipartRequest sends data as the named form file with the given filename
+func multipartRequest(t *testing.T, field, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		w.WriteField(name, value)
+	}
+	part, err := w.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestStoredExtensionComesFromTheContent(t *testing.T) {
+	store := storage.NewLocalStore(t.TempDir(), "/media", []byte("validate-test"))
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = multipartRequest(t, "image", "holiday.jpg.exe", pngBytes(t, 4, 4), nil)
+
+	key, err := utils.HandleImageUpload(c, store, "images/test", media.Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(key), "images/test/") || !strings.HasSuffix(string(key), ".png") {
+		t.Fatalf("stored as %s", key)
+	}
+	if _, err := store.Get(string(key)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func register(t *testing.T, filename string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", handlers.RegisterUser)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, multipartRequest(t, "image", filename, data, map[string]string{
+		"username": "ana", "email": "ana@example.com", "password": "secret123",
+	}))
+	return w
+}
+
+func withUploads(t *testing.T, limits media.Limits) {
+	handlers.SetBlobStore(storage.NewLocalStore(t.TempDir(), "/media", []byte("validate-test")))
+	handlers.SetUploadLimits(limits)
+	t.Cleanup(func() {
+		handlers.SetBlobStore(nil)
+		handlers.SetUploadLimits(media.Limits{})
+	})
+}
+
+func errorOf(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Error
+}
+
+func TestRenamedUploadAnswers415WithTheReason(t *testing.T) {
+	withUploads(t, media.Limits{})
+
+	w := register(t, "avatar.png", []byte("MZ\x90\x00 definitely not an image"))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status %d, want 415", w.Code)
+	}
+	if reason := errorOf(t, w); !strings.HasPrefix(reason, "unsupported image type ") {
+		t.Fatalf("error %q", reason)
+	}
+}
+
+func TestOversizedImageAnswers415WithTheDimensions(t *testing.T) {
+	withUploads(t, media.Limits{MaxImageWidth: 10, MaxImageHeight: 10})
+
+	w := register(t, "avatar.png", pngBytes(t, 20, 5))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status %d, want 415", w.Code)
+	}
+	if reason := errorOf(t, w); reason != "image is 20x5, larger than the allowed 10x10" {
+		t.Fatalf("error %q", reason)
+	}
+}
+
+func TestOversizeUploadAnswers413(t *testing.T) {
+	withUploads(t, media.Limits{MaxImageBytes: 16})
+
+	w := register(t, "avatar.png", pngBytes(t, 4, 4))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d, want 413", w.Code)
+	}
+	if reason := errorOf(t, w); !strings.HasPrefix(reason, "image file too large") {
+		t.Fatalf("error %q", reason)
+	}
+}
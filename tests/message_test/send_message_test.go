@@ -0,0 +1,135 @@
+package message_test
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/storage"
+	"GoVersi/tests/testdb"
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type env struct {
+	db     *gorm.DB
+	router *gin.Engine
+	root   string // directory of the blob store
+	sender uuid.UUID
+}
+
+func setup(t *testing.T) *env {
+	db := testdb.Open(t, &models.Conversation{}, &models.ConversationMember{}, &models.Message{}, &models.Friendship{})
+	gin.SetMode(gin.TestMode)
+
+	e := &env{db: db, root: t.TempDir(), sender: uuid.New()}
+	handlers.SetBlobStore(storage.NewLocalStore(e.root, "/media", []byte("message-test")))
+	handlers.SetUploadLimits(media.Limits{MaxImageBytes: 1 << 20, MaxImageWidth: 1000, MaxImageHeight: 1000, MaxImagePixels: 1 << 20})
+
+	messages := services.NewMessageService(repository.NewConversationRepository(db), repository.NewFriendshipRepository(db), repository.NewUserRepository(db), realtime.NewMemoryHub(10, time.Minute), nil)
+	handler := handlers.NewMessageHandler(messages)
+	e.router = gin.New()
+	e.router.POST("/conversations/:id/messages", func(c *gin.Context) { c.Set("user_id", e.sender.String()) }, handler.SendMessage)
+	return e
+}
+
+func (e *env) conversation(t *testing.T, a, b uuid.UUID) uuid.UUID {
+	t.Helper()
+	userA, userB := models.OrderedPair(a, b)
+	conversation := &models.Conversation{UserAID: userA, UserBID: userB, LastActivityAt: time.Now()}
+	if err := e.db.Create(conversation).Error; err != nil {
+		t.Fatal(err)
+	}
+	return conversation.ID
+}
+
+func (e *env) friendship(t *testing.T, a, b uuid.UUID, status models.FriendshipStatus) {
+	t.Helper()
+	if err := e.db.Create(&models.Friendship{RequesterID: a, AddresseeID: b, Status: status}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// send posts a message with an image and returns the status code
+func (e *env) send(t *testing.T, conversationID uuid.UUID) int {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("content", "look")
+	part, _ := form.CreateFormFile("image", "photo.png")
+	png.Encode(part, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/conversations/"+conversationID.String()+"/messages", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+// stored counts the files written to the blob store
+func (e *env) stored(t *testing.T) int {
+	t.Helper()
+	files := 0
+	filepath.Walk(e.root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files++
+		}
+		return nil
+	})
+	return files
+}
+
+func TestOutsiderCannotUploadToAConversation(t *testing.T) {
+	e := setup(t)
+	a, b := uuid.New(), uuid.New()
+	e.friendship(t, a, b, models.StatusAccepted)
+	conversationID := e.conversation(t, a, b)
+
+	if code := e.send(t, conversationID); code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403", code)
+	}
+	if n := e.stored(t); n != 0 {
+		t.Fatalf("%d files stored for a refused message", n)
+	}
+}
+
+func TestFormerFriendCannotUpload(t *testing.T) {
+	e := setup(t)
+	friend := uuid.New()
+	e.friendship(t, e.sender, friend, models.StatusDeclined)
+	conversationID := e.conversation(t, e.sender, friend)
+
+	if code := e.send(t, conversationID); code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403", code)
+	}
+	if n := e.stored(t); n != 0 {
+		t.Fatalf("%d files stored for a refused message", n)
+	}
+}
+
+func TestUnknownConversationIsNotFound(t *testing.T) {
+	e := setup(t)
+
+	if code := e.send(t, uuid.New()); code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", code)
+	}
+	if n := e.stored(t); n != 0 {
+		t.Fatalf("%d files stored for a refused message", n)
+	}
+}
@@ -0,0 +1,234 @@
+package notification_test
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/tests/testdb"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type env struct {
+	db            *gorm.DB
+	repo          *repository.NotificationRepository
+	notifications *services.NotificationService
+	hub           *realtime.MemoryHub
+}
+
+func setup(t *testing.T) *env {
+	db := testdb.Open(t, &models.User{}, &models.Notification{}, &models.NotificationActor{})
+	repo := repository.NewNotificationRepository(db)
+	hub := realtime.NewMemoryHub(10, time.Minute)
+	return &env{db: db, repo: repo, hub: hub, notifications: services.NewNotificationService(repo, repository.NewUserRepository(db), hub)}
+}
+
+func (e *env) user(t *testing.T, name string) uuid.UUID {
+	t.Helper()
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "x", IsActive: true}
+	if err := e.db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func reaction(recipient, actor, target uuid.UUID) *models.Notification {
+	return &models.Notification{RecipientID: recipient, Type: models.NotificationReaction, TargetType: "post", TargetID: target, LastActorID: actor}
+}
+
+func (e *env) rows(t *testing.T, recipient uuid.UUID) []models.Notification {
+	t.Helper()
+	var rows []models.Notification
+	if err := e.db.Where("recipient_id = ?", recipient).Order("created_at").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestEventsOnTheSameTargetAreGrouped(t *testing.T) {
+	e := setup(t)
+	recipient, post := uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	for _, actor := range []uuid.UUID{first, second, first} {
+		if _, err := e.repo.RecordGrouped(reaction(recipient, actor, post)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows := e.rows(t, recipient)
+	if len(rows) != 1 {
+		t.Fatalf("%d notifications, want one group", len(rows))
+	}
+	// the actor reacting twice is counted once but is still the latest one
+	if rows[0].ActorCount != 2 || rows[0].LastActorID != first {
+		t.Fatalf("group %+v", rows[0])
+	}
+
+	// another target, or another type on the same target, is another group
+	if _, err := e.repo.RecordGrouped(reaction(recipient, first, uuid.New())); err != nil {
+		t.Fatal(err)
+	}
+	comment := reaction(recipient, first, post)
+	comment.Type = models.NotificationComment
+	if _, err := e.repo.RecordGrouped(comment); err != nil {
+		t.Fatal(err)
+	}
+	if rows := e.rows(t, recipient); len(rows) != 3 {
+		t.Fatalf("%d notifications, want 3 groups", len(rows))
+	}
+}
+
+func TestReadGroupsAreNotReopened(t *testing.T) {
+	e := setup(t)
+	recipient, post := uuid.New(), uuid.New()
+
+	group, err := e.repo.RecordGrouped(reaction(recipient, uuid.New(), post))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.repo.MarkRead(recipient, group.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, err := e.repo.RecordGrouped(reaction(recipient, uuid.New(), post))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.ID == group.ID || fresh.ActorCount != 1 {
+		t.Fatalf("event after reading joined the read group: %+v", fresh)
+	}
+	if unread, err := e.repo.CountUnread(recipient); err != nil || unread != 1 {
+		t.Fatalf("unread %d, %v", unread, err)
+	}
+}
+
+// the grouping index only covers unread rows
+func TestGroupingIndexIsPartial(t *testing.T) {
+	e := setup(t)
+	recipient, post := uuid.New(), uuid.New()
+
+	insert := func(readAt *time.Time) error {
+		row := reaction(recipient, uuid.New(), post)
+		row.ID, row.ReadAt = uuid.New(), readAt
+		return e.db.Create(row).Error
+	}
+
+	read := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := insert(&read); err != nil {
+			t.Fatalf("read duplicate refused: %v", err)
+		}
+	}
+	if err := insert(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := insert(nil); err == nil {
+		t.Fatal("second unread row of the same group accepted")
+	}
+}
+
+func TestConcurrentEventsShareOneGroup(t *testing.T) {
+	e := setup(t)
+	recipient, post := uuid.New(), uuid.New()
+	const actors = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < actors; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.repo.RecordGrouped(reaction(recipient, uuid.New(), post)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	rows := e.rows(t, recipient)
+	if len(rows) != 1 || rows[0].ActorCount != actors {
+		t.Fatalf("groups %+v, want one of %d actors", rows, actors)
+	}
+}
+
+func TestPublishPushesTheGroupedMessage(t *testing.T) {
+	e := setup(t)
+	recipient := e.user(t, "owner")
+	ana, bob := e.user(t, "ana"), e.user(t, "bob")
+	post := uuid.New()
+
+	sub, _ := e.hub.Subscribe(recipient, "")
+	defer sub.Close()
+
+	// acting on your own content notifies nobody
+	e.notifications.Publish(services.NotificationEvent{RecipientID: recipient, ActorID: recipient, Type: models.NotificationReaction, TargetType: "post", TargetID: post})
+	for _, actor := range []uuid.UUID{ana, bob} {
+		e.notifications.Publish(services.NotificationEvent{RecipientID: recipient, ActorID: actor, Type: models.NotificationReaction, TargetType: "post", TargetID: post})
+	}
+
+	var messages []string
+	for len(messages) < 2 {
+		select {
+		case event := <-sub.Events:
+			messages = append(messages, event.Data.(services.NotificationView).Message)
+		case <-time.After(time.Second):
+			t.Fatalf("got %v", messages)
+		}
+	}
+	if messages[0] != "ana reacted to your post" || messages[1] != "bob and 1 other reacted to your post" {
+		t.Fatalf("messages %q", messages)
+	}
+	if rows := e.rows(t, recipient); len(rows) != 1 {
+		t.Fatalf("%d notifications", len(rows))
+	}
+}
+
+func TestListNotificationsPages(t *testing.T) {
+	e := setup(t)
+	recipient := e.user(t, "owner")
+	actor := e.user(t, "ana")
+
+	for i := 0; i < 5; i++ {
+		e.notifications.Publish(services.NotificationEvent{RecipientID: recipient, ActorID: actor, Type: models.NotificationComment, TargetType: "post", TargetID: uuid.New()})
+	}
+
+	seen := map[uuid.UUID]bool{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		page, err := e.notifications.ListNotifications(recipient, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Fatalf("notification %s listed twice", item.ID)
+			}
+			seen[item.ID] = true
+			if item.Actor == nil || item.Actor.Username != "ana" {
+				t.Fatalf("actor %+v", item.Actor)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+		cursor = page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("listed %d notifications, want 5", len(seen))
+	}
+
+	if n, err := e.notifications.MarkManyAsRead(recipient, nil); err != nil || n != 5 {
+		t.Fatalf("marked %d, %v", n, err)
+	}
+	if unread, _ := e.notifications.UnreadCount(recipient); unread != 0 {
+		t.Fatalf("unread %d", unread)
+	}
+}
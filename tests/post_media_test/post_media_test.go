@@ -0,0 +1,208 @@
+package post_media_test
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	"GoVersi/tests/testdb"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func setup(t *testing.T) (*gorm.DB, *repository.PostRepository, *models.Post) {
+	db := testdb.Open(t, &models.Post{}, &models.PostMedia{})
+	post := &models.Post{Title: "trip", AuthorID: uuid.New()}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, repository.NewPostRepository(db), post
+}
+
+func attachment(postID uuid.UUID, i int) *models.PostMedia {
+	return &models.PostMedia{PostID: postID, Type: models.MediaTypeVideo, URL: models.MediaKey(fmt.Sprintf("videos/%d.mp4", i)), Status: models.MediaReady}
+}
+
+func positions(t *testing.T, db *gorm.DB, postID uuid.UUID) []int {
+	t.Helper()
+
+	var got []int
+	if err := db.Model(&models.PostMedia{}).Where("post_id = ?", postID).Order("position").Pluck("position", &got).Error; err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func ids(attachments []models.PostMedia) []uuid.UUID {
+	out := make([]uuid.UUID, len(attachments))
+	for i, m := range attachments {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestConcurrentAddMediaRespectsTheLimit(t *testing.T) {
+	db, repo, post := setup(t)
+	const limit = 3
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	added := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := repo.AddMedia(attachment(post.ID, i), limit)
+			if err != nil {
+				t.Errorf("add %d: %v", i, err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				added++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if added != limit {
+		t.Fatalf("%d attachments added, want %d", added, limit)
+	}
+	if got := positions(t, db, post.ID); fmt.Sprint(got) != "[0 1 2]" {
+		t.Fatalf("positions %v", got)
+	}
+}
+
+func TestReplaceMediaReorders(t *testing.T) {
+	db, repo, post := setup(t)
+
+	var stored []models.PostMedia
+	for i := 0; i < 3; i++ {
+		a := attachment(post.ID, i)
+		if _, err := repo.AddMedia(a, 10); err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, *a)
+	}
+
+	// reversing swaps positions, which a unique index only allows in two steps
+	reversed := []models.PostMedia{stored[2], stored[1], stored[0]}
+	removed, replaced, err := repo.ReplaceMedia(post, ids(stored), reversed)
+	if err != nil || !replaced || len(removed) != 0 {
+		t.Fatalf("replace: %v, replaced %v, removed %d", err, replaced, len(removed))
+	}
+
+	var order []uuid.UUID
+	db.Model(&models.PostMedia{}).Where("post_id = ?", post.ID).Order("position").Pluck("id", &order)
+	if len(order) != 3 || order[0] != stored[2].ID || order[2] != stored[0].ID {
+		t.Fatalf("order %v", order)
+	}
+
+	a := attachment(post.ID, 3)
+	if _, err := repo.AddMedia(a, 10); err != nil {
+		t.Fatal(err)
+	}
+	if a.Position != 3 {
+		t.Fatalf("appended at %d, want 3", a.Position)
+	}
+}
+
+func TestDuplicatePositionsAreRenumbered(t *testing.T) {
+	db, _, post := setup(t)
+
+	// the index as it was before positions became unique, with the duplicates it let through
+	if err := db.Migrator().DropIndex(&models.PostMedia{}, "idx_post_media_post_position"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE INDEX idx_post_media_post_position ON post_media (post_id, position)").Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		a := attachment(post.ID, i)
+		a.Position = 1
+		if err := db.Create(a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := repository.UniquePostMediaPositions(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.PostMedia{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := positions(t, db, post.ID); fmt.Sprint(got) != "[0 1 2]" {
+		t.Fatalf("positions %v", got)
+	}
+	duplicate := attachment(post.ID, 9)
+	duplicate.Position = 0
+	if err := db.Create(duplicate).Error; err == nil {
+		t.Fatal("index still accepts duplicate positions")
+	}
+}
+
+func TestReplaceMediaReturnsTheRemovedAttachments(t *testing.T) {
+	db, repo, post := setup(t)
+
+	var stored []models.PostMedia
+	for i := 0; i < 3; i++ {
+		a := attachment(post.ID, i)
+		if _, err := repo.AddMedia(a, 10); err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, *a)
+	}
+
+	post.Title = "trip, edited"
+	removed, replaced, err := repo.ReplaceMedia(post, ids(stored), []models.PostMedia{stored[1]})
+	if err != nil || !replaced {
+		t.Fatalf("replace: %v, replaced %v", err, replaced)
+	}
+	if len(removed) != 2 || removed[0].URL == "" {
+		t.Fatalf("removed %+v, want the two dropped attachments", removed)
+	}
+	if got := positions(t, db, post.ID); fmt.Sprint(got) != "[0]" {
+		t.Fatalf("positions %v", got)
+	}
+	var saved models.Post
+	if err := db.First(&saved, "id = ?", post.ID).Error; err != nil || saved.Title != "trip, edited" {
+		t.Fatalf("post columns not saved with the attachments: %q %v", saved.Title, err)
+	}
+}
+
+// an attachment uploaded between reading the post and replacing its attachments
+// must neither be deleted nor let the update through
+func TestReplaceMediaRefusesAStaleRead(t *testing.T) {
+	db, repo, post := setup(t)
+
+	first := attachment(post.ID, 0)
+	if _, err := repo.AddMedia(first, 10); err != nil {
+		t.Fatal(err)
+	}
+	read := []uuid.UUID{first.ID}
+
+	concurrent := attachment(post.ID, 1)
+	if _, err := repo.AddMedia(concurrent, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	post.Title = "stale"
+	removed, replaced, err := repo.ReplaceMedia(post, read, []models.PostMedia{*first})
+	if err != nil || replaced || len(removed) != 0 {
+		t.Fatalf("stale replace: %v, replaced %v, removed %d", err, replaced, len(removed))
+	}
+
+	var count int64
+	db.Model(&models.PostMedia{}).Where("id = ?", concurrent.ID).Count(&count)
+	if count != 1 {
+		t.Fatal("concurrently added attachment was deleted")
+	}
+	var saved models.Post
+	if err := db.First(&saved, "id = ?", post.ID).Error; err != nil || saved.Title == "stale" {
+		t.Fatalf("post columns saved despite the conflict: %q %v", saved.Title, err)
+	}
+}
@@ -0,0 +1,238 @@
+package reaction_test
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/tests/testdb"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// recorder keeps the published notification events
+type recorder struct {
+	events []services.NotificationEvent
+}
+
+func (r *recorder) Publish(event services.NotificationEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestReactionTypes(t *testing.T) {
+	for _, valid := range models.ReactionTypes {
+		if !valid.IsValid() {
+			t.Errorf("%s refused", valid)
+		}
+	}
+	for _, invalid := range []models.ReactionType{"", "Like", "dislike"} {
+		if invalid.IsValid() {
+			t.Errorf("%q accepted", invalid)
+		}
+	}
+}
+
+func TestReactRefusesUnknownTypesFirst(t *testing.T) {
+	notifier := &recorder{}
+	service := services.NewReactionService(nil, nil, nil, notifier)
+
+	if _, err := service.React(uuid.New(), models.ReactionTargetPost, uuid.New(), "dislike"); !errors.Is(err, services.ErrInvalidReactionType) {
+		t.Fatalf("got %v, want ErrInvalidReactionType", err)
+	}
+	if len(notifier.events) != 0 {
+		t.Fatal("refused reaction notified")
+	}
+}
+
+type env struct {
+	db        *gorm.DB
+	repo      *repository.ReactionRepository
+	reactions *services.ReactionService
+	notifier  *recorder
+	post      *models.Post
+	comment   *models.Comment
+}
+
+func setup(t *testing.T) *env {
+	db := testdb.Open(t, &models.Post{}, &models.PostMedia{}, &models.Comment{}, &models.Reaction{})
+
+	post := &models.Post{Title: "trip", AuthorID: uuid.New()}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatal(err)
+	}
+	comment := &models.Comment{Content: "nice", PostID: post.ID.String(), AuthorID: uuid.New()}
+	if err := db.Create(comment).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	repo := repository.NewReactionRepository(db)
+	notifier := &recorder{}
+	reactions := services.NewReactionService(repo, repository.NewPostRepository(db), repository.NewCommentRepository(db), notifier)
+	return &env{db: db, repo: repo, reactions: reactions, notifier: notifier, post: post, comment: comment}
+}
+
+func TestReactReplacesThePreviousReaction(t *testing.T) {
+	e := setup(t)
+	user := uuid.New()
+
+	first, err := e.reactions.React(user, models.ReactionTargetPost, e.post.ID, models.ReactionLike)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.reactions.React(user, models.ReactionTargetPost, e.post.ID, models.ReactionWow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID || second.Type != models.ReactionWow {
+		t.Fatalf("first %+v, second %+v; want the same row with the new type", first, second)
+	}
+
+	var count int64
+	e.db.Model(&models.Reaction{}).Where("user_id = ?", user).Count(&count)
+	if count != 1 {
+		t.Fatalf("user holds %d reactions, want 1", count)
+	}
+
+	if len(e.notifier.events) != 2 {
+		t.Fatalf("%d events published", len(e.notifier.events))
+	}
+	event := e.notifier.events[0]
+	if event.RecipientID != e.post.AuthorID || event.ActorID != user || event.Type != models.NotificationReaction ||
+		event.TargetType != "post" || event.TargetID != e.post.ID {
+		t.Fatalf("event %+v", event)
+	}
+}
+
+func TestReactToACommentNotifiesItsAuthor(t *testing.T) {
+	e := setup(t)
+
+	if _, err := e.reactions.React(uuid.New(), models.ReactionTargetComment, e.comment.ID, models.ReactionHaha); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.notifier.events) != 1 || e.notifier.events[0].RecipientID != e.comment.AuthorID {
+		t.Fatalf("events %+v", e.notifier.events)
+	}
+}
+
+func TestReactToAMissingTarget(t *testing.T) {
+	e := setup(t)
+
+	cases := map[models.ReactionTarget]string{
+		models.ReactionTargetPost:    "post not found",
+		models.ReactionTargetComment: "comment not found",
+		"message":                    "invalid reaction target",
+	}
+	for target, want := range cases {
+		if _, err := e.reactions.React(uuid.New(), target, uuid.New(), models.ReactionLike); err == nil || err.Error() != want {
+			t.Errorf("%s: %v, want %q", target, err, want)
+		}
+	}
+	if len(e.notifier.events) != 0 {
+		t.Fatal("failed reaction notified")
+	}
+}
+
+func TestSummaryCountsEachType(t *testing.T) {
+	e := setup(t)
+	viewer := uuid.New()
+
+	for _, reaction := range []models.ReactionType{models.ReactionLike, models.ReactionLike, models.ReactionSad} {
+		if _, err := e.reactions.React(uuid.New(), models.ReactionTargetPost, e.post.ID, reaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := e.reactions.React(viewer, models.ReactionTargetPost, e.post.ID, models.ReactionLove); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := e.reactions.GetSummary(viewer, models.ReactionTargetPost, e.post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Total != 4 || summary.ViewerReaction != models.ReactionLove || len(summary.Counts) != len(models.ReactionTypes) {
+		t.Fatalf("summary %+v", summary)
+	}
+	if summary.Counts[models.ReactionLike] != 2 || summary.Counts[models.ReactionSad] != 1 || summary.Counts[models.ReactionAngry] != 0 {
+		t.Fatalf("counts %v", summary.Counts)
+	}
+
+	if err := e.reactions.RemoveReaction(viewer, models.ReactionTargetPost, e.post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if summary, err = e.reactions.GetSummary(viewer, models.ReactionTargetPost, e.post.ID); err != nil || summary.Total != 3 || summary.ViewerReaction != "" {
+		t.Fatalf("after removal: %+v, %v", summary, err)
+	}
+}
+
+func TestBulkCountsByTarget(t *testing.T) {
+	e := setup(t)
+	other := &models.Post{Title: "other", AuthorID: uuid.New()}
+	if err := e.db.Create(other).Error; err != nil {
+		t.Fatal(err)
+	}
+	viewer := uuid.New()
+
+	for _, user := range []uuid.UUID{viewer, uuid.New()} {
+		if _, err := e.reactions.React(user, models.ReactionTargetPost, e.post.ID, models.ReactionLike); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the comment shares no count with the posts
+	if _, err := e.reactions.React(viewer, models.ReactionTargetComment, e.comment.ID, models.ReactionWow); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []uuid.UUID{e.post.ID, other.ID}
+	counts, err := e.repo.CountByTargets(models.ReactionTargetPost, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[e.post.ID] != 2 || counts[other.ID] != 0 {
+		t.Fatalf("counts %v", counts)
+	}
+
+	own, err := e.repo.FindUserReactions(viewer, models.ReactionTargetPost, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(own) != 1 || own[e.post.ID] != models.ReactionLike {
+		t.Fatalf("viewer reactions %v", own)
+	}
+}
+
+func TestMigrateLegacyLikes(t *testing.T) {
+	e := setup(t)
+	user := uuid.New()
+
+	if err := repository.MigrateLegacyLikes(e.db); err != nil {
+		t.Fatalf("without a likes table: %v", err)
+	}
+
+	err := e.db.Exec(`CREATE TABLE likes (id text, user_id text, post_id text, comment_id text, created_at timestamptz)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = e.db.Exec(`INSERT INTO likes VALUES
+		(?, ?, ?, '00000000-0000-0000-0000-000000000000', NOW()),
+		(?, ?, '00000000-0000-0000-0000-000000000000', ?, NOW())`,
+		uuid.NewString(), user.String(), e.post.ID.String(),
+		uuid.NewString(), user.String(), e.comment.ID.String()).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repository.MigrateLegacyLikes(e.db); err != nil {
+		t.Fatal(err)
+	}
+	if e.db.Migrator().HasTable("likes") {
+		t.Fatal("likes table kept")
+	}
+	for target, id := range map[models.ReactionTarget]uuid.UUID{models.ReactionTargetPost: e.post.ID, models.ReactionTargetComment: e.comment.ID} {
+		reaction, err := e.repo.FindUserReaction(user, target, id)
+		if err != nil || reaction == nil || reaction.Type != models.ReactionLike {
+			t.Fatalf("%s like: %+v, %v", target, reaction, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package realtime_test
+
+import (
+	"GoVersi/internal/realtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func ids(events []realtime.Event) []string {
+	var got []string
+	for _, event := range events {
+		got = append(got, event.ID)
+	}
+	return got
+}
+
+func TestSubscribeReturnsMissedEvents(t *testing.T) {
+	hub := realtime.NewMemoryHub(10, time.Minute)
+	user := uuid.New()
+	for i := 0; i < 3; i++ {
+		hub.Publish(user, "notification", i)
+	}
+
+	sub, backlog := hub.Subscribe(user, "1")
+	defer sub.Close()
+	if got := ids(backlog); len(got) != 2 || got[0] != "2" || got[1] != "3" {
+		t.Fatalf("backlog %v, want [2 3]", got)
+	}
+}
+
+func TestSubscribeResetsWhenHistoryWasTrimmed(t *testing.T) {
+	hub := realtime.NewMemoryHub(2, time.Minute)
+	user := uuid.New()
+	for i := 0; i < 4; i++ {
+		hub.Publish(user, "notification", i)
+	}
+
+	// event 2 was trimmed, so resuming after 1 would silently skip it
+	sub, backlog := hub.Subscribe(user, "1")
+	defer sub.Close()
+	if len(backlog) != 1 || backlog[0].Type != realtime.EventReset || backlog[0].ID != "4" {
+		t.Fatalf("backlog %+v, want a reset at 4", backlog)
+	}
+
+	// the events still retained resume normally
+	again, backlog := hub.Subscribe(user, "2")
+	defer again.Close()
+	if got := ids(backlog); len(got) != 2 || got[0] != "3" {
+		t.Fatalf("backlog %v, want [3 4]", got)
+	}
+}
+
+func TestSubscribeResetsAfterRestart(t *testing.T) {
+	hub := realtime.NewMemoryHub(10, time.Minute)
+	user := uuid.New()
+	hub.Publish(user, "notification", nil)
+
+	for _, lastEventID := range []string{"42", "not-a-number"} {
+		sub, backlog := hub.Subscribe(user, lastEventID)
+		sub.Close()
+		if len(backlog) != 1 || backlog[0].Type != realtime.EventReset || backlog[0].ID != "1" {
+			t.Errorf("last event id %q: backlog %+v, want a reset at 1", lastEventID, backlog)
+		}
+	}
+}
+
+func TestEvictIdleDropsStreamsWithoutConnections(t *testing.T) {
+	hub := realtime.NewMemoryHub(10, 10*time.Millisecond)
+	idle, connected := uuid.New(), uuid.New()
+	hub.Publish(idle, "notification", nil)
+	sub, _ := hub.Subscribe(connected, "")
+	defer sub.Close()
+
+	time.Sleep(20 * time.Millisecond)
+	if evicted := hub.EvictIdle(); evicted != 1 {
+		t.Fatalf("evicted %d streams, want 1", evicted)
+	}
+
+	// the evicted history is gone, so resuming from it resets
+	again, backlog := hub.Subscribe(idle, "0")
+	defer again.Close()
+	if len(backlog) != 1 || backlog[0].Type != realtime.EventReset {
+		t.Fatalf("backlog %+v, want a reset", backlog)
+	}
+
+	hub.Publish(connected, "notification", nil)
+	select {
+	case event := <-sub.Events:
+		if event.ID != "2" {
+			t.Fatalf("event %+v", event)
+		}
+	default:
+		t.Fatal("connected stream was evicted")
+	}
+}
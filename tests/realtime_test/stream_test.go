@@ -0,0 +1,185 @@
+package realtime_test
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/middleware"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/routes"
+	"GoVersi/internal/utils"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const secretKey = "stream-test-secret"
+
+type blacklist struct {
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func (b *blacklist) IsTokenBlacklisted(token string, expiresAt time.Time) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.revoked[token], nil
+}
+
+func (b *blacklist) revoke(token string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.revoked[token] = true
+}
+
+type streamEnv struct {
+	server    *httptest.Server
+	hub       *realtime.MemoryHub
+	blacklist *blacklist
+}
+
+func newStreamEnv(t *testing.T) *streamEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	e := &streamEnv{hub: realtime.NewMemoryHub(10, time.Minute), blacklist: &blacklist{revoked: map[string]bool{}}}
+	streamHandler := handlers.NewStreamHandler(e.hub, realtime.NewTicketStore(time.Minute), e.blacklist, 20*time.Millisecond)
+
+	router := gin.New()
+	auth := router.Group("/")
+	auth.Use(middleware.AuthMiddleware(secretKey, e.blacklist))
+	routes.SetupStreamRoutes(router, auth, secretKey, e.blacklist, streamHandler)
+
+	e.server = httptest.NewServer(router)
+	t.Cleanup(e.server.Close)
+	return e
+}
+
+func token(t *testing.T, userID uuid.UUID, ttl time.Duration) string {
+	t.Helper()
+	value, err := utils.GenerateJWT(userID.String(), uuid.NewString(), secretKey, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func (e *streamEnv) ticket(t *testing.T, bearer string) string {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, e.server.URL+"/stream/ticket", nil)
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("ticket: status %d", resp.StatusCode)
+	}
+	var body struct {
+		Ticket string `json:"ticket"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Ticket
+}
+
+// readUntil reads the stream until it contains want or ends, returning what was read
+func readUntil(t *testing.T, body io.Reader, want string) string {
+	t.Helper()
+
+	var read strings.Builder
+	buf := make([]byte, 1024)
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		n, err := body.Read(buf)
+		read.Write(buf[:n])
+		if strings.Contains(read.String(), want) || err != nil {
+			break
+		}
+	}
+	return read.String()
+}
+
+func TestStreamOpensWithATicket(t *testing.T) {
+	e := newStreamEnv(t)
+	user := uuid.New()
+	ticket := e.ticket(t, token(t, user, time.Hour))
+
+	resp, err := http.Get(e.server.URL + "/stream?ticket=" + ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+
+	e.hub.Publish(user, "notification", "hello")
+	if read := readUntil(t, resp.Body, "hello"); !strings.Contains(read, "event:notification") {
+		t.Fatalf("stream %q lacks the notification", read)
+	}
+
+	reused, err := http.Get(e.server.URL + "/stream?ticket=" + ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reused.Body.Close()
+	if reused.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("reused ticket: status %d, want 401", reused.StatusCode)
+	}
+}
+
+func TestStreamRequiresCredentials(t *testing.T) {
+	e := newStreamEnv(t)
+
+	resp, err := http.Get(e.server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestStreamClosesWhenTheTokenIsRevoked(t *testing.T) {
+	e := newStreamEnv(t)
+	bearer := token(t, uuid.New(), time.Hour)
+
+	req, _ := http.NewRequest(http.MethodGet, e.server.URL+"/stream", nil)
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	e.blacklist.revoke(bearer)
+	if read := readUntil(t, resp.Body, "token revoked"); !strings.Contains(read, "event:unauthorized") {
+		t.Fatalf("stream %q was not closed", read)
+	}
+}
+
+func TestStreamClosesWhenTheTokenExpires(t *testing.T) {
+	e := newStreamEnv(t)
+	ticket := e.ticket(t, token(t, uuid.New(), 2*time.Second))
+
+	resp, err := http.Get(e.server.URL + "/stream?ticket=" + ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if read := readUntil(t, resp.Body, "token expired"); !strings.Contains(read, "event:unauthorized") {
+		t.Fatalf("stream %q outlived its token", read)
+	}
+}
@@ -0,0 +1,54 @@
+package realtime_test
+
+import (
+	"GoVersi/internal/realtime"
+	"testing"
+	"time"
+)
+
+func grant(ttl time.Duration) realtime.StreamGrant {
+	return realtime.StreamGrant{UserID: "user", SessionID: "session", Token: "token", ExpiresAt: time.Now().Add(ttl)}
+}
+
+func TestTicketIsSingleUse(t *testing.T) {
+	store := realtime.NewTicketStore(time.Minute)
+	ticket, err := store.Issue(grant(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := store.Redeem(ticket)
+	if !ok || got.UserID != "user" || got.Token != "token" {
+		t.Fatalf("redeem: %+v, %v", got, ok)
+	}
+	if _, ok := store.Redeem(ticket); ok {
+		t.Fatal("ticket redeemed twice")
+	}
+	if _, ok := store.Redeem("unknown"); ok {
+		t.Fatal("unknown ticket redeemed")
+	}
+}
+
+func TestTicketExpires(t *testing.T) {
+	store := realtime.NewTicketStore(10 * time.Millisecond)
+	ticket, err := store.Issue(grant(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := store.Redeem(ticket); ok {
+		t.Fatal("expired ticket redeemed")
+	}
+}
+
+func TestTicketNeverOutlivesTheToken(t *testing.T) {
+	store := realtime.NewTicketStore(time.Hour)
+	ticket, err := store.Issue(grant(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := store.Redeem(ticket); ok {
+		t.Fatal("ticket outlived its access token")
+	}
+}
@@ -0,0 +1,63 @@
+package routes_test
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/routes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func commentRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	auth := router.Group("/")
+	auth.Use(func(c *gin.Context) { c.Set("user_id", uuid.NewString()) })
+	routes.SetupCommentRoutes(auth, handlers.NewCommentHandler(nil))
+	return router
+}
+
+func post(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// the service is never reached: both requests stop at the validation of the body,
+// which CreateComment only gets to once it has read the post id
+func TestDeprecatedCreateCommentRoute(t *testing.T) {
+	router := commentRouter()
+	postID := uuid.NewString()
+
+	current := post(router, "/posts/"+postID+"/comments")
+	alias := post(router, "/posts/comments/"+postID+"/create")
+	if current.Code != http.StatusBadRequest || alias.Code != current.Code || alias.Body.String() != current.Body.String() {
+		t.Fatalf("alias answered %d %s, current route %d %s", alias.Code, alias.Body, current.Code, current.Body)
+	}
+	if !strings.Contains(alias.Body.String(), "Invalid input") {
+		t.Fatalf("alias did not read the post id: %s", alias.Body)
+	}
+
+	if alias.Header().Get("Deprecation") != "true" {
+		t.Error("alias lacks the Deprecation header")
+	}
+	if want := "</posts/" + postID + "/comments>; rel=\"successor-version\""; alias.Header().Get("Link") != want {
+		t.Errorf("Link = %q, want %q", alias.Header().Get("Link"), want)
+	}
+	if current.Header().Get("Deprecation") != "" {
+		t.Error("current route marked deprecated")
+	}
+}
+
+func TestDeprecatedCreateCommentRouteRejectsBadPostID(t *testing.T) {
+	w := post(commentRouter(), "/posts/comments/not-a-uuid/create")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Invalid post ID") {
+		t.Fatalf("%d %s", w.Code, w.Body)
+	}
+}
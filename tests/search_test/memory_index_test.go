@@ -0,0 +1,130 @@
+package search_test
+
+import (
+	"GoVersi/internal/search"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func index(t *testing.T, idx search.SearchIndex, text string) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	if err := idx.Index(search.Document{ID: id, Type: search.TypePosts, Text: text}); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func find(t *testing.T, idx search.SearchIndex, text string, offset, limit int) []search.Hit {
+	t.Helper()
+	hits, err := idx.Search(search.Query{Text: text, Type: search.TypePosts, Offset: offset, Limit: limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hits
+}
+
+func TestMemoryIndexRanking(t *testing.T) {
+	idx := search.NewMemoryIndex()
+	once := index(t, idx, "gophers like go and many other languages around the world")
+	often := index(t, idx, "go go go")
+	index(t, idx, "rust and zig")
+
+	hits := find(t, idx, "go", 0, 10)
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(hits))
+	}
+	if hits[0].ID != often || hits[1].ID != once {
+		t.Fatalf("documents ranked %v, want the denser match first", hits)
+	}
+	if hits[0].Score <= hits[1].Score {
+		t.Fatalf("scores %v and %v are not decreasing", hits[0].Score, hits[1].Score)
+	}
+}
+
+func TestMemoryIndexRequiresEveryTerm(t *testing.T) {
+	idx := search.NewMemoryIndex()
+	both := index(t, idx, "concurrency in go")
+	index(t, idx, "concurrency in rust")
+
+	hits := find(t, idx, "Go concurrency", 0, 10)
+	if len(hits) != 1 || hits[0].ID != both {
+		t.Fatalf("got %v, want only the document with both terms", hits)
+	}
+}
+
+func TestMemoryIndexPagination(t *testing.T) {
+	idx := search.NewMemoryIndex()
+	for i := 0; i < 5; i++ {
+		index(t, idx, "topic "+strings.Repeat("word ", i))
+	}
+
+	all := find(t, idx, "topic", 0, 10)
+	if len(all) != 5 {
+		t.Fatalf("got %d hits, want 5", len(all))
+	}
+
+	var paged []search.Hit
+	for offset := 0; offset < 5; offset += 2 {
+		paged = append(paged, find(t, idx, "topic", offset, 2)...)
+	}
+	if len(paged) != len(all) {
+		t.Fatalf("pages hold %d hits, want %d", len(paged), len(all))
+	}
+	for i := range all {
+		if paged[i].ID != all[i].ID {
+			t.Fatalf("page order differs at %d", i)
+		}
+	}
+
+	if hits := find(t, idx, "topic", 10, 2); len(hits) != 0 {
+		t.Fatalf("offset past the end gave %d hits", len(hits))
+	}
+}
+
+func TestMemoryIndexRemove(t *testing.T) {
+	idx := search.NewMemoryIndex()
+	id := index(t, idx, "ephemeral post")
+	if err := idx.Remove(search.TypePosts, id); err != nil {
+		t.Fatal(err)
+	}
+	if hits := find(t, idx, "ephemeral", 0, 10); len(hits) != 0 {
+		t.Fatalf("removed document still found: %v", hits)
+	}
+}
+
+func TestMemoryIndexHighlight(t *testing.T) {
+	idx := search.NewMemoryIndex()
+	index(t, idx, "learning Go generics today")
+
+	hits := find(t, idx, "generics", 0, 10)
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+	if hits[0].Highlight != "learning Go <b>generics</b> today" {
+		t.Fatalf("highlight %q", hits[0].Highlight)
+	}
+}
+
+// user text is escaped, so a highlight is safe to render as HTML
+func TestMemoryIndexHighlightEscapesText(t *testing.T) {
+	idx := search.NewMemoryIndex()
+	index(t, idx, `exploit <img src=x onerror=alert(1)> <script>alert("x")</script> here`)
+	index(t, idx, "sneaky \x01marker\x02 exploit")
+
+	hits := find(t, idx, "exploit", 0, 10)
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(hits))
+	}
+	for _, hit := range hits {
+		html := strings.NewReplacer("<b>", "", "</b>", "").Replace(hit.Highlight)
+		if strings.ContainsAny(html, "<>\x01\x02") {
+			t.Fatalf("highlight %q carries markup or markers from the text", hit.Highlight)
+		}
+		if !strings.Contains(hit.Highlight, "<b>exploit</b>") {
+			t.Fatalf("highlight %q does not mark the match", hit.Highlight)
+		}
+	}
+}
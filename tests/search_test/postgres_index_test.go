@@ -0,0 +1,64 @@
+package search_test
+
+import (
+	"GoVersi/internal/models"
+	"GoVersi/internal/search"
+	"GoVersi/tests/testdb"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func postgresIndex(t *testing.T) (*gorm.DB, *search.PostgresIndex) {
+	db := testdb.Open(t, &models.User{}, &models.Post{}, &models.PostMedia{}, &models.Comment{})
+	if err := search.MigratePostgres(db, "simple"); err != nil {
+		t.Fatal(err)
+	}
+	return db, search.NewPostgresIndex(db, "simple")
+}
+
+func createPost(t *testing.T, db *gorm.DB, title, content string) uuid.UUID {
+	t.Helper()
+	post := models.Post{Title: title, Content: content, Topic: "general", AuthorID: uuid.New()}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	return post.ID
+}
+
+func TestPostgresIndexRankingAndPagination(t *testing.T) {
+	db, idx := postgresIndex(t)
+	dense := createPost(t, db, "go go go", "go")
+	sparse := createPost(t, db, "notes", "a long text that mentions go only once among many other words")
+	createPost(t, db, "rust", "nothing to see")
+
+	hits := find(t, idx, "go", 0, 10)
+	if len(hits) != 2 || hits[0].ID != dense || hits[1].ID != sparse {
+		t.Fatalf("got %v, want the denser match first", hits)
+	}
+
+	first := find(t, idx, "go", 0, 1)
+	second := find(t, idx, "go", 1, 1)
+	if len(first) != 1 || len(second) != 1 || first[0].ID != dense || second[0].ID != sparse {
+		t.Fatalf("pages %v and %v do not follow the ranking", first, second)
+	}
+}
+
+func TestPostgresIndexHighlightEscapesText(t *testing.T) {
+	db, idx := postgresIndex(t)
+	createPost(t, db, "exploit", `<img src=x onerror=alert(1)> exploit <script>alert("x")</script> sneaky `+"\x01marker\x02")
+
+	hits := find(t, idx, "exploit", 0, 10)
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+	highlight := hits[0].Highlight
+	if !strings.Contains(highlight, "<b>exploit</b>") {
+		t.Fatalf("highlight %q does not mark the match", highlight)
+	}
+	if text := strings.NewReplacer("<b>", "", "</b>", "").Replace(highlight); strings.ContainsAny(text, "<>\x01\x02") {
+		t.Fatalf("highlight %q carries markup or markers from the text", highlight)
+	}
+}
@@ -0,0 +1,257 @@
+package smtp_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	smtpUser     = "mailer"
+	smtpPassword = "s3cret"
+)
+
+// fakeSMTP is an SMTP server good enough for net/smtp: EHLO, STARTTLS, AUTH PLAIN
+// and LOGIN, MAIL, RCPT, DATA, RSET, NOOP and QUIT. Recipients starting with
+// "bounce" are refused with 550.
+type fakeSMTP struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	caFile    string
+
+	implicitTLS    bool   // TLS from the first byte, as on port 465
+	startTLS       bool   // offer STARTTLS
+	loginChallenge string // first LOGIN challenge, "Username:" when empty
+
+	mu          sync.Mutex
+	connections int
+	auths       []string // mechanisms clients authenticated with
+	messages    []fakeMessage
+	tlsSessions int // sessions that sent mail over TLS
+}
+
+type fakeMessage struct {
+	From, To string
+	Data     string
+}
+
+func newFakeSMTP(t *testing.T, configure func(*fakeSMTP)) *fakeSMTP {
+	t.Helper()
+
+	cert, caFile := selfSigned(t)
+	s := &fakeSMTP{
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		caFile:    caFile,
+	}
+	if configure != nil {
+		configure(s)
+	}
+
+	var err error
+	if s.implicitTLS {
+		s.listener, err = tls.Listen("tcp", "127.0.0.1:0", s.tlsConfig)
+	} else {
+		s.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.listener.Close() })
+
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+func (s *fakeSMTP) Messages() []fakeMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMessage(nil), s.messages...)
+}
+
+func (s *fakeSMTP) Auths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.auths...)
+}
+
+func (s *fakeSMTP) TLSSessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tlsSessions
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	_, secure := conn.(*tls.Conn)
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	}
+	readLine := func() (string, bool) {
+		line, err := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+
+	reply("220 fake.example ESMTP")
+	var from, to string
+	for {
+		line, ok := readLine()
+		if !ok {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"250-fake.example"}
+			if s.startTLS && !secure {
+				lines = append(lines, "250-STARTTLS")
+			}
+			reply(append(lines, "250 AUTH PLAIN LOGIN")...)
+		case "STARTTLS":
+			reply("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			r = bufio.NewReader(conn)
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			var user, password string
+			switch strings.ToUpper(mechanism) {
+			case "PLAIN":
+				decoded, _ := base64.StdEncoding.DecodeString(initial)
+				parts := strings.Split(string(decoded), "\x00")
+				if len(parts) == 3 {
+					user, password = parts[1], parts[2]
+				}
+			case "LOGIN":
+				challenge := s.loginChallenge
+				if challenge == "" {
+					challenge = "Username:"
+				}
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte(challenge)))
+				answer, _ := readLine()
+				decoded, _ := base64.StdEncoding.DecodeString(answer)
+				user = string(decoded)
+				reply("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				answer, _ = readLine()
+				decoded, _ = base64.StdEncoding.DecodeString(answer)
+				password = string(decoded)
+			}
+			if user != smtpUser || password != smtpPassword {
+				reply("535 5.7.8 authentication credentials invalid")
+				continue
+			}
+			s.mu.Lock()
+			s.auths = append(s.auths, strings.ToUpper(mechanism))
+			s.mu.Unlock()
+			reply("235 2.7.0 authenticated")
+		case "MAIL":
+			from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if strings.HasPrefix(to, "bounce") {
+				reply("550 5.1.1 no such user")
+				continue
+			}
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				line, ok := readLine()
+				if !ok {
+					return
+				}
+				if line == "." {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, ".") + "\n")
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, fakeMessage{From: from, To: to, Data: data.String()})
+			if secure {
+				s.tlsSessions++
+			}
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RSET", "NOOP":
+			from, to = "", ""
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// selfSigned makes a certificate for 127.0.0.1 and writes it to a PEM file
+func selfSigned(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, caFile
+}
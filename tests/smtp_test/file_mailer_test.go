@@ -0,0 +1,74 @@
+package smtp_test
+
+import (
+	"GoVersi/internal/service/email"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesEML(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := email.NewFileMailer(dir, "GoVerse <no-reply@goverse.example>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, to := range []string{"ana@example.com", "bia@example.com"} {
+		if err := mailer.Send(message(to)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%d files, want 2", len(entries))
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".eml") {
+			t.Fatalf("unexpected file %s", entry.Name())
+		}
+		f, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := mail.ReadMessage(f)
+		if err != nil {
+			f.Close()
+			t.Fatalf("%s: %v", entry.Name(), err)
+		}
+		if msg.Header.Get("Subject") != "Hello" || !strings.Contains(msg.Header.Get("From"), "no-reply@goverse.example") {
+			t.Errorf("%s: headers %v", entry.Name(), msg.Header)
+		}
+
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/alternative" {
+			t.Fatalf("%s: content type %q", entry.Name(), msg.Header.Get("Content-Type"))
+		}
+		var parts []string
+		reader := multipart.NewReader(msg.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			parts = append(parts, part.Header.Get("Content-Type"))
+		}
+		f.Close()
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "text/plain") || !strings.HasPrefix(parts[1], "text/html") {
+			t.Errorf("%s: parts %v", entry.Name(), parts)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package smtp_test
+
+import (
+	"GoVersi/internal/service/email"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMailer(t *testing.T, server *fakeSMTP, configure func(*email.SMTPConfig)) *email.SMTPMailer {
+	t.Helper()
+
+	cfg := email.SMTPConfig{
+		Host:         "127.0.0.1",
+		Port:         server.Port(),
+		From:         "GoVerse <no-reply@goverse.example>",
+		Security:     email.SMTPSecurityNone,
+		Auth:         email.SMTPAuthPlain,
+		CAFile:       server.caFile,
+		MaxIdleConns: 2,
+		IdleTimeout:  time.Minute,
+		Timeout:      5 * time.Second,
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	mailer, err := email.NewSMTPMailer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mailer.Close)
+	return mailer
+}
+
+func message(to string) email.EmailMessage {
+	return email.EmailMessage{To: to, Subject: "Hello", Body: "plain body", HTML: "<p>html body</p>"}
+}
+
+func withCredentials(auth string) func(*email.SMTPConfig) {
+	return func(cfg *email.SMTPConfig) {
+		cfg.Username, cfg.Password, cfg.Auth = smtpUser, smtpPassword, auth
+	}
+}
+
+func TestSMTPDelivers(t *testing.T) {
+	server := newFakeSMTP(t, nil)
+	mailer := newMailer(t, server, nil)
+
+	if err := mailer.Send(message("ana@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("server got %d emails", len(messages))
+	}
+	got := messages[0]
+	if got.From != "no-reply@goverse.example" || got.To != "ana@example.com" {
+		t.Errorf("envelope %s -> %s", got.From, got.To)
+	}
+	for _, part := range []string{"Subject: Hello", "plain body", "<p>html body</p>"} {
+		if !strings.Contains(got.Data, part) {
+			t.Errorf("message lacks %q", part)
+		}
+	}
+}
+
+func TestSMTPStartTLS(t *testing.T) {
+	server := newFakeSMTP(t, func(s *fakeSMTP) { s.startTLS = true })
+	mailer := newMailer(t, server, func(cfg *email.SMTPConfig) {
+		cfg.Security = email.SMTPSecuritySTARTTLS
+		withCredentials(email.SMTPAuthPlain)(cfg)
+	})
+
+	if err := mailer.Send(message("ana@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if server.TLSSessions() != 1 {
+		t.Fatal("email was not sent over TLS")
+	}
+	if auths := server.Auths(); len(auths) != 1 || auths[0] != "PLAIN" {
+		t.Fatalf("authenticated with %v, want PLAIN", auths)
+	}
+}
+
+func TestSMTPStartTLSRequired(t *testing.T) {
+	server := newFakeSMTP(t, nil)
+	mailer := newMailer(t, server, func(cfg *email.SMTPConfig) { cfg.Security = email.SMTPSecuritySTARTTLS })
+
+	err := mailer.Send(message("ana@example.com"))
+	if err == nil || len(server.Messages()) != 0 {
+		t.Fatal("email sent in clear text to a server without STARTTLS")
+	}
+	if email.IsPermanent(err) {
+		t.Fatalf("missing STARTTLS treated as permanent: %v", err)
+	}
+}
+
+func TestSMTPImplicitTLS(t *testing.T) {
+	server := newFakeSMTP(t, func(s *fakeSMTP) { s.implicitTLS = true })
+	mailer := newMailer(t, server, func(cfg *email.SMTPConfig) {
+		cfg.Security = email.SMTPSecurityTLS
+		withCredentials(email.SMTPAuthLogin)(cfg)
+	})
+
+	if err := mailer.Send(message("ana@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if server.TLSSessions() != 1 {
+		t.Fatal("email was not sent over TLS")
+	}
+	if auths := server.Auths(); len(auths) != 1 || auths[0] != "LOGIN" {
+		t.Fatalf("authenticated with %v, want LOGIN", auths)
+	}
+}
+
+func TestSMTPUntrustedCertificate(t *testing.T) {
+	server := newFakeSMTP(t, func(s *fakeSMTP) { s.implicitTLS = true })
+	mailer := newMailer(t, server, func(cfg *email.SMTPConfig) {
+		cfg.Security = email.SMTPSecurityTLS
+		cfg.CAFile = ""
+	})
+
+	if err := mailer.Send(message("ana@example.com")); err == nil {
+		t.Fatal("email sent to a server with an untrusted certificate")
+	}
+}
+
+// servers word the LOGIN challenges differently
+func TestSMTPLoginChallengeCase(t *testing.T) {
+	for _, challenge := range []string{"Username:", "username:", "USERNAME:", "User Name\x00"} {
+		server := newFakeSMTP(t, func(s *fakeSMTP) {
+			s.startTLS = true
+			s.loginChallenge = challenge
+		})
+		mailer := newMailer(t, server, func(cfg *email.SMTPConfig) {
+			cfg.Security = email.SMTPSecuritySTARTTLS
+			withCredentials(email.SMTPAuthLogin)(cfg)
+		})
+
+		if err := mailer.Send(message("ana@example.com")); err != nil {
+			t.Errorf("challenge %q: %v", challenge, err)
+		}
+	}
+}
+
+func TestSMTPAuthFailureIsRetried(t *testing.T) {
+	server := newFakeSMTP(t, func(s *fakeSMTP) { s.startTLS = true })
+	mailer := newMailer(t, server, func(cfg *email.SMTPConfig) {
+		cfg.Security = email.SMTPSecuritySTARTTLS
+		withCredentials(email.SMTPAuthPlain)(cfg)
+		cfg.Password = "wrong"
+	})
+
+	err := mailer.Send(message("ana@example.com"))
+	if err == nil || !strings.Contains(err.Error(), "535") {
+		t.Fatalf("err = %v, want the 535 reply", err)
+	}
+	if email.IsPermanent(err) {
+		t.Fatal("authentication failure treated as permanent")
+	}
+}
+
+func TestSMTPRejectedRecipientIsPermanent(t *testing.T) {
+	server := newFakeSMTP(t, nil)
+	mailer := newMailer(t, server, nil)
+
+	err := mailer.Send(message("bounce@example.com"))
+	if !email.IsPermanent(err) {
+		t.Fatalf("err = %v, want a permanent error", err)
+	}
+
+	// the session survives the rejection
+	if err := mailer.Send(message("ana@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.Connections(); n != 1 {
+		t.Fatalf("%d connections, want 1", n)
+	}
+}
+
+func TestSMTPReusesConnections(t *testing.T) {
+	server := newFakeSMTP(t, nil)
+	mailer := newMailer(t, server, nil)
+
+	for i := 0; i < 3; i++ {
+		if err := mailer.Send(message("ana@example.com")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(server.Messages()); n != 3 {
+		t.Fatalf("server got %d emails, want 3", n)
+	}
+	if n := server.Connections(); n != 1 {
+		t.Fatalf("%d connections, want 1", n)
+	}
+}
+
+func TestSMTPDropsIdleConnections(t *testing.T) {
+	server := newFakeSMTP(t, nil)
+	mailer := newMailer(t, server, func(cfg *email.SMTPConfig) { cfg.IdleTimeout = 50 * time.Millisecond })
+
+	if err := mailer.Send(message("ana@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := mailer.Send(message("ana@example.com")); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.Connections(); n != 2 {
+		t.Fatalf("%d connections, want a new one after the idle timeout", n)
+	}
+}
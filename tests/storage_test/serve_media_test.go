@@ -0,0 +1,61 @@
+package storage_test
+
+import (
+	"GoVersi/internal/handlers"
+	"GoVersi/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveSigned(t *testing.T, ttl time.Duration) *httptest.ResponseRecorder {
+	t.Helper()
+	store := storage.NewLocalStore(t.TempDir(), "/media", []byte("serve-test"))
+	if err := store.Put("images/a.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := store.SignedURL("images/a.png", ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/media/*key", handlers.NewMediaHandler(store).ServeMedia)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, signed, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	return w
+}
+
+func maxAge(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	value, ok := strings.CutPrefix(w.Header().Get("Cache-Control"), "private, max-age=")
+	if !ok {
+		t.Fatalf("Cache-Control %q", w.Header().Get("Cache-Control"))
+	}
+	age, err := strconv.Atoi(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return age
+}
+
+func TestServedMediaIsCachedForFiveMinutesAtMost(t *testing.T) {
+	if age := maxAge(t, serveSigned(t, time.Hour)); age != 300 {
+		t.Fatalf("max-age %d, want 300", age)
+	}
+}
+
+func TestServedMediaIsNotCachedPastTheURLExpiry(t *testing.T) {
+	if age := maxAge(t, serveSigned(t, time.Minute)); age > 60 || age < 58 {
+		t.Fatalf("max-age %d, want the 60s left on the URL", age)
+	}
+}
@@ -0,0 +1,233 @@
+package topic_test
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/utils"
+	"GoVersi/tests/testdb"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Go":                  "go",
+		"  Machine Learning ": "machine-learning",
+		"C++ & Rust!":         "c-rust",
+		"Été--à  Paris":       "été-à-paris",
+		"2024 Elections":      "2024-elections",
+		"!!!":                 "",
+	}
+	for in, want := range cases {
+		if got := utils.Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+type env struct {
+	db     *gorm.DB
+	topics *services.TopicService
+	posts  *services.PostService
+}
+
+func setup(t *testing.T) *env {
+	db := testdb.Open(t, &models.User{}, &models.Topic{}, &models.TopicFollow{}, &models.Post{}, &models.PostMedia{}, &models.MediaObject{})
+
+	topicRepo := repository.NewTopicRepository(db)
+	authz := services.NewAuthorizer(repository.NewUserRepository(db))
+	cfg := config.TopicConfig{TrendingWindow: 24 * time.Hour, MaxTrendingWindow: 7 * 24 * time.Hour}
+	posts := services.NewPostService(repository.NewPostRepository(db), repository.NewFriendshipRepository(db), topicRepo,
+		authz, realtime.NewMemoryHub(10, time.Minute), nil, nil, repository.NewMediaObjectRepository(db), config.PostConfig{MaxMedia: 4})
+
+	return &env{db: db, topics: services.NewTopicService(topicRepo, authz, cfg), posts: posts}
+}
+
+func (e *env) user(t *testing.T, role models.Role) uuid.UUID {
+	t.Helper()
+	name := uuid.NewString()[:8]
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "x", Role: role, IsActive: true}
+	if err := e.db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func (e *env) topic(t *testing.T, name string) *models.Topic {
+	t.Helper()
+	topic := &models.Topic{Slug: utils.Slugify(name), Name: name}
+	if err := e.db.Create(topic).Error; err != nil {
+		t.Fatal(err)
+	}
+	return topic
+}
+
+func TestOnlyModeratorsCreateTopics(t *testing.T) {
+	e := setup(t)
+
+	if _, err := e.topics.CreateTopic(e.user(t, models.RoleUser), "Go", "", ""); !errors.Is(err, services.ErrForbidden) {
+		t.Fatalf("user: %v, want ErrForbidden", err)
+	}
+
+	topic, err := e.topics.CreateTopic(e.user(t, models.RoleModerator), "  Machine Learning ", "", " all things ML ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if topic.Slug != "machine-learning" || topic.Name != "Machine Learning" || topic.Description != "all things ML" {
+		t.Fatalf("topic %+v", topic)
+	}
+
+	if _, err := e.topics.CreateTopic(e.user(t, models.RoleAdmin), "Machine learning", "", ""); !errors.Is(err, repository.ErrTopicExists) {
+		t.Fatalf("duplicate slug: %v, want ErrTopicExists", err)
+	}
+}
+
+func TestFollowIsIdempotent(t *testing.T) {
+	e := setup(t)
+	e.topic(t, "Go")
+	user := e.user(t, models.RoleUser)
+
+	for i := 0; i < 2; i++ {
+		if err := e.topics.Follow(user, "Go"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	view, err := e.topics.GetTopic(user, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !view.Following || view.FollowerCount != 1 {
+		t.Fatalf("after following twice: %+v", view)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := e.topics.Unfollow(user, "go"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if view, err = e.topics.GetTopic(user, "go"); err != nil || view.Following || view.FollowerCount != 0 {
+		t.Fatalf("after unfollowing twice: %+v, %v", view, err)
+	}
+
+	if err := e.topics.Follow(user, "rust"); !errors.Is(err, services.ErrTopicNotFound) {
+		t.Fatalf("unknown topic: %v, want ErrTopicNotFound", err)
+	}
+}
+
+func TestTrendingCountsPostsOfTheWindow(t *testing.T) {
+	e := setup(t)
+	e.topic(t, "Go")
+	e.topic(t, "Rust")
+	e.topic(t, "Quiet")
+
+	post := func(topic string, age time.Duration) {
+		p := &models.Post{Title: topic, Topic: topic, AuthorID: uuid.New(), CreatedAt: time.Now().Add(-age)}
+		if err := e.db.Create(p).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	post("go", time.Hour)
+	post("rust", time.Hour)
+	post("rust", 2*time.Hour)
+	post("go", 48*time.Hour)
+	post("go", 72*time.Hour)
+
+	trending, err := e.topics.Trending("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ""
+	for _, topic := range trending {
+		got += fmt.Sprintf("%s:%d ", topic.Slug, topic.PostCount)
+	}
+	if got != "rust:2 go:1 " {
+		t.Fatalf("trending over the default window: %s", got)
+	}
+
+	if trending, err = e.topics.Trending("96h", 10); err != nil || trending[0].Slug != "go" || trending[0].PostCount != 3 {
+		t.Fatalf("trending over 96h: %+v, %v", trending, err)
+	}
+
+	for _, window := range []string{"soon", "0s", "-1h", "200h"} {
+		if _, err := e.topics.Trending(window, 10); !errors.Is(err, services.ErrInvalidTrendingWindow) {
+			t.Errorf("window %q: %v, want ErrInvalidTrendingWindow", window, err)
+		}
+	}
+}
+
+func TestPostsReferenceTopicsBySlug(t *testing.T) {
+	e := setup(t)
+	e.topic(t, "Machine Learning")
+	author := e.user(t, models.RoleUser)
+
+	post, err := e.posts.CreatePost("title", "content", "  machine LEARNING!", nil, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Topic != "machine-learning" {
+		t.Fatalf("topic stored as %q", post.Topic)
+	}
+
+	for _, topic := range []string{"", "!!!", "cooking"} {
+		if _, err := e.posts.CreatePost("title", "content", topic, nil, author); !errors.Is(err, services.ErrUnknownTopic) {
+			t.Errorf("topic %q: %v, want ErrUnknownTopic", topic, err)
+		}
+	}
+}
+
+func TestUpdatePostKeepsTheTopicWhenEmpty(t *testing.T) {
+	e := setup(t)
+	e.topic(t, "Go")
+	e.topic(t, "Rust")
+	author := e.user(t, models.RoleUser)
+
+	post, err := e.posts.CreatePost("title", "content", "Go", nil, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := e.posts.UpdatePost(author, post.ID, &models.Post{Title: "new", Content: "content"}, nil)
+	if err != nil || updated.Topic != "go" {
+		t.Fatalf("empty topic: %q, %v", updated.Topic, err)
+	}
+	if updated, err = e.posts.UpdatePost(author, post.ID, &models.Post{Title: "new", Content: "content", Topic: "RUST"}, nil); err != nil || updated.Topic != "rust" {
+		t.Fatalf("new topic: %q, %v", updated.Topic, err)
+	}
+	if _, err := e.posts.UpdatePost(author, post.ID, &models.Post{Title: "new", Content: "content", Topic: "cooking"}, nil); !errors.Is(err, services.ErrUnknownTopic) {
+		t.Fatalf("unknown topic: %v, want ErrUnknownTopic", err)
+	}
+}
+
+func TestMigrateLegacyTopics(t *testing.T) {
+	e := setup(t)
+	for _, topic := range []string{"Machine Learning", "machine learning", "!!!"} {
+		if err := e.db.Create(&models.Post{Title: "old", Topic: topic, AuthorID: uuid.New()}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repository.MigrateLegacyTopics(e.db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var slugs []string
+	e.db.Model(&models.Topic{}).Order("slug").Pluck("slug", &slugs)
+	if fmt.Sprint(slugs) != "[machine-learning]" {
+		t.Fatalf("topics %v", slugs)
+	}
+	var topics []string
+	e.db.Model(&models.Post{}).Order("topic").Pluck("topic", &topics)
+	if fmt.Sprint(topics) != "[ machine-learning machine-learning]" {
+		t.Fatalf("post topics %v", topics)
+	}
+}
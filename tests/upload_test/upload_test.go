@@ -0,0 +1,321 @@
+package upload_test
+
+import (
+	"GoVersi/internal/config"
+	"GoVersi/internal/media"
+	"GoVersi/internal/models"
+	"GoVersi/internal/realtime"
+	"GoVersi/internal/repository"
+	services "GoVersi/internal/service"
+	"GoVersi/internal/storage"
+	"GoVersi/tests/testdb"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type env struct {
+	db      *gorm.DB
+	uploads *services.UploadService
+	cfg     config.ResumableUploadConfig
+	user    uuid.UUID
+	post    *models.Post
+}
+
+func setup(t *testing.T, ttl time.Duration) *env {
+	db := testdb.Open(t, &models.User{}, &models.Post{}, &models.PostMedia{}, &models.MediaObject{}, &models.UploadSession{})
+
+	user := uuid.New()
+	post := &models.Post{Title: "trip", AuthorID: user}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	refs := repository.NewMediaObjectRepository(db)
+	posts := services.NewPostService(repository.NewPostRepository(db), repository.NewFriendshipRepository(db), repository.NewTopicRepository(db),
+		services.NewAuthorizer(repository.NewUserRepository(db)), realtime.NewMemoryHub(10, time.Minute), nil, nil, refs, config.PostConfig{MaxMedia: 4})
+
+	cfg := config.ResumableUploadConfig{Dir: t.TempDir(), MaxSizeMB: 1, SessionTTL: ttl}
+	store := storage.NewLocalStore(t.TempDir(), "/media", []byte("upload-test"))
+	uploads, err := services.NewUploadService(repository.NewUploadSessionRepository(db), posts, store, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &env{db: db, uploads: uploads, cfg: cfg, user: user, post: post}
+}
+
+// video is the smallest file detected as MP4: an ftyp box and padding
+func video() []byte {
+	header := []byte{0, 0, 0, 0x18, 'f', 't', 'y', 'p', 'i', 's', 'o', 'm', 0, 0, 2, 0, 'i', 's', 'o', 'm', 'm', 'p', '4', '1'}
+	return append(header, bytes.Repeat([]byte{0}, 1000)...)
+}
+
+func (e *env) partial(t *testing.T, id uuid.UUID) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(e.cfg.Dir, id.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// upload creates a session for content and sends it in one chunk
+func (e *env) upload(t *testing.T, content []byte) *models.UploadSession {
+	t.Helper()
+	session, err := e.uploads.CreateSession(e.user, int64(len(content)), "clip.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err = e.uploads.WriteChunk(e.user, session.ID, 0, bytes.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestCreateSessionChecksTheSize(t *testing.T) {
+	e := setup(t, time.Hour)
+
+	if _, err := e.uploads.CreateSession(e.user, 0, "clip.mp4"); !errors.Is(err, services.ErrInvalidUpload) {
+		t.Fatalf("empty upload: %v, want ErrInvalidUpload", err)
+	}
+	if _, err := e.uploads.CreateSession(e.user, e.uploads.MaxSize()+1, "clip.mp4"); !errors.Is(err, services.ErrUploadTooLarge) {
+		t.Fatalf("oversize upload: %v, want ErrUploadTooLarge", err)
+	}
+}
+
+func TestChunksMustStartAtTheCurrentOffset(t *testing.T) {
+	e := setup(t, time.Hour)
+	session, err := e.uploads.CreateSession(e.user, 10, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session, err = e.uploads.WriteChunk(e.user, session.ID, 0, strings.NewReader("hello")); err != nil || session.Offset != 5 {
+		t.Fatalf("first chunk: offset %d, %v", session.Offset, err)
+	}
+
+	// a replayed or skipped chunk is refused and the current offset returned
+	for _, offset := range []int64{0, 7} {
+		got, err := e.uploads.WriteChunk(e.user, session.ID, offset, strings.NewReader("xxxxx"))
+		if !errors.Is(err, services.ErrOffsetMismatch) || got.Offset != 5 {
+			t.Fatalf("chunk at %d: offset %d, %v, want ErrOffsetMismatch at 5", offset, got.Offset, err)
+		}
+	}
+
+	if session, err = e.uploads.WriteChunk(e.user, session.ID, 5, strings.NewReader("world")); err != nil || session.Offset != 10 {
+		t.Fatalf("second chunk: offset %d, %v", session.Offset, err)
+	}
+	if got := string(e.partial(t, session.ID)); got != "helloworld" {
+		t.Fatalf("partial file %q", got)
+	}
+}
+
+func TestChunkPastTheAnnouncedSizeIsRefused(t *testing.T) {
+	e := setup(t, time.Hour)
+	session, err := e.uploads.CreateSession(e.user, 10, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err = e.uploads.WriteChunk(e.user, session.ID, 0, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	// one byte too many: nothing of the chunk is kept
+	got, err := e.uploads.WriteChunk(e.user, session.ID, 5, strings.NewReader("world!"))
+	if !errors.Is(err, services.ErrUploadTooLarge) || got.Offset != 5 {
+		t.Fatalf("overflowing chunk: offset %d, %v, want ErrUploadTooLarge at 5", got.Offset, err)
+	}
+	if data := string(e.partial(t, session.ID)); data != "hello" {
+		t.Fatalf("partial file %q, want the overflow truncated", data)
+	}
+
+	// exactly the remaining bytes are accepted
+	if session, err = e.uploads.WriteChunk(e.user, session.ID, 5, strings.NewReader("world")); err != nil || session.Offset != 10 {
+		t.Fatalf("last chunk: offset %d, %v", session.Offset, err)
+	}
+}
+
+// brokenReader delivers data then fails, as a dropped connection does
+type brokenReader struct {
+	data []byte
+	done bool
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.done = true
+	return copy(p, r.data), nil
+}
+
+func TestResumeAfterAnInterruptedWrite(t *testing.T) {
+	e := setup(t, time.Hour)
+	session, err := e.uploads.CreateSession(e.user, 10, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := e.uploads.WriteChunk(e.user, session.ID, 0, &brokenReader{data: []byte("hell")})
+	if !errors.Is(err, io.ErrUnexpectedEOF) || got.Offset != 4 {
+		t.Fatalf("interrupted chunk: offset %d, %v, want the received bytes kept", got.Offset, err)
+	}
+	stored, err := e.uploads.GetSession(e.user, session.ID)
+	if err != nil || stored.Offset != 4 {
+		t.Fatalf("stored offset %d, %v", stored.Offset, err)
+	}
+
+	// bytes written past the recorded offset by a crashed writer are dropped
+	f, err := os.OpenFile(filepath.Join(e.cfg.Dir, session.ID.String()), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("GARBAGE")
+	f.Close()
+
+	if _, err := e.uploads.WriteChunk(e.user, session.ID, 4, strings.NewReader("oworld")); err != nil {
+		t.Fatal(err)
+	}
+	if data := string(e.partial(t, session.ID)); data != "helloworld" {
+		t.Fatalf("resumed file %q", data)
+	}
+}
+
+func TestUploadsOfOtherUsersAreMissing(t *testing.T) {
+	e := setup(t, time.Hour)
+	session, err := e.uploads.CreateSession(e.user, 10, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger := uuid.New()
+
+	if _, err := e.uploads.GetSession(stranger, session.ID); !errors.Is(err, services.ErrUploadNotFound) {
+		t.Errorf("get: %v, want ErrUploadNotFound", err)
+	}
+	if _, err := e.uploads.WriteChunk(stranger, session.ID, 0, strings.NewReader("x")); !errors.Is(err, services.ErrUploadNotFound) {
+		t.Errorf("write: %v, want ErrUploadNotFound", err)
+	}
+	if _, err := e.uploads.Finalize(stranger, session.ID, e.post.ID, ""); !errors.Is(err, services.ErrUploadNotFound) {
+		t.Errorf("finalize: %v, want ErrUploadNotFound", err)
+	}
+	if err := e.uploads.Cancel(stranger, session.ID); !errors.Is(err, services.ErrUploadNotFound) {
+		t.Errorf("cancel: %v, want ErrUploadNotFound", err)
+	}
+}
+
+func TestFinalizeAttachesTheVideo(t *testing.T) {
+	e := setup(t, time.Hour)
+	content := video()
+
+	session, err := e.uploads.CreateSession(e.user, int64(len(content)), "clip.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.uploads.WriteChunk(e.user, session.ID, 0, bytes.NewReader(content[:100])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.uploads.Finalize(e.user, session.ID, e.post.ID, ""); !errors.Is(err, services.ErrUploadIncomplete) {
+		t.Fatalf("incomplete finalize: %v, want ErrUploadIncomplete", err)
+	}
+	if _, err := e.uploads.WriteChunk(e.user, session.ID, 100, bytes.NewReader(content[100:])); err != nil {
+		t.Fatal(err)
+	}
+
+	post, err := e.uploads.Finalize(e.user, session.ID, e.post.ID, "the beach")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(post.Media) != 1 {
+		t.Fatalf("post has %d attachments, want 1", len(post.Media))
+	}
+	attachment := post.Media[0]
+	if attachment.Type != models.MediaTypeVideo || attachment.MimeType != "video/mp4" || attachment.AltText != "the beach" ||
+		!strings.HasSuffix(string(attachment.URL), ".mp4") {
+		t.Fatalf("attachment %+v", attachment)
+	}
+
+	if _, err := os.Stat(filepath.Join(e.cfg.Dir, session.ID.String())); !os.IsNotExist(err) {
+		t.Fatal("partial file kept after finalizing")
+	}
+	if _, err := e.uploads.Finalize(e.user, session.ID, e.post.ID, ""); !errors.Is(err, services.ErrUploadFinalized) {
+		t.Fatalf("second finalize: %v, want ErrUploadFinalized", err)
+	}
+}
+
+func TestFinalizeValidatesTheContent(t *testing.T) {
+	e := setup(t, time.Hour)
+	session := e.upload(t, []byte("#!/bin/sh\necho renamed script\n"))
+
+	_, err := e.uploads.Finalize(e.user, session.ID, e.post.ID, "")
+	var unsupported *media.UnsupportedMediaError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("finalize of a script: %v, want UnsupportedMediaError", err)
+	}
+
+	var count int64
+	e.db.Model(&models.PostMedia{}).Where("post_id = ?", e.post.ID).Count(&count)
+	if count != 0 {
+		t.Fatal("invalid upload was attached")
+	}
+}
+
+func TestFinalizeOnAPostOfSomeoneElse(t *testing.T) {
+	e := setup(t, time.Hour)
+	other := &models.Post{Title: "not mine", AuthorID: uuid.New()}
+	if err := e.db.Create(other).Error; err != nil {
+		t.Fatal(err)
+	}
+	session := e.upload(t, video())
+
+	if _, err := e.uploads.Finalize(e.user, session.ID, other.ID, ""); !errors.Is(err, services.ErrForbidden) {
+		t.Fatalf("finalize on another post: %v, want ErrForbidden", err)
+	}
+	// the upload stays available for another post
+	if _, err := e.uploads.Finalize(e.user, session.ID, e.post.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanupStaleRemovesExpiredSessions(t *testing.T) {
+	e := setup(t, -time.Minute)
+	session, err := e.uploads.CreateSession(e.user, 10, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := e.uploads.CleanupStale()
+	if err != nil || removed != 1 {
+		t.Fatalf("removed %d sessions: %v", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(e.cfg.Dir, session.ID.String())); !os.IsNotExist(err) {
+		t.Fatal("partial file of an expired session kept")
+	}
+	var count int64
+	e.db.Model(&models.UploadSession{}).Where("id = ?", session.ID).Count(&count)
+	if count != 0 {
+		t.Fatal("expired session kept")
+	}
+}
+
+func TestCleanupStaleKeepsActiveSessions(t *testing.T) {
+	e := setup(t, time.Hour)
+	session, err := e.uploads.CreateSession(e.user, 10, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, err := e.uploads.CleanupStale(); err != nil || removed != 0 {
+		t.Fatalf("removed %d sessions: %v", removed, err)
+	}
+	if _, err := e.uploads.GetSession(e.user, session.ID); err != nil {
+		t.Fatal(err)
+	}
+}